@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+)
+
+const principalKey ctxKey = "principal"
+
+// Principal is the signed in user the request is made by.
+type Principal struct {
+	UserID uint
+	// Permissions are the names of permissions the user has.
+	Permissions map[string]bool
+}
+
+// TokenDecoder returns the user the access token was issued to.
+type TokenDecoder func(token string) (Principal, *errdef.Error)
+
+// Bearer authenticates requests by the access token in the Authorization
+// header and puts the user into the request context. Requests without
+// a token are passed on anonymously, RequirePermission refuses them
+// where a signed in user is needed. Requests for which public returns
+// true are passed on without authentication, even with a token.
+func Bearer(decode TokenDecoder, public func(*http.Request) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if public != nil && public(req) {
+				next.ServeHTTP(w, req)
+				return
+			}
+			if req.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+			token, ok := bearerToken(req)
+			if !ok {
+				unauthenticated(w, req, errdef.ErrUnauthenticated("access token is not a Bearer token"))
+				return
+			}
+			p, errSet := decode(token)
+			if errSet != nil {
+				unauthenticated(w, req, errSet)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(WithPrincipal(req.Context(), p)))
+		})
+	}
+}
+
+// bearerToken returns the token of the Bearer Authorization header.
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func unauthenticated(w http.ResponseWriter, req *http.Request, errSet *errdef.Error) {
+	if errdef.IsUnauthenticated(errSet) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	httpio.WriteErr(w, req, errSet)
+}
+
+// RequirePermission passes on only requests of users who have the
+// permission, others get errdef PermissionDenied. It has to run after
+// Bearer.
+func RequirePermission(permission string) Middleware {
+	return RequirePermissionOr(permission, nil)
+}
+
+// RequirePermissionOr is RequirePermission which passes on requests of
+// users without the permission too, if allow returns true for them,
+// e.g. when users ask for their own data.
+func RequirePermissionOr(permission string, allow func(req *http.Request, p Principal) (bool, *errdef.Error)) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			p, ok := PrincipalFrom(req.Context())
+			if !ok {
+				unauthenticated(w, req, errdef.ErrUnauthenticated("access token is required"))
+				return
+			}
+			if !p.Permissions[permission] {
+				allowed := false
+				if allow != nil {
+					var errSet *errdef.Error
+					if allowed, errSet = allow(req, p); errSet != nil {
+						httpio.WriteErr(w, req, errSet)
+						return
+					}
+				}
+				if !allowed {
+					httpio.WriteErr(w, req, errdef.ErrPermissionDeniedf("%s permission is required", permission).
+						WithMeta("permission", permission))
+					return
+				}
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// WithPrincipal returns copy of the context with the signed in user.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFrom returns the user signed in by Bearer.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
+
+// UserID returns ID of the user signed in by Bearer.
+func UserID(ctx context.Context) (uint, bool) {
+	p, ok := PrincipalFrom(ctx)
+	return p.UserID, ok
+}
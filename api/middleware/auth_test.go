@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func TestBearer(t *testing.T) {
+	decode := func(token string) (Principal, *errdef.Error) {
+		if token != "good" {
+			return Principal{}, errdef.ErrUnauthenticated("access token is not valid")
+		}
+		return Principal{UserID: 7}, nil
+	}
+	public := func(r *http.Request) bool { return r.URL.Path == "/login" }
+	h := Bearer(decode, public)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := UserID(r.Context())
+		w.Write([]byte(strconv.FormatUint(uint64(id), 10)))
+	}))
+
+	for _, tc := range []struct {
+		path, header string
+		status       int
+		body         string
+	}{
+		{"/", "Bearer good", http.StatusOK, "7"},
+		{"/", "bearer  good", http.StatusOK, "7"},
+		{"/", "", http.StatusOK, "0"},
+		{"/login", "Bearer bad", http.StatusOK, "0"},
+		{"/", "good", http.StatusUnauthorized, "access token is not a Bearer token"},
+		{"/", "Basic good", http.StatusUnauthorized, "access token is not a Bearer token"},
+		{"/", "Bearer bad", http.StatusUnauthorized, "access token is not valid"},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		if tc.header != "" {
+			r.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.header)
+		assert.Contains(t, w.Body.String(), tc.body, tc.header)
+		if tc.status == http.StatusUnauthorized {
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	h := RequirePermission("books:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, tc := range []struct {
+		principal *Principal
+		status    int
+	}{
+		{nil, http.StatusUnauthorized},
+		{&Principal{UserID: 1}, http.StatusForbidden},
+		{&Principal{UserID: 1, Permissions: map[string]bool{"books:read": true}}, http.StatusForbidden},
+		{&Principal{UserID: 1, Permissions: map[string]bool{"books:write": true}}, http.StatusOK},
+	} {
+		r := httptest.NewRequest("POST", "/", nil)
+		if tc.principal != nil {
+			r = r.WithContext(WithPrincipal(r.Context(), *tc.principal))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.principal)
+		if tc.status == http.StatusForbidden {
+			assert.Contains(t, w.Body.String(), "books:write permission is required")
+		}
+	}
+}
+
+func TestRequirePermissionOr(t *testing.T) {
+	own := func(r *http.Request, p Principal) (bool, *errdef.Error) {
+		if r.URL.Path == "/fail" {
+			return false, errdef.ErrInternal("failed to load user")
+		}
+		return r.URL.Path == "/user/"+strconv.FormatUint(uint64(p.UserID), 10), nil
+	}
+	h := RequirePermissionOr("records:read", own)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	staff := Principal{UserID: 2, Permissions: map[string]bool{"records:read": true}}
+	for _, tc := range []struct {
+		principal *Principal
+		path      string
+		status    int
+	}{
+		{nil, "/user/1", http.StatusUnauthorized},
+		{&Principal{UserID: 1}, "/user/1", http.StatusOK},
+		{&Principal{UserID: 1}, "/user/2", http.StatusForbidden},
+		{&Principal{UserID: 1}, "/fail", http.StatusInternalServerError},
+		{&staff, "/user/1", http.StatusOK},
+		{&staff, "/fail", http.StatusOK},
+	} {
+		r := httptest.NewRequest("GET", tc.path, nil)
+		if tc.principal != nil {
+			r = r.WithContext(WithPrincipal(r.Context(), *tc.principal))
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, tc.status, w.Code, tc.path)
+	}
+}
+
+func TestParamUint(t *testing.T) {
+	router := mux.NewRouter()
+	router.Handle("/book/{id}", ParamUint("id")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := Param(r.Context(), "id")
+		w.Write([]byte(strconv.FormatUint(uint64(id), 10)))
+	})))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/book/12", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "12", w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/book/twelve", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "id is not valid")
+}
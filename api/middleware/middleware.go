@@ -0,0 +1,10 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps handler, it can be passed to mux.Router.Use.
+type Middleware func(http.Handler) http.Handler
+
+// ctxKey is the type of keys of values the middlewares put into the
+// request context.
+type ctxKey string
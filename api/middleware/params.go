@@ -5,13 +5,10 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/coinfinitygroup/cfapp/api/shared"
-	"github.com/coinfinitygroup/cfapp/pkg/errdef"
-	"github.com/coinfinitygroup/cfapp/pkg/httpio"
+	"github.com/gorilla/mux"
 
-	"github.com/coinfinitygroup/cfapp/pkg/logger"
-	"github.com/go-chi/chi"
-	"github.com/sirupsen/logrus"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
 )
 
 // ParamUint parses uint from route
@@ -22,27 +19,30 @@ func ParamUint(paramName string) Middleware {
 			// vytáhne context z requestu
 			ctx := req.Context()
 			// vytáhne z URL parametry podle předaného názvu parametru z argumentu
-			code := chi.URLParam(req, paramName)
-			// převede parametry do uint64 aby bylo možné je použít ve funkci with value k 
+			code := mux.Vars(req)[paramName]
+			// převede parametry do uint64 aby bylo možné je použít ve funkci with value
 			param, err := strconv.ParseUint(code, 10, 64)
 			if err != nil {
-				errSet := errdef.NewErrData(paramName, "is not valid")
-				// pokud mají parametry špatně formát odpoví ze servu error 
-				httpio.WriteJSONErrSet(w, errSet)
+				// pokud mají parametry špatně formát odpoví ze servu error
+				httpio.WriteErr(w, req, errdef.ErrInvalidArgumentf("%s is not valid", paramName).WithMeta("field", paramName))
 				return
 			}
-			// obohatí kontext o loger
-			ctx = logger.CtxFields(ctx, logrus.Fields{
-				paramName: param,
-			})
-			// umožnuje sdílet data, vytvoří nový kontext v zavislosti na poskytnutém rodiči 
-			// přidává do takto vytvořeného kontextu novou hodnotu na poskytnutý key, jsou tam data uložená jako klíč a hodnota 
+			// umožnuje sdílet data, vytvoří nový kontext v zavislosti na poskytnutém rodiči
+			// přidává do takto vytvořeného kontextu novou hodnotu na poskytnutý key, jsou tam data uložená jako klíč a hodnota
 			// které lze později vytváhnout a pracovat s nimi
-			// ukládá tam klíč předaný do funkce jako argument prohnaný funkcí ParamCTx ze složky shared, hodnotou jsou parametry vytažené z
-			// url převedené do formátu uint
-			ctx = context.WithValue(ctx, shared.ParamCtx(paramName), uint(param))
-			// píše headrs a value do odpovědi 
+			ctx = context.WithValue(ctx, paramKey(paramName), uint(param))
+			// píše headrs a value do odpovědi
 			next.ServeHTTP(w, req.WithContext(ctx))
 		})
 	}
 }
+
+// Param returns route parameter parsed by ParamUint.
+func Param(ctx context.Context, paramName string) (uint, bool) {
+	param, ok := ctx.Value(paramKey(paramName)).(uint)
+	return param, ok
+}
+
+func paramKey(paramName string) ctxKey {
+	return ctxKey("param." + paramName)
+}
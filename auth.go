@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/investapp/backend/api/middleware"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/crypto"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+)
+
+// authenticator signs users in with short lived access tokens and
+// refresh tokens to get new ones with. Sign in is off while it has no
+// signer.
+type authenticator struct {
+	signer crypto.TokenSigner
+	// keys is the signer when tokens are signed with private keys, its
+	// public keys are published.
+	keys       *crypto.KeySet
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// newAuthenticator creates authenticator of the configuration. Private
+// keys are loaded from the key file, so all replicas sign and verify
+// with the same keys. Without the file they are generated at start,
+// which is meant for development: access tokens signed before a restart
+// or by another replica are not valid and clients get new ones by refresh.
+func newAuthenticator(cfg config.Auth) (authenticator, error) {
+	a := authenticator{accessTTL: cfg.AccessTokenTTL, refreshTTL: cfg.RefreshTokenTTL}
+	if cfg.Asymmetric() && cfg.KeyRotation < crypto.JWKSMaxAge {
+		return a, errdef.ErrInvalidArgumentf("auth.key_rotation must be at least %s, the next key is published a rotation before it signs", crypto.JWKSMaxAge)
+	}
+	switch {
+	case cfg.KeyFile != "":
+		data, err := ioutil.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return a, errdef.Wrap(err, errdef.CodeInvalidArgument, "failed to read auth.key_file")
+		}
+		keys, err := crypto.LoadKeySet(cfg.Algorithm, cfg.KeyOverlap, data)
+		if err != nil {
+			return a, errdef.Wrapf(err, errdef.CodeInvalidArgument, "auth.key_file is not valid: %s", err)
+		}
+		a.signer, a.keys = keys, keys
+	case cfg.Asymmetric():
+		log.Println("auth.key_file is not set, signing keys are generated and tokens don't survive restarts")
+		keys, err := crypto.NewKeySet(cfg.Algorithm, cfg.KeyOverlap)
+		if err != nil {
+			return a, err
+		}
+		a.signer, a.keys = keys, keys
+	case cfg.Secret != "":
+		a.signer = crypto.Secret(cfg.Secret)
+	}
+	return a, nil
+}
+
+// enabled tells if users can sign in.
+func (a authenticator) enabled() bool {
+	return a.signer != nil
+}
+
+type loginRequest struct {
+	// Login is the username or a verified email of the user.
+	Login    string
+	Password string
+}
+
+// accessToken is the response of a successful sign in.
+type accessToken struct {
+	AccessToken string
+	TokenType   string
+	// ExpiresIn is the number of seconds the token is valid for.
+	ExpiresIn int64
+	// RefreshToken is exchanged for a new access token once this one
+	// expires. It can be used once, the response of the refresh has
+	// the next refresh token.
+	RefreshToken string
+}
+
+// refreshRequest carries the refresh token to refresh and logout.
+type refreshRequest struct {
+	RefreshToken string
+}
+
+// errLoginFailed doesn't tell if the user exists.
+func errLoginFailed() *errdef.Error {
+	return errdef.ErrUnauthenticated("login or password is not valid").WithProcess(user.ProcessName)
+}
+
+// errRefreshFailed doesn't tell why the refresh token is not valid.
+func errRefreshFailed() *errdef.Error {
+	return errdef.ErrUnauthenticated("refresh token is not valid").WithProcess(user.ProcessName)
+}
+
+// issue creates access token of the user.
+func (a authenticator) issue(userID uint) (accessToken, *errdef.Error) {
+	token, err := a.signer.CreateToken(userID, a.accessTTL)
+	if err != nil {
+		return accessToken{}, errdef.Wrap(err, errdef.CodeInternal, "failed to create access token")
+	}
+	return accessToken{AccessToken: token, TokenType: "Bearer", ExpiresIn: int64(a.accessTTL / time.Second)}, nil
+}
+
+// verify returns ID of the user the access token was issued to.
+func (a authenticator) verify(token string) (uint, *errdef.Error) {
+	if !a.enabled() {
+		return 0, errdef.ErrUnavailable("sign in is not configured")
+	}
+	userID, err := a.signer.DecodeToken(token)
+	if err != nil {
+		return 0, errdef.ErrUnauthenticated("access token is not valid").WithProcess(user.ProcessName)
+	}
+	return userID, nil
+}
+
+// authenticate returns the user the access token was issued to with the
+// permissions of the user's role. The role is loaded on every request,
+// so role changes take effect right away.
+func (s *server) authenticate(token string) (middleware.Principal, *errdef.Error) {
+	userID, errSet := s.auth.verify(token)
+	if errSet != nil {
+		return middleware.Principal{}, errSet
+	}
+	u, errSet := s.store.Users().Get(userID)
+	if errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			errSet = errdef.ErrUnauthenticated("access token is not valid").WithProcess(user.ProcessName)
+		}
+		return middleware.Principal{}, errSet
+	}
+	p := middleware.Principal{UserID: u.ID, Permissions: map[string]bool{}}
+	for _, perm := range u.Role.Permissions() {
+		p.Permissions[string(perm)] = true
+	}
+	return p, nil
+}
+
+// login signs the user in by the username or a verified email and the
+// password, and returns an access token.
+func (s *server) login(w http.ResponseWriter, r *http.Request) {
+	if !s.auth.enabled() {
+		httpio.WriteErr(w, r, errdef.ErrUnavailable("sign in is not configured"))
+		return
+	}
+	var req loginRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if req.Login == "" || req.Password == "" {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("login and password are required"))
+		return
+	}
+
+	u, errSet := s.store.Users().ByLogin(req.Login)
+	if errSet != nil && !errdef.IsNotFound(errSet) {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if !checkPassword(u, errSet == nil, req.Password) {
+		httpio.WriteErr(w, r, errLoginFailed())
+		return
+	}
+	if errSet := s.store.Users().SignedIn(u.ID, time.Now().UTC()); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	token, errSet := s.startSession(s.store, u.ID, "", time.Now().UTC())
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &token)
+}
+
+// startSession issues access token and refresh token of the family to
+// the user, a new family is started if family is empty.
+func (s *server) startSession(tx store.Store, userID uint, family string, now time.Time) (accessToken, *errdef.Error) {
+	token, errSet := s.auth.issue(userID)
+	if errSet != nil {
+		return accessToken{}, errSet
+	}
+	rt, refresh, errSet := user.NewRefreshToken(userID, family, s.auth.refreshTTL, now)
+	if errSet != nil {
+		return accessToken{}, errSet
+	}
+	if errSet := tx.RefreshTokens().Create(&rt); errSet != nil {
+		return accessToken{}, errSet
+	}
+	token.RefreshToken = refresh
+	return token, nil
+}
+
+// refresh exchanges the refresh token for a new access token and a new
+// refresh token. A refresh token which was already used is a sign it
+// leaked, so all tokens of its sign in are revoked.
+func (s *server) refresh(w http.ResponseWriter, r *http.Request) {
+	if !s.auth.enabled() {
+		httpio.WriteErr(w, r, errdef.ErrUnavailable("sign in is not configured"))
+		return
+	}
+	req, errSet := readRefreshRequest(r)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	now := time.Now().UTC()
+	rt, errSet := s.store.RefreshTokens().ByHash(crypto.HashToken(req.RefreshToken))
+	if errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			errSet = errRefreshFailed()
+		}
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if rt.RotatedAt != nil && rt.RevokedAt == nil {
+		s.revokeReused(rt, now)
+	}
+	if !rt.Active(now) {
+		httpio.WriteErr(w, r, errRefreshFailed())
+		return
+	}
+
+	var token accessToken
+	err := s.store.Transaction(func(tx store.Store) error {
+		if errSet := tx.RefreshTokens().Rotate(rt.ID, now); errSet != nil {
+			return errSet
+		}
+		next, errSet := s.startSession(tx, rt.UserID, rt.Family, now)
+		if errSet != nil {
+			return errSet
+		}
+		token = next
+		return nil
+	})
+	if errdef.IsFailedPrecondition(err) {
+		// the token was used by another request in the meantime
+		s.revokeReused(rt, now)
+		httpio.WriteErr(w, r, errRefreshFailed())
+		return
+	}
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &token)
+}
+
+// revokeReused revokes the family of the refresh token used twice.
+func (s *server) revokeReused(rt user.RefreshToken, now time.Time) {
+	log.Printf("refresh token of user %d was used twice, revoking its family", rt.UserID)
+	if errSet := s.store.RefreshTokens().RevokeFamily(rt.Family, now); errSet != nil {
+		log.Println("failed to revoke refresh tokens:", errSet)
+	}
+}
+
+// logout revokes the refresh token and all tokens of the same sign in.
+// Access tokens stay valid until they expire.
+func (s *server) logout(w http.ResponseWriter, r *http.Request) {
+	req, errSet := readRefreshRequest(r)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	rt, errSet := s.store.RefreshTokens().ByHash(crypto.HashToken(req.RefreshToken))
+	if errSet == nil {
+		errSet = s.store.RefreshTokens().RevokeFamily(rt.Family, time.Now().UTC())
+	} else if errdef.IsNotFound(errSet) {
+		// nothing to revoke, the client is signed out either way
+		errSet = nil
+	}
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// logoutAll revokes all refresh tokens of the signed in user, signing
+// them out on every device once the access tokens expire.
+func (s *server) logoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		httpio.WriteErr(w, r, errdef.ErrUnauthenticated("access token is required"))
+		return
+	}
+	if errSet := s.store.RefreshTokens().RevokeUser(userID, time.Now().UTC()); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getJWKS returns the public keys access tokens are verified with, so
+// other services can verify them. The set is empty when tokens are
+// signed with the secret.
+func (s *server) getJWKS(w http.ResponseWriter, r *http.Request) {
+	set := crypto.JWKS{Keys: []crypto.JWK{}}
+	if s.auth.keys != nil {
+		set = s.auth.keys.JWKS()
+	}
+	// keys are rotated, caches should not keep them for long
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(crypto.JWKSMaxAge/time.Second)))
+	httpio.WriteJSON(w, http.StatusOK, &set)
+}
+
+// rotateKeys replaces the signing key every interval until ctx is done.
+// Keys of the key file are reloaded instead, they are rotated by
+// changing the file: a new first key is published on the first reload
+// and signs from the second one.
+func rotateKeys(ctx context.Context, keys *crypto.KeySet, keyFile string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if keyFile != "" {
+			data, err := ioutil.ReadFile(keyFile)
+			if err == nil {
+				err = keys.Load(data)
+			}
+			if err != nil {
+				log.Println("failed to reload signing keys:", err)
+			}
+			continue
+		}
+		if err := keys.Rotate(); err != nil {
+			log.Println("failed to rotate signing key:", err)
+			continue
+		}
+		log.Println("rotated signing key")
+	}
+}
+
+func readRefreshRequest(r *http.Request) (refreshRequest, *errdef.Error) {
+	var req refreshRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		return req, errSet
+	}
+	if req.RefreshToken == "" {
+		return req, errdef.ErrInvalidArgument("refresh token is required").WithMeta("field", "refresh_token")
+	}
+	return req, nil
+}
+
+var (
+	decoyOnce sync.Once
+	decoy     user.User
+)
+
+// checkPassword compares the password with the one of the user. When
+// there is no user or the user has no password, it is compared with
+// a decoy, so the failure takes as long as a wrong password does.
+func checkPassword(u user.User, found bool, password string) bool {
+	if found && u.HasPwd() {
+		return u.ComparePwd(password)
+	}
+	decoyOnce.Do(func() {
+		if errSet := decoy.SetPwd("decoy password of nobody"); errSet != nil {
+			log.Println("failed to hash decoy password:", errSet)
+		}
+	})
+	decoy.ComparePwd(password)
+	return false
+}
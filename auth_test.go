@@ -0,0 +1,419 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/store/memstore"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/models/user/contact"
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/crypto"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newAuthServer returns test server with staff user jack as well,
+// whose password is "librarian", with a verified and a not verified email.
+func newAuthServer(t *testing.T) *server {
+	srv := newTestServer(t, memstore.New())
+	u := user.User{Username: "jack", Role: user.Staff, Contacts: contact.Contacts{
+		{Channel: contact.Email, Contact: "Jack@Gmail.com", Verified: true},
+		{Channel: contact.Email, Contact: "jack@work.com"},
+	}}
+	require.Nil(t, u.SetPwd("librarian"))
+	require.Nil(t, srv.store.Users().Save(&u))
+	return srv
+}
+
+func TestLogin(t *testing.T) {
+	srv := newAuthServer(t)
+	for _, login := range []string{"Jack", "jack@gmail.com", "JACK@gmail.com"} {
+		w := do(t, srv, "POST", "/auth/login", `{"Login":"`+login+`","Password":"librarian"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var token accessToken
+		decode(t, w, &token)
+		assert.Equal(t, "Bearer", token.TokenType)
+		assert.Equal(t, int64(60), token.ExpiresIn)
+		assert.NotEmpty(t, token.RefreshToken)
+		id, err := crypto.DecodeToken(testSecret, token.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), id)
+	}
+	u, errSet := srv.store.Users().Get(2)
+	require.Nil(t, errSet)
+	require.NotNil(t, u.LastSignedAt)
+
+	for _, body := range []string{
+		`{"Login":"jack","Password":"wrong password"}`,
+		`{"Login":"jack@work.com","Password":"librarian"}`,
+		`{"Login":"jane","Password":"librarian"}`,
+	} {
+		w := do(t, srv, "POST", "/auth/login", body)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, body)
+		assert.Contains(t, w.Body.String(), "login or password is not valid")
+	}
+	w := do(t, srv, "POST", "/auth/login", `{"Login":"jack"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	srv.auth.signer = nil
+	w = do(t, srv, "POST", "/auth/login", `{"Login":"jack","Password":"librarian"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+// signIn signs jack in and returns the tokens.
+func signIn(t *testing.T, srv *server) accessToken {
+	t.Helper()
+	w := do(t, srv, "POST", "/auth/login", `{"Login":"jack","Password":"librarian"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var token accessToken
+	decode(t, w, &token)
+	return token
+}
+
+// refresh exchanges the refresh token and returns the response.
+func refresh(t *testing.T, srv *server, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	return do(t, srv, "POST", "/auth/refresh", `{"RefreshToken":"`+refreshToken+`"}`)
+}
+
+func TestAccessToken(t *testing.T) {
+	srv := newAuthServer(t)
+	token := signIn(t, srv)
+
+	w := doAs(t, srv, token.AccessToken, "POST", "/create/person", `{"Name":"Jane","Email":"jane@gmail.com"}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doAs(t, srv, "", "GET", "/books", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	expired, err := crypto.CreateToken(testSecret, 2, -time.Second)
+	require.NoError(t, err)
+	forged, err := crypto.CreateToken("fedcba9876543210fedcba9876543210", 2, time.Minute)
+	require.NoError(t, err)
+	for _, token := range []string{"", "not a token", expired, forged} {
+		for _, route := range []struct{ method, path string }{
+			{"POST", "/create/person"},
+			{"PATCH", "/update/person/1"},
+			{"DELETE", "/delete/person/1"},
+			{"GET", "/people"},
+		} {
+			w = doAs(t, srv, token, route.method, route.path, `{"Name":"Joe","Email":"joe@gmail.com"}`)
+			assert.Equal(t, http.StatusUnauthorized, w.Code, route.path)
+			assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	srv.auth.signer = nil
+	w = doAs(t, srv, token.AccessToken, "POST", "/create/person", `{"Name":"Joe","Email":"joe@gmail.com"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+}
+
+func TestRefreshToken(t *testing.T) {
+	srv := newAuthServer(t)
+	first := signIn(t, srv)
+
+	w := refresh(t, srv, first.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var second accessToken
+	decode(t, w, &second)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	id, err := crypto.DecodeToken(testSecret, second.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), id)
+
+	// the token is stored hashed
+	_, errSet := srv.store.RefreshTokens().ByHash(second.RefreshToken)
+	assert.True(t, errdef.IsNotFound(errSet))
+
+	// another sign in is not affected by the reuse below
+	other := signIn(t, srv)
+
+	// the first token was rotated, using it again revokes the family
+	w = refresh(t, srv, first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "refresh token is not valid")
+	w = refresh(t, srv, second.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = refresh(t, srv, other.RefreshToken)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, body := range []string{`{}`, `{"RefreshToken":`} {
+		w = do(t, srv, "POST", "/auth/refresh", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	w = refresh(t, srv, "unknown")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	rt, expired, errSet := user.NewRefreshToken(2, "", -time.Second, time.Now())
+	require.Nil(t, errSet)
+	require.Nil(t, srv.store.RefreshTokens().Create(&rt))
+	w = refresh(t, srv, expired)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+}
+
+func TestLogout(t *testing.T) {
+	srv := newAuthServer(t)
+	phone, laptop, tablet := signIn(t, srv), signIn(t, srv), signIn(t, srv)
+
+	w := do(t, srv, "POST", "/auth/logout", `{"RefreshToken":"`+phone.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	w = refresh(t, srv, phone.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/auth/logout", `{"RefreshToken":"`+phone.RefreshToken+`"}`)
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = refresh(t, srv, laptop.RefreshToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &laptop)
+
+	w = doAs(t, srv, "", "POST", "/auth/logout/all", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = doAs(t, srv, tablet.AccessToken, "POST", "/auth/logout/all", "")
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	for _, token := range []accessToken{laptop, tablet} {
+		w = refresh(t, srv, token.RefreshToken)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	srv := newAuthServer(t)
+	jill := user.User{Username: "jill"}
+	require.Nil(t, jill.SetPwd("bookworm"))
+	require.Nil(t, srv.store.Users().Save(&jill))
+	w := do(t, srv, "POST", "/auth/login", `{"Login":"jill","Password":"bookworm"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var member accessToken
+	decode(t, w, &member)
+	staff := signIn(t, srv)
+
+	body := `{"Name":"Joe","Email":"joe@gmail.com"}`
+	w = doAs(t, srv, member.AccessToken, "POST", "/create/person", body)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "people:write permission is required")
+	w = doAs(t, srv, member.AccessToken, "GET", "/books", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doAs(t, srv, staff.AccessToken, "POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// only admins change roles, staff can't promote anyone
+	target := "/update/user/" + strconv.Itoa(int(jill.ID)) + "/role"
+	w = doAs(t, srv, staff.AccessToken, "PUT", target, `{"Role":"staff"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = doAs(t, srv, member.AccessToken, "PUT", target, `{"Role":"admin"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = do(t, srv, "PUT", target, `{"Role":"staff"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"role":"staff"`)
+	assert.NotContains(t, w.Body.String(), "password")
+	// the role is in effect without signing in again
+	w = doAs(t, srv, member.AccessToken, "POST", "/create/person", body)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for _, body := range []string{`{}`, `{"Role":"root"}`} {
+		w = do(t, srv, "PUT", target, body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+	w = do(t, srv, "PUT", "/update/user/99/role", `{"Role":"staff"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	// there is always an admin left to change roles
+	admin, errSet := srv.store.Users().ByLogin(testUsername)
+	require.Nil(t, errSet)
+	self := "/update/user/" + strconv.Itoa(int(admin.ID)) + "/role"
+	w = do(t, srv, "PUT", self, `{"Role":"member"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "last admin")
+	w = do(t, srv, "PUT", self, `{"Role":"admin"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "PUT", target, `{"Role":"admin"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "PUT", self, `{"Role":"member"}`)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doAs(t, srv, member.AccessToken, "PUT", target, `{"Role":"staff"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
+
+func TestPrivateReads(t *testing.T) {
+	srv := newAuthServer(t)
+	jill := user.User{Username: "jill", Contacts: contact.Contacts{
+		{Channel: contact.Email, Contact: "jill@gmail.com", Verified: true},
+	}}
+	require.Nil(t, jill.SetPwd("bookworm"))
+	require.Nil(t, srv.store.Users().Save(&jill))
+	w := do(t, srv, "POST", "/auth/login", `{"Login":"jill","Password":"bookworm"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var member accessToken
+	decode(t, w, &member)
+	staff := signIn(t, srv)
+	do(t, srv, "POST", "/create/person", `{"Name":"Jill","Email":"Jill@gmail.com"}`)
+	do(t, srv, "POST", "/create/person", `{"Name":"Joe","Email":"joe@gmail.com"}`)
+
+	for _, path := range []string{"/export/people", "/reports/loans", "/trash/people", "/trash/books"} {
+		w = doAs(t, srv, "", "GET", path, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		w = doAs(t, srv, member.AccessToken, "GET", path, "")
+		assert.Equal(t, http.StatusForbidden, w.Code, path)
+		w = doAs(t, srv, staff.AccessToken, "GET", path, "")
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+
+	// people see their own loans and fines only
+	for _, kind := range []string{"loans", "holds", "recommendations", "balance", "fines"} {
+		w = doAs(t, srv, "", "GET", "/person/1/"+kind, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code, kind)
+		w = doAs(t, srv, member.AccessToken, "GET", "/person/1/"+kind, "")
+		assert.Equal(t, http.StatusOK, w.Code, kind)
+		w = doAs(t, srv, member.AccessToken, "GET", "/person/2/"+kind, "")
+		assert.Equal(t, http.StatusForbidden, w.Code, kind)
+		w = doAs(t, srv, staff.AccessToken, "GET", "/person/2/"+kind, "")
+		assert.Equal(t, http.StatusOK, w.Code, kind)
+	}
+	w = doAs(t, srv, member.AccessToken, "GET", "/person/99/loans", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// loans and holds show who borrowed the book
+	for _, step := range []struct{ path, body string }{
+		{"/create/book", `{"Title":"Go","CallNumber":1}`},
+		{"/create/branch", `{"Code":"MAIN","Name":"Main library"}`},
+		{"/create/copy", `{"BookID":1,"Barcode":"0001","HomeBranchID":1}`},
+		{"/checkout/book/1", `{"PersonID":1}`},
+		{"/hold/book/1", `{"PersonID":2}`},
+	} {
+		w = do(t, srv, "POST", step.path, step.body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	for _, tc := range []struct {
+		path   string
+		member int
+	}{
+		{"/people", http.StatusForbidden},
+		{"/person/1", http.StatusOK},
+		{"/person/2", http.StatusForbidden},
+		{"/loan/1", http.StatusOK},
+		{"/hold/1", http.StatusForbidden},
+		{"/book/1/loans", http.StatusForbidden},
+		{"/copy/1/loans", http.StatusForbidden},
+		{"/book/1/holds", http.StatusForbidden},
+	} {
+		w = doAs(t, srv, "", "GET", tc.path, "")
+		assert.Equal(t, http.StatusUnauthorized, w.Code, tc.path)
+		w = doAs(t, srv, member.AccessToken, "GET", tc.path, "")
+		assert.Equal(t, tc.member, w.Code, tc.path)
+		w = doAs(t, srv, staff.AccessToken, "GET", tc.path, "")
+		assert.Equal(t, http.StatusOK, w.Code, tc.path)
+	}
+
+	// the catalog is public, but a token sent along has to be valid
+	w = doAs(t, srv, "", "GET", "/books", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doAs(t, srv, member.AccessToken, "GET", "/book/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doAs(t, srv, "not a token", "GET", "/books", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestKeyFile(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	cfg := config.Default().Auth
+	cfg.Algorithm = crypto.EdDSA
+	cfg.KeyFile = filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, ioutil.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+
+	// a restarted server or another replica verifies the tokens
+	srv := newAuthServer(t)
+	srv.auth, err = newAuthenticator(cfg)
+	require.NoError(t, err)
+	token := signIn(t, srv)
+	replica, err := newAuthenticator(cfg)
+	require.NoError(t, err)
+	id, errSet := replica.verify(token.AccessToken)
+	require.Nil(t, errSet)
+	assert.Equal(t, uint(2), id)
+
+	// reloads publish a new key a rotation before it signs too
+	short := cfg
+	short.KeyRotation = time.Minute
+	_, err = newAuthenticator(short)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(cfg.KeyFile, []byte("not a key"), 0600))
+	_, err = newAuthenticator(cfg)
+	assert.Error(t, err)
+}
+
+func TestJWKS(t *testing.T) {
+	srv := newAuthServer(t)
+	w := do(t, srv, "GET", "/.well-known/jwks.json", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+
+	cfg := config.Default().Auth
+	cfg.Algorithm = crypto.EdDSA
+	auth, err := newAuthenticator(cfg)
+	require.NoError(t, err)
+	srv.auth = auth
+	token := signIn(t, srv)
+
+	w = do(t, srv, "GET", "/.well-known/jwks.json", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "public, max-age=300", w.Header().Get("Cache-Control"))
+	var set crypto.JWKS
+	decode(t, w, &set)
+	require.Len(t, set.Keys, 2)
+	assert.Equal(t, "OKP", set.Keys[0].KeyType)
+	x, err := base64.RawURLEncoding.DecodeString(set.Keys[0].X)
+	require.NoError(t, err)
+	parsed, err := jwt.Parse(token.AccessToken, func(t *jwt.Token) (interface{}, error) {
+		if t.Header["kid"] != set.Keys[0].ID {
+			return nil, errors.New("unknown key")
+		}
+		return ed25519.PublicKey(x), nil
+	})
+	require.NoError(t, err)
+	assert.True(t, parsed.Valid)
+
+	// tokens signed before the rotation stay valid and the key which
+	// signs after it was published before
+	require.NoError(t, auth.keys.Rotate())
+	w = doAs(t, srv, token.AccessToken, "POST", "/create/person", `{"Name":"Jane","Email":"jane@gmail.com"}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	rotated := signIn(t, srv)
+	parsed, _, err = new(jwt.Parser).ParseUnverified(rotated.AccessToken, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.NotEqual(t, set.Keys[0].ID, parsed.Header["kid"])
+	assert.Equal(t, set.Keys[1].ID, parsed.Header["kid"])
+	hmac, err := crypto.CreateToken(testSecret, 2, time.Minute)
+	require.NoError(t, err)
+	w = doAs(t, srv, hmac, "POST", "/create/person", `{"Name":"Joe","Email":"joe@gmail.com"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+
+	cfg.KeyRotation = time.Minute
+	_, err = newAuthenticator(cfg)
+	assert.Error(t, err)
+
+	cfg = config.Default().Auth
+	auth, err = newAuthenticator(cfg)
+	require.NoError(t, err)
+	assert.False(t, auth.enabled())
+}
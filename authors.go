@@ -0,0 +1,273 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// authorFields are the fields of Author clients can write.
+var authorFields = []string{"Name"}
+
+func (s *server) getAuthors(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, author.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	authors, total, errSet := s.store.Authors().Find(store.AuthorFilter{Name: q.Get("name")}, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(authors), func(i int) []interface{} {
+		return authors[i].SortValues(params.Sort)
+	})
+	authors = authors[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &authors, Paging: page})
+}
+
+func (s *server) getAuthor(w http.ResponseWriter, r *http.Request) {
+	authorID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	a, errSet := s.store.Authors().Get(authorID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &a)
+}
+
+func (s *server) createAuthor(w http.ResponseWriter, r *http.Request) {
+	var input author.Author
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var created author.Author
+	copyFields(&created, &input, authorFields)
+	if errSet := saveAuthor(s.store, &created); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
+
+// replaceAuthor replaces all writable fields of the author.
+func (s *server) replaceAuthor(w http.ResponseWriter, r *http.Request) {
+	var input author.Author
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.updateAuthor(w, r, func(a *author.Author) *errdef.Error {
+		copyFields(a, &input, authorFields)
+		return nil
+	})
+}
+
+// patchAuthor applies JSON merge patch to the author.
+func (s *server) patchAuthor(w http.ResponseWriter, r *http.Request) {
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
+	}
+	s.updateAuthor(w, r, func(a *author.Author) *errdef.Error {
+		return applyPatch(a, patch, authorFields)
+	})
+}
+
+// updateAuthor changes the author and rebuilds bylines of the books
+// the author is credited on, so they show the new name.
+func (s *server) updateAuthor(w http.ResponseWriter, r *http.Request, change func(*author.Author) *errdef.Error) {
+	authorID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var updated author.Author
+	err := s.store.Transaction(func(tx store.Store) error {
+		a, errSet := tx.Authors().Lock(authorID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := change(&a); errSet != nil {
+			return errSet
+		}
+		if errSet := saveAuthor(tx, &a); errSet != nil {
+			return errSet
+		}
+		bookIDs, errSet := tx.Authors().BookIDs(authorID)
+		if errSet != nil {
+			return errSet
+		}
+		for _, bookID := range bookIDs {
+			if errSet := refreshByline(tx, bookID); errSet != nil {
+				return errSet
+			}
+		}
+		updated = a
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}
+
+// deleteAuthor deletes the author who is not credited on any book.
+func (s *server) deleteAuthor(w http.ResponseWriter, r *http.Request) {
+	authorID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var deleted author.Author
+	err := s.store.Transaction(func(tx store.Store) error {
+		a, errSet := tx.Authors().Lock(authorID)
+		if errSet != nil {
+			return errSet
+		}
+		bookIDs, errSet := tx.Authors().BookIDs(authorID)
+		if errSet != nil {
+			return errSet
+		}
+		if len(bookIDs) > 0 {
+			return errdef.ErrFailedPreconditionf("author %d is credited on %d books, remove the credits first", authorID, len(bookIDs)).
+				WithProcess(author.ProcessName)
+		}
+		if errSet := tx.Authors().Delete(authorID); errSet != nil {
+			return errSet
+		}
+		deleted = a
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &deleted)
+}
+
+// setBookAuthors replaces credits of the book by the list in the body,
+// the authors are credited in the order they are listed. The byline of
+// the book is rebuilt from them, a book without credits keeps its byline.
+func (s *server) setBookAuthors(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	var credits []author.Credit
+	if errSet := httpio.ReadJSON(r, &credits); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	author.Order(bookID, credits)
+	if errSet := author.ValidateCredits(credits); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var updated book.Book
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Books().Lock(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		for _, c := range credits {
+			if _, errSet := tx.Authors().Get(c.AuthorID); errSet != nil {
+				if errdef.IsNotFound(errSet) {
+					return errdef.ErrInvalidArgumentf("author %d does not exist", c.AuthorID).WithMeta("field", "authors")
+				}
+				return errSet
+			}
+		}
+		if errSet := tx.Authors().SetCredits(bookID, credits); errSet != nil {
+			return errSet
+		}
+		if errSet := saveBook(tx, &b); errSet != nil {
+			return errSet
+		}
+		if errSet := loadAuthors(tx, &b); errSet != nil {
+			return errSet
+		}
+		updated = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}
+
+// saveAuthor validates and stores the author.
+func saveAuthor(tx store.Store, a *author.Author) *errdef.Error {
+	a.Sanitize()
+	if errSet := a.Validate(); errSet != nil {
+		return errSet
+	}
+	return tx.Authors().Save(a)
+}
+
+// loadAuthors sets credits of the books. Books without credits get
+// an empty list, so clients always get the list.
+func loadAuthors(tx store.Store, books ...*book.Book) *errdef.Error {
+	ids := make([]uint, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+	credits, errSet := tx.Authors().Credits(ids...)
+	if errSet != nil {
+		return errSet
+	}
+	for _, b := range books {
+		b.Authors = credits[b.ID]
+		if b.Authors == nil {
+			b.Authors = []author.Credit{}
+		}
+	}
+	return nil
+}
+
+// refreshByline rebuilds byline of the book from its credits.
+// Deleted books are skipped, they are refreshed when they are saved.
+func refreshByline(tx store.Store, bookID uint) *errdef.Error {
+	b, errSet := tx.Books().Lock(bookID)
+	if errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			return nil
+		}
+		return errSet
+	}
+	credits, errSet := tx.Authors().Credits(bookID)
+	if errSet != nil {
+		return errSet
+	}
+	if len(credits[bookID]) == 0 {
+		return nil
+	}
+	b.Author = author.Byline(credits[bookID])
+	return tx.Books().Save(&b)
+}
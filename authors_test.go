@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestAuthors(t *testing.T) {
+	st := memstore.New()
+	srv := newTestServer(t, st)
+
+	for _, name := range []string{"Terry  Pratchett", "Neil Gaiman", "Jan Zábrana"} {
+		w := do(t, srv, "POST", "/create/author", `{"Name":"`+name+`"}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := do(t, srv, "POST", "/create/author", `{"Name":" "}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Good Omens","Author":"T. Pratchett","CallNumber":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Coraline","Author":"Neil Gaiman","CallNumber":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// credits are ordered as listed and the byline is built from them
+	w = do(t, srv, "PUT", "/update/book/1/authors", `[{"AuthorID":1},{"AuthorID":2,"Role":"author"},{"AuthorID":3,"Role":"translator"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var b book.Book
+	decode(t, w, &b)
+	assert.Equal(t, "Terry Pratchett, Neil Gaiman", b.Author)
+	assert.Equal(t, []author.Credit{
+		{BookID: 1, AuthorID: 1, Role: author.Writer, Position: 1, Name: "Terry Pratchett"},
+		{BookID: 1, AuthorID: 2, Role: author.Writer, Position: 2, Name: "Neil Gaiman"},
+		{BookID: 1, AuthorID: 3, Role: author.Translator, Position: 3, Name: "Jan Zábrana"},
+	}, b.Authors)
+
+	w = do(t, srv, "PUT", "/update/book/2/authors", `[{"AuthorID":9}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "author does not exist")
+	w = do(t, srv, "PUT", "/update/book/2/authors", `[{"AuthorID":2},{"AuthorID":2}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "the same credit twice")
+	w = do(t, srv, "PUT", "/update/book/2/authors", `[{"AuthorID":2,"Role":"illustrator"}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown role")
+	w = do(t, srv, "PUT", "/update/book/2/authors", `[{"AuthorID":2}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// books list credits and can be filtered by author
+	w = do(t, srv, "GET", "/books?author_id=2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var books struct{ Data []book.Book }
+	decode(t, w, &books)
+	require.Len(t, books.Data, 2)
+	assert.Len(t, books.Data[0].Authors, 3)
+	assert.Len(t, books.Data[1].Authors, 1)
+
+	// renaming the author rebuilds the bylines
+	w = do(t, srv, "PATCH", "/update/author/2", `{"name":"Neil Richard Gaiman"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/book/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &b)
+	assert.Equal(t, "Terry Pratchett, Neil Richard Gaiman", b.Author)
+	assert.Equal(t, "Neil Richard Gaiman", b.Authors[1].Name)
+
+	// byline of the book with credits can't be written directly
+	w = do(t, srv, "PATCH", "/update/book/2", `{"Author":"Somebody"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &b)
+	assert.Equal(t, "Neil Richard Gaiman", b.Author)
+
+	// credited author can't be deleted
+	w = do(t, srv, "DELETE", "/delete/author/2", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = do(t, srv, "PUT", "/update/book/2/authors", `[]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &b)
+	assert.Empty(t, b.Authors)
+	assert.Equal(t, "Neil Richard Gaiman", b.Author, "book without credits keeps its byline")
+	w = do(t, srv, "PUT", "/update/book/1/authors", `[{"AuthorID":1}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "DELETE", "/delete/author/2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/authors?name=gaiman", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var authors struct{ Data []author.Author }
+	decode(t, w, &authors)
+	assert.Empty(t, authors.Data)
+}
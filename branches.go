@@ -0,0 +1,217 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// branchFields are the fields of Branch clients can write.
+var branchFields = []string{"Code", "Name", "Address"}
+
+func (s *server) getBranches(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, branch.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	branches, total, errSet := s.store.Branches().Find(store.BranchFilter{Name: q.Get("name")}, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(branches), func(i int) []interface{} {
+		return branches[i].SortValues(params.Sort)
+	})
+	branches = branches[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &branches, Paging: page})
+}
+
+func (s *server) getBranch(w http.ResponseWriter, r *http.Request) {
+	branchID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	b, errSet := s.store.Branches().Get(branchID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &b)
+}
+
+func (s *server) createBranch(w http.ResponseWriter, r *http.Request) {
+	var input branch.Branch
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var created branch.Branch
+	copyFields(&created, &input, branchFields)
+	if errSet := saveBranch(s.store, &created); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
+
+// replaceBranch replaces all writable fields of the branch.
+func (s *server) replaceBranch(w http.ResponseWriter, r *http.Request) {
+	var input branch.Branch
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.updateBranch(w, r, func(b *branch.Branch) *errdef.Error {
+		copyFields(b, &input, branchFields)
+		return nil
+	})
+}
+
+// patchBranch applies JSON merge patch to the branch.
+func (s *server) patchBranch(w http.ResponseWriter, r *http.Request) {
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
+	}
+	s.updateBranch(w, r, func(b *branch.Branch) *errdef.Error {
+		return applyPatch(b, patch, branchFields)
+	})
+}
+
+func (s *server) updateBranch(w http.ResponseWriter, r *http.Request, change func(*branch.Branch) *errdef.Error) {
+	branchID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var updated branch.Branch
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Branches().Lock(branchID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := change(&b); errSet != nil {
+			return errSet
+		}
+		if errSet := saveBranch(tx, &b); errSet != nil {
+			return errSet
+		}
+		updated = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}
+
+// deleteBranch deletes the branch which has no copies, transfers
+// under way and no holds to be picked up there.
+func (s *server) deleteBranch(w http.ResponseWriter, r *http.Request) {
+	branchID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var deleted branch.Branch
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Branches().Lock(branchID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := checkBranchUnused(tx, branchID); errSet != nil {
+			return errSet
+		}
+		if errSet := tx.Branches().Delete(branchID); errSet != nil {
+			return errSet
+		}
+		deleted = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &deleted)
+}
+
+// checkBranchUnused returns errdef FailedPrecondition if anything
+// in circulation still refers to the branch.
+func checkBranchUnused(tx store.Store, branchID uint) *errdef.Error {
+	for _, f := range []store.CopyFilter{{BranchID: branchID}, {HomeBranchID: branchID}} {
+		_, total, errSet := tx.Copies().Find(f, countParams)
+		if errSet != nil {
+			return errSet
+		}
+		if *total > 0 {
+			return errdef.ErrFailedPreconditionf("branch %d has %d copies, move them first", branchID, *total).WithProcess(branch.ProcessName)
+		}
+	}
+	open := true
+	_, total, errSet := tx.Transfers().Find(store.TransferFilter{BranchID: branchID, Open: &open}, countParams)
+	if errSet != nil {
+		return errSet
+	}
+	if *total > 0 {
+		return errdef.ErrFailedPreconditionf("branch %d has %d transfers under way", branchID, *total).WithProcess(branch.ProcessName)
+	}
+	for _, status := range []hold.Status{hold.Waiting, hold.Ready} {
+		status := status
+		_, total, errSet := tx.Holds().Find(store.HoldFilter{BranchID: branchID, Status: &status}, countParams)
+		if errSet != nil {
+			return errSet
+		}
+		if *total > 0 {
+			return errdef.ErrFailedPreconditionf("branch %d has %d %s holds to be picked up", branchID, *total, status).WithProcess(branch.ProcessName)
+		}
+	}
+	return nil
+}
+
+// countParams is used when only the total of the rows is needed.
+var countParams = paging.Params{Limit: 1, WithTotal: true, Sort: paging.Sort{{Name: "id", Column: "id"}}}
+
+// firstByID is used when only the oldest matching row is needed,
+// e.g. to look up an existing row by its natural key.
+var firstByID = paging.Params{Limit: 1, Sort: paging.Sort{{Name: "id", Column: "id"}}}
+
+// saveBranch validates and stores the branch. Code has to be unique
+// among all branches, including the deleted ones.
+func saveBranch(tx store.Store, b *branch.Branch) *errdef.Error {
+	b.Sanitize()
+	if errSet := b.Validate(); errSet != nil {
+		return errSet
+	}
+	return tx.Branches().Save(b)
+}
+
+// checkBranch returns errdef InvalidArgument for the field if the
+// branch does not exist.
+func checkBranch(tx store.Store, branchID uint, field string) *errdef.Error {
+	if _, errSet := tx.Branches().Get(branchID); errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			return errdef.ErrInvalidArgumentf("branch %d does not exist", branchID).WithMeta("field", field)
+		}
+		return errSet
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestBranches(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, body := range []string{`{"Code":"main","Name":"Main library"}`, `{"Code":"NORTH","Name":"North side"}`} {
+		w := do(t, srv, "POST", "/create/branch", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := do(t, srv, "POST", "/create/branch", `{"Code":"MAIN","Name":"Another"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "code is taken")
+	w = do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com","PickupBranchID":9}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "branch does not exist")
+	for _, body := range []string{
+		`{"Name":"Jack","Email":"jack@gmail.com","PickupBranchID":2}`,
+		`{"Name":"Jane","Email":"jane@gmail.com"}`,
+		`{"Name":"Joe","Email":"joe@gmail.com"}`,
+	} {
+		w = do(t, srv, "POST", "/create/person", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Go","CallNumber":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	for _, body := range []string{`{"BookID":1,"Barcode":"M1","HomeBranchID":1}`, `{"BookID":1,"Barcode":"N1","HomeBranchID":2}`} {
+		w = do(t, srv, "POST", "/create/copy", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = do(t, srv, "GET", "/books?branch_id=2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var books struct{ Data []book.Book }
+	decode(t, w, &books)
+	assert.Len(t, books.Data, 1)
+	w = do(t, srv, "GET", "/book/1/copies?branch_id=1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var copies struct{ Data []item.Copy }
+	decode(t, w, &copies)
+	require.Len(t, copies.Data, 1)
+	assert.Equal(t, "M1", copies.Data[0].Barcode)
+
+	// the copy at the pickup branch is lent, the other one is sent there
+	w = do(t, srv, "POST", "/checkout/copy/2", `{"PersonID":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/hold/book/1", `{"PersonID":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var h hold.Hold
+	decode(t, w, &h)
+	assert.Equal(t, hold.Waiting, h.Status)
+	require.NotNil(t, h.PickupBranchID)
+	assert.Equal(t, uint(2), *h.PickupBranchID)
+	require.NotNil(t, h.CopyID)
+	assert.Equal(t, uint(1), *h.CopyID)
+
+	w = do(t, srv, "GET", "/transfers?branch_id=2&open=true", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var transfers struct{ Data []branch.Transfer }
+	decode(t, w, &transfers)
+	require.Len(t, transfers.Data, 1)
+	tr := transfers.Data[0]
+	assert.Equal(t, uint(1), tr.CopyID)
+	assert.Equal(t, uint(1), tr.FromBranchID)
+	assert.Equal(t, h.ID, *tr.HoldID)
+
+	w = do(t, srv, "POST", "/checkout/copy/1", `{"PersonID":3}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy is held for another person")
+	w = do(t, srv, "POST", "/cancel/transfer/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "transfer of the hold")
+	w = do(t, srv, "POST", "/receive/transfer/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy was not sent")
+
+	w = do(t, srv, "POST", "/ship/transfer/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/copy/1", "")
+	var c item.Copy
+	decode(t, w, &c)
+	assert.Equal(t, item.InTransit, c.Status)
+	assert.Equal(t, uint(2), c.LocationID)
+	assert.Equal(t, uint(1), c.HomeBranchID)
+
+	// the hold is ready once the copy arrives
+	w = do(t, srv, "POST", "/receive/transfer/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/hold/1", "")
+	decode(t, w, &h)
+	assert.Equal(t, hold.Ready, h.Status)
+	w = do(t, srv, "GET", "/book/1/holds?branch_id=2&status=ready", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var holds struct{ Data hold.Holds }
+	decode(t, w, &holds)
+	assert.Len(t, holds.Data, 1)
+	w = do(t, srv, "DELETE", "/delete/branch/2", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "branch has copies")
+
+	// the copy is returned at another branch and staff send it back home
+	w = do(t, srv, "POST", "/return/copy/2", `{"BranchID":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/copy/2", "")
+	decode(t, w, &c)
+	assert.Equal(t, uint(1), c.LocationID)
+	w = do(t, srv, "POST", "/create/transfer", `{"CopyID":2,"ToBranchID":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/create/transfer", `{"CopyID":2,"ToBranchID":2}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "copy is already being transferred")
+	w = do(t, srv, "POST", "/cancel/transfer/2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/create/transfer", `{"CopyID":2,"ToBranchID":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy is already there")
+
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var l loan.Loan
+	decode(t, w, &l)
+	assert.Equal(t, uint(1), l.CopyID)
+	w = do(t, srv, "GET", "/hold/1", "")
+	decode(t, w, &h)
+	assert.Equal(t, hold.Fulfilled, h.Status)
+}
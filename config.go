@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ConfigCMD inspects the configuration.
+var ConfigCMD = &cli.Command{
+	Name:  "config",
+	Usage: "inspect the configuration",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "print",
+			Usage: "print effective configuration with secrets redacted",
+			Flags: configFlags(),
+			Action: func(ctx *cli.Context) error {
+				cfg, errSet := loadConfig(ctx)
+				if errSet != nil {
+					return errSet
+				}
+				values := cfg.Values()
+				for _, k := range config.Keys() {
+					fmt.Printf("%-24s %s\n", k.Name, values[k.Name])
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// configFlags returns flags of all settings, plus the paths of the
+// config file and the .env file. Flags have no values of their own,
+// defaults are applied by config.Load.
+func configFlags() []cli.Flag {
+	flags := []cli.Flag{
+		&cli.StringFlag{Name: "config", Usage: "path to YAML config file", EnvVars: []string{config.EnvPrefix + "CONFIG"}},
+		&cli.StringFlag{Name: "env-file", Value: ".env", Usage: "path to .env file, skipped if missing"},
+	}
+	for _, k := range config.Keys() {
+		f := &cli.StringFlag{Name: k.Name, Usage: k.Usage + " [$" + k.Env() + "]"}
+		if !k.Secret {
+			f.DefaultText = k.Default
+		}
+		flags = append(flags, f)
+	}
+	return flags
+}
+
+// loadConfig loads the configuration of the command.
+func loadConfig(ctx *cli.Context) (config.Config, *errdef.Error) {
+	flags := map[string]string{}
+	for _, k := range config.Keys() {
+		if ctx.IsSet(k.Name) {
+			flags[k.Name] = ctx.String(k.Name)
+		}
+	}
+	return config.Load(config.Sources{
+		Flags:   flags,
+		File:    ctx.String("config"),
+		Env:     os.Environ(),
+		EnvFile: ctx.String("env-file"),
+	})
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// itemFields are the fields of Copy clients can write. The book of
+// the copy is set when it is created and can't be changed, the location
+// is changed by transfers and returns.
+var itemFields = []string{"Barcode", "Condition", "Status", "HomeBranchID"}
+
+// getBookCopies returns copies of a book.
+func (s *server) getBookCopies(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, item.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if _, errSet := s.store.Books().Get(bookID); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	filter := store.CopyFilter{BookID: bookID}
+	if filter.BranchID, errSet = branchParam(q); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if v := q.Get("status"); v != "" {
+		status, errSet := item.ParseStatus(v)
+		if errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		filter.Status = &status
+	}
+
+	copies, total, errSet := s.store.Copies().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(copies), func(i int) []interface{} {
+		return copies[i].SortValues(params.Sort)
+	})
+	copies = copies[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &copies, Paging: page})
+}
+
+// getBookAvailability tells how many copies of a book can be checked
+// out and how many people wait for one. With the branch_id parameter
+// only copies located at the branch are counted.
+func (s *server) getBookAvailability(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	branchID, errSet := branchParam(r.URL.Query())
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var a item.Availability
+	err := s.store.Transaction(func(tx store.Store) error {
+		if _, errSet := tx.Books().Lock(bookID); errSet != nil {
+			return errSet
+		}
+		holds, errSet := s.policies.openBookHolds(tx, bookID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		copies, errSet := tx.Copies().OfBook(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		if branchID != 0 {
+			var located []item.Copy
+			for _, c := range copies {
+				if c.LocationID == branchID {
+					located = append(located, c)
+				}
+			}
+			copies = located
+		}
+		reserved := map[uint]bool{}
+		var waiting int
+		for _, h := range holds {
+			switch h.Status {
+			case hold.Ready:
+				reserved[*h.CopyID] = true
+			case hold.Waiting:
+				waiting++
+			}
+		}
+		a = item.Count(bookID, copies, reserved, waiting)
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &a)
+}
+
+func (s *server) getCopy(w http.ResponseWriter, r *http.Request) {
+	copyID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	c, errSet := s.store.Copies().Get(copyID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &c)
+}
+
+func (s *server) getCopyByBarcode(w http.ResponseWriter, r *http.Request) {
+	c, errSet := s.store.Copies().GetByBarcode(mux.Vars(r)["barcode"])
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &c)
+}
+
+// createCopy adds a copy of the book, a new available copy is set
+// aside for the next person in the hold queue.
+func (s *server) createCopy(w http.ResponseWriter, r *http.Request) {
+	var input item.Copy
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	created := item.Copy{BookID: input.BookID}
+	copyFields(&created, &input, itemFields)
+	err := s.store.Transaction(func(tx store.Store) error {
+		if _, errSet := tx.Books().Lock(created.BookID); errSet != nil {
+			if errdef.IsNotFound(errSet) {
+				return errdef.ErrInvalidArgumentf("book %d does not exist", created.BookID).WithMeta("field", "book_id")
+			}
+			return errSet
+		}
+		if errSet := item.ChangeStatus(item.Available, created.Status); errSet != nil {
+			return errSet
+		}
+		created.LocationID = created.HomeBranchID
+		if errSet := saveCopy(tx, &created); errSet != nil {
+			return errSet
+		}
+		if _, errSet := s.policies.openBookHolds(tx, created.BookID, time.Now().UTC()); errSet != nil {
+			return errSet
+		}
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
+
+// replaceCopy replaces all writable fields of the copy.
+func (s *server) replaceCopy(w http.ResponseWriter, r *http.Request) {
+	var input item.Copy
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.updateCopy(w, r, func(c *item.Copy) *errdef.Error {
+		copyFields(c, &input, itemFields)
+		return nil
+	})
+}
+
+// patchCopy applies JSON merge patch to the copy.
+func (s *server) patchCopy(w http.ResponseWriter, r *http.Request) {
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
+	}
+	s.updateCopy(w, r, func(c *item.Copy) *errdef.Error {
+		return applyPatch(c, patch, itemFields)
+	})
+}
+
+// updateCopy changes the copy. Copies going to repair or getting lost
+// give up their ready holds, copies coming back serve the hold queue.
+func (s *server) updateCopy(w http.ResponseWriter, r *http.Request, change func(*item.Copy) *errdef.Error) {
+	copyID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var updated item.Copy
+	err := s.store.Transaction(func(tx store.Store) error {
+		c, errSet := lockCopy(tx, copyID)
+		if errSet != nil {
+			return errSet
+		}
+		from := c.Status
+		if errSet := change(&c); errSet != nil {
+			return errSet
+		}
+		if errSet := item.ChangeStatus(from, c.Status); errSet != nil {
+			return errSet
+		}
+		if errSet := saveCopy(tx, &c); errSet != nil {
+			return errSet
+		}
+		if _, errSet := s.policies.openBookHolds(tx, c.BookID, time.Now().UTC()); errSet != nil {
+			return errSet
+		}
+		updated = c
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}
+
+// deleteCopy deletes the copy unless it is on loan.
+func (s *server) deleteCopy(w http.ResponseWriter, r *http.Request) {
+	copyID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var deleted item.Copy
+	err := s.store.Transaction(func(tx store.Store) error {
+		c, errSet := lockCopy(tx, copyID)
+		if errSet != nil {
+			return errSet
+		}
+		if c.Status == item.OnLoan {
+			return errdef.ErrFailedPreconditionf("copy %d is on loan, return it first", copyID).WithProcess(item.ProcessName)
+		}
+		if errSet := tx.Copies().Delete(copyID); errSet != nil {
+			return errSet
+		}
+		if _, errSet := s.policies.openBookHolds(tx, c.BookID, time.Now().UTC()); errSet != nil {
+			return errSet
+		}
+		deleted = c
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &deleted)
+}
+
+// lockCopy locks the book of the copy and then the copy, in the same
+// order as circulation does.
+func lockCopy(tx store.Store, copyID uint) (item.Copy, *errdef.Error) {
+	c, errSet := tx.Copies().Get(copyID)
+	if errSet != nil {
+		return c, errSet
+	}
+	if _, errSet := tx.Books().Lock(c.BookID); errSet != nil {
+		return c, errSet
+	}
+	// reload as the copy could change before the book was locked
+	return tx.Copies().Lock(copyID)
+}
+
+// saveCopy validates and stores the copy. Barcode has to be unique
+// among all copies, including the deleted ones.
+func saveCopy(tx store.Store, c *item.Copy) *errdef.Error {
+	c.Sanitize()
+	if errSet := c.Validate(); errSet != nil {
+		return errSet
+	}
+	if errSet := checkBranch(tx, c.HomeBranchID, "home_branch_id"); errSet != nil {
+		return errSet
+	}
+	return tx.Copies().Save(c)
+}
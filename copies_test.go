@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestCopies(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, body := range []string{
+		`{"Name":"Jack","Email":"jack@gmail.com"}`,
+		`{"Name":"Jane","Email":"jane@gmail.com"}`,
+		`{"Name":"Joe","Email":"joe@gmail.com"}`,
+	} {
+		w := do(t, srv, "POST", "/create/person", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := do(t, srv, "POST", "/create/book", `{"Title":"Go","CallNumber":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	for _, barcode := range []string{"A1", "A2"} {
+		w = do(t, srv, "POST", "/create/copy", `{"BookID":1,"Barcode":"`+barcode+`","HomeBranchID":1}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w = do(t, srv, "POST", "/create/copy", `{"BookID":1,"Barcode":"A1","HomeBranchID":1}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "barcode is taken")
+	w = do(t, srv, "POST", "/create/copy", `{"BookID":9,"Barcode":"B1","HomeBranchID":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "book does not exist")
+	w = do(t, srv, "POST", "/create/copy", `{"BookID":1,"Barcode":"B1","HomeBranchID":1,"Status":"on_loan"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copies go on loan by checkout")
+
+	w = do(t, srv, "GET", "/copy/barcode/A2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var c item.Copy
+	decode(t, w, &c)
+	assert.Equal(t, uint(2), c.ID)
+
+	// both copies are lent, the third person has to wait
+	w = do(t, srv, "POST", "/checkout/copy/2", `{"PersonID":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var l loan.Loan
+	decode(t, w, &l)
+	assert.Equal(t, uint(2), l.CopyID)
+	w = do(t, srv, "POST", "/checkout/copy/2", `{"PersonID":2}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	decode(t, w, &l)
+	assert.Equal(t, uint(1), l.CopyID)
+	w = do(t, srv, "POST", "/hold/book/1", `{"PersonID":3}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var h hold.Hold
+	decode(t, w, &h)
+	assert.Equal(t, hold.Waiting, h.Status)
+
+	w = do(t, srv, "GET", "/book/1/availability", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var a item.Availability
+	decode(t, w, &a)
+	assert.Equal(t, item.Availability{BookID: 1, Copies: 2, OnLoan: 2, Waiting: 1}, a)
+
+	w = do(t, srv, "DELETE", "/delete/copy/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy is on loan")
+	w = do(t, srv, "PATCH", "/update/copy/1", `{"Status":"lost"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy is on loan")
+
+	// the returned copy is set aside for the waiting person
+	w = do(t, srv, "POST", "/return/copy/2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/hold/1", "")
+	decode(t, w, &h)
+	require.Equal(t, hold.Ready, h.Status)
+	assert.Equal(t, uint(2), *h.CopyID)
+	w = do(t, srv, "POST", "/checkout/copy/2", `{"PersonID":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "copy is held for another person")
+
+	// the copy goes to repair, the hold waits for another one
+	w = do(t, srv, "PATCH", "/update/copy/2", `{"Status":"repair","Condition":"damaged"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/hold/1", "")
+	decode(t, w, &h)
+	assert.Equal(t, hold.Waiting, h.Status)
+	assert.Nil(t, h.CopyID)
+	w = do(t, srv, "POST", "/return/copy/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":3}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	decode(t, w, &l)
+	assert.Equal(t, uint(1), l.CopyID)
+	w = do(t, srv, "GET", "/hold/1", "")
+	decode(t, w, &h)
+	assert.Equal(t, hold.Fulfilled, h.Status)
+
+	w = do(t, srv, "GET", "/book/1/copies?status=repair", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var copies struct{ Data []item.Copy }
+	decode(t, w, &copies)
+	require.Len(t, copies.Data, 1)
+	assert.Equal(t, item.Damaged, copies.Data[0].Condition)
+	w = do(t, srv, "GET", "/copy/1/loans", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var loans struct{ Data loan.Loans }
+	decode(t, w, &loans)
+	assert.Len(t, loans.Data, 2)
+
+	// books with copies can't be deleted
+	w = do(t, srv, "DELETE", "/delete/book/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(t, srv, "POST", "/return/copy/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	for _, id := range []string{"1", "2"} {
+		w = do(t, srv, "DELETE", "/delete/copy/"+id, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+	w = do(t, srv, "DELETE", "/delete/book/1", "")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
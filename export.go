@@ -0,0 +1,235 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/urfave/cli/v2"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/records"
+)
+
+// streamFunc calls fn for every exported row.
+type streamFunc func(s store.Store, fn func(row interface{}) *errdef.Error) *errdef.Error
+
+// exporter streams rows of one model.
+type exporter struct {
+	// row is empty model, CSV columns are its fields.
+	row interface{}
+	// stream returns function streaming rows matching the filter, the
+	// filter is read from the same parameters as the list endpoint has.
+	stream func(q url.Values) (streamFunc, *errdef.Error)
+}
+
+// exporters are models which can be exported by their plural name.
+// Books refer to people they are assigned to and loans refer to both.
+var exporters = map[string]exporter{
+	"people": {
+		row: person.Person{},
+		stream: func(q url.Values) (streamFunc, *errdef.Error) {
+			filter := personFilter(q)
+			return func(s store.Store, fn func(row interface{}) *errdef.Error) *errdef.Error {
+				return s.People().Each(filter, func(p person.Person) *errdef.Error { return fn(p) })
+			}, nil
+		},
+	},
+	"books": {
+		row: book.Book{},
+		stream: func(q url.Values) (streamFunc, *errdef.Error) {
+			filter, errSet := bookFilter(q)
+			if errSet != nil {
+				return nil, errSet
+			}
+			return func(s store.Store, fn func(row interface{}) *errdef.Error) *errdef.Error {
+				return s.Books().Each(filter, func(b book.Book) *errdef.Error { return fn(b) })
+			}, nil
+		},
+	},
+	"loans": {
+		row: loan.Loan{},
+		stream: func(q url.Values) (streamFunc, *errdef.Error) {
+			filter, errSet := loanFilter(q)
+			if errSet != nil {
+				return nil, errSet
+			}
+			return func(s store.Store, fn func(row interface{}) *errdef.Error) *errdef.Error {
+				return s.Loans().Each(filter, func(l loan.Loan) *errdef.Error { return fn(l) })
+			}, nil
+		},
+	},
+}
+
+// runExport writes all streamed rows and finishes the output.
+func runExport(s store.Store, wr *records.Writer, stream streamFunc) *errdef.Error {
+	errSet := stream(s, func(row interface{}) *errdef.Error {
+		if err := wr.Write(row); err != nil {
+			return errdef.Wrap(err, errdef.CodeUnavailable, "failed to write row")
+		}
+		return nil
+	})
+	if errSet != nil {
+		return errSet
+	}
+	if err := wr.Close(); err != nil {
+		return errdef.Wrap(err, errdef.CodeUnavailable, "failed to finish export")
+	}
+	return nil
+}
+
+// sentWriter tells if anything was written to the response.
+type sentWriter struct {
+	w    io.Writer
+	sent bool
+}
+
+func (sw *sentWriter) Write(p []byte) (int, error) {
+	sw.sent = true
+	return sw.w.Write(p)
+}
+
+// exportRows streams people, books or loans as CSV (default), NDJSON or
+// JSON array, gzipped if the gzip parameter is true. Rows are written
+// as they are read, so a failure in the middle of the export can only
+// be reported by cutting the connection.
+func (s *server) exportRows(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	kind := mux.Vars(r)["kind"]
+	exp := exporters[kind]
+
+	format := records.CSV
+	if v := q.Get("format"); v != "" {
+		var errSet *errdef.Error
+		if format, errSet = records.ParseFormat(v); errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+	}
+	var compress bool
+	if v := q.Get("gzip"); v != "" {
+		var err error
+		if compress, err = strconv.ParseBool(v); err != nil {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("gzip must be a boolean").WithMeta("field", "gzip"))
+			return
+		}
+	}
+	stream, errSet := exp.stream(q)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+kind+"."+format.String()+`"`)
+	sw := &sentWriter{w: w}
+	out := io.Writer(sw)
+	var zw *gzip.Writer
+	if compress {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw = gzip.NewWriter(sw)
+		out = zw
+	}
+
+	errSet = runExport(s.store, records.NewWriter(out, format, exp.row), stream)
+	if errSet == nil && zw != nil {
+		if err := zw.Close(); err != nil {
+			errSet = errdef.Wrap(err, errdef.CodeUnavailable, "failed to finish export")
+		}
+	}
+	if errSet == nil {
+		return
+	}
+	if !sw.sent {
+		for _, h := range []string{"Content-Type", "Content-Disposition", "Content-Encoding"} {
+			w.Header().Del(h)
+		}
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	log.Printf("export of %s failed: %s", kind, errSet)
+	panic(http.ErrAbortHandler)
+}
+
+// ExportCMD exports people, books or loans to a file or stdout.
+var ExportCMD = &cli.Command{
+	Name:      "export",
+	Usage:     "export people, books or loans as CSV, NDJSON or JSON",
+	ArgsUsage: "people|books|loans",
+	Flags: append(configFlags(),
+		&cli.StringFlag{Name: "format", Usage: "csv, ndjson or json, guessed from the output file extension, csv by default"},
+		&cli.StringFlag{Name: "output", Aliases: []string{"o"}, Usage: "file to write, stdout by default"},
+		&cli.BoolFlag{Name: "gzip", Usage: "compress the output, on by default if the output file ends with .gz"},
+		&cli.StringFlag{Name: "name", Usage: "people whose name contains it"},
+		&cli.StringFlag{Name: "email", Usage: "people with the email"},
+		&cli.StringFlag{Name: "author", Usage: "books whose author contains it"},
+		&cli.StringFlag{Name: "title", Usage: "books whose title starts with it"},
+		&cli.StringFlag{Name: "author-id", Usage: "books crediting the author"},
+		&cli.StringFlag{Name: "branch-id", Usage: "books with a copy at the branch"},
+		&cli.StringFlag{Name: "person-id", Usage: "books or loans of the person"},
+		&cli.StringFlag{Name: "book-id", Usage: "loans of the book"},
+		&cli.StringFlag{Name: "copy-id", Usage: "loans of the copy"},
+		&cli.StringFlag{Name: "active", Usage: "loans not returned yet if true, the returned ones if false"},
+	),
+	Action: func(ctx *cli.Context) error {
+		exp, ok := exporters[ctx.Args().First()]
+		if ctx.NArg() != 1 || !ok {
+			return cli.Exit("kind must be people, books or loans", 1)
+		}
+		q := url.Values{}
+		for _, name := range []string{"name", "email", "author", "title", "author-id", "branch-id", "person-id", "book-id", "copy-id", "active"} {
+			if ctx.IsSet(name) {
+				q.Set(strings.ReplaceAll(name, "-", "_"), ctx.String(name))
+			}
+		}
+		stream, errSet := exp.stream(q)
+		if errSet != nil {
+			return errSet
+		}
+
+		path := ctx.String("output")
+		format, ok := records.FormatOf(path, "")
+		if ctx.IsSet("format") {
+			if format, errSet = records.ParseFormat(ctx.String("format")); errSet != nil {
+				return errSet
+			}
+		} else if !ok {
+			format = records.CSV
+		}
+		out := io.Writer(os.Stdout)
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		var zw *gzip.Writer
+		if ctx.Bool("gzip") || strings.HasSuffix(path, ".gz") {
+			zw = gzip.NewWriter(out)
+			out = zw
+		}
+
+		return withStore(ctx, func(s store.Store) error {
+			if errSet := runExport(s, records.NewWriter(out, format, exp.row), stream); errSet != nil {
+				return errSet
+			}
+			if zw != nil {
+				return zw.Close()
+			}
+			return nil
+		})
+	},
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestExport(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	w := do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	for _, body := range []string{
+		`{"Title":"The rules of Thinking","Author":"Richard Templar","CallNumber":1,"PersonID":1}`,
+		`{"Title":"Happy, world champion","Author":"Deko Montera","CallNumber":2}`,
+		`{"Title":"The rules of Work","Author":"Richard Templar","CallNumber":3}`,
+	} {
+		w := do(t, srv, "POST", "/create/book", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = do(t, srv, "GET", "/export/books?author=templar", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id,created_at,updated_at,deleted_at,title,author,call_number,isbn,person_id", lines[0])
+	assert.True(t, strings.HasSuffix(lines[1], ",The rules of Thinking,Richard Templar,1,,1"), lines[1])
+
+	// the export can be imported back
+	w = do(t, srv, "POST", "/import/books?format=csv", w.Body.String())
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report importReport
+	decode(t, w, &report)
+	assert.Equal(t, 2, report.Updated)
+
+	w = do(t, srv, "GET", "/export/books?format=json&gzip=true", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	var books []book.Book
+	require.NoError(t, json.Unmarshal(data, &books))
+	require.Len(t, books, 3)
+	assert.Equal(t, "Happy, world champion", books[1].Title)
+
+	w = do(t, srv, "GET", "/export/people?format=ndjson&email=nobody@gmail.com", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, w.Body.String())
+
+	for _, target := range []string{"/export/books?format=xml", "/export/books?person_id=x", "/export/loans?active=maybe"} {
+		w = do(t, srv, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	}
+}
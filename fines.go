@@ -0,0 +1,185 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Fine controllers
+
+type creditRequest struct {
+	Amount decimal.Decimal
+	LoanID *uint
+	Note   string
+}
+
+type balanceResponse struct {
+	PersonID uint
+	Balance  decimal.Decimal
+	Blocked  bool
+}
+
+func (s *server) getPersonBalance(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if _, errSet := s.store.People().Get(personID); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	ledger, errSet := s.store.Fines().Ledger(personID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	balance := ledger.Balance()
+	httpio.WriteJSON(w, http.StatusOK, balanceResponse{
+		PersonID: personID,
+		Balance:  balance,
+		Blocked:  s.policies.fine.Blocks(balance),
+	})
+}
+
+// getPersonFines returns the fines ledger of a person.
+func (s *server) getPersonFines(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, fine.Sortable, "-created_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	filter := store.FineFilter{PersonID: personID}
+	if v := q.Get("kind"); v != "" {
+		kind, errSet := fine.ParseKind(v)
+		if errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		filter.Kind = &kind
+	}
+
+	entries, total, errSet := s.store.Fines().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(entries), func(i int) []interface{} {
+		return entries[i].SortValues(params.Sort)
+	})
+	entries = entries[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &entries, Paging: page})
+}
+
+func (s *server) payFine(w http.ResponseWriter, r *http.Request) {
+	s.addCredit(w, r, fine.Payment)
+}
+
+func (s *server) waiveFine(w http.ResponseWriter, r *http.Request) {
+	s.addCredit(w, r, fine.Waive)
+}
+
+func (s *server) addCredit(w http.ResponseWriter, r *http.Request, kind fine.Kind) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	var req creditRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	e := fine.Entry{
+		PersonID: personID,
+		LoanID:   req.LoanID,
+		Kind:     kind,
+		Amount:   req.Amount,
+		Note:     req.Note,
+	}
+	err := s.store.Transaction(func(tx store.Store) error {
+		if errSet := credit(tx, &e); errSet != nil {
+			return errSet
+		}
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &e)
+}
+
+// Fine operations
+
+// checkBalance refuses people whose balance is over the threshold.
+func (p policies) checkBalance(tx store.Store, personID uint) *errdef.Error {
+	ledger, errSet := tx.Fines().Ledger(personID)
+	if errSet != nil {
+		return errSet
+	}
+	if balance := ledger.Balance(); p.fine.Blocks(balance) {
+		return errdef.ErrFailedPreconditionf("person %d has unpaid fines of %s", personID, balance.StringFixed(2)).WithProcess(fine.ProcessName)
+	}
+	return nil
+}
+
+// chargeOverdue adds the fine for the returned loan to the ledger.
+func (p policies) chargeOverdue(tx store.Store, l loan.Loan) *errdef.Error {
+	if l.ReturnedAt == nil {
+		return nil
+	}
+	amount := p.fine.Compute(l.DueAt, *l.ReturnedAt)
+	if amount.IsZero() {
+		return nil
+	}
+	e := fine.NewCharge(l.PersonID, l.ID, amount)
+	e.Note = "overdue"
+	return tx.Fines().Save(&e)
+}
+
+// credit adds waive or payment to the ledger. The person row is locked,
+// so concurrent payments can't take the balance below zero. The loan
+// the credit is for has to be a loan of the person.
+func credit(tx store.Store, e *fine.Entry) *errdef.Error {
+	if _, errSet := tx.People().Lock(e.PersonID); errSet != nil {
+		return errSet
+	}
+	if e.LoanID != nil {
+		l, errSet := tx.Loans().Get(*e.LoanID)
+		if errSet != nil && !errdef.IsNotFound(errSet) {
+			return errSet
+		}
+		if errSet != nil || l.PersonID != e.PersonID {
+			return errdef.ErrInvalidArgumentf("loan %d is not a loan of person %d", *e.LoanID, e.PersonID).
+				WithProcess(fine.ProcessName).
+				WithMeta("field", "loan_id")
+		}
+	}
+	ledger, errSet := tx.Fines().Ledger(e.PersonID)
+	if errSet != nil {
+		return errSet
+	}
+	if errSet := ledger.Credit(*e); errSet != nil {
+		return errSet
+	}
+	return tx.Fines().Save(e)
+}
@@ -3,35 +3,41 @@ module github.com/investapp/backend
 go 1.19
 
 require (
-	github.com/Pallinder/go-randomdata v1.2.0 // indirect
+	github.com/Pallinder/go-randomdata v1.2.0
+	github.com/dgrijalva/jwt-go v3.2.0+incompatible
+	github.com/go-pg/pg v8.0.7+incompatible
+	github.com/gorilla/mux v1.8.0
+	github.com/huttarichard/phone v0.0.0-20191230101442-a4e818f31872
+	github.com/jinzhu/gorm v1.9.16
+	github.com/kr/pretty v0.3.0
+	github.com/lib/pq v1.10.4
+	github.com/satori/go.uuid v1.2.0
+	github.com/shopspring/decimal v1.3.1
+	github.com/stretchr/testify v1.8.0
+	github.com/urfave/cli/v2 v2.16.3
+	gocloud.dev v0.26.0
+	golang.org/x/crypto v0.0.0-20220331220935-ae2d96664a29
+	golang.org/x/text v0.3.7
+	google.golang.org/grpc v1.49.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/cpuguy83/go-md2man/v2 v2.0.2 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/dgrijalva/jwt-go v3.2.0+incompatible // indirect
-	github.com/go-pg/pg v8.0.7+incompatible // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/googleapis/gax-go/v2 v2.2.0 // indirect
-	github.com/huttarichard/phone v0.0.0-20191230101442-a4e818f31872 // indirect
 	github.com/jinzhu/inflection v1.0.0 // indirect
-	github.com/kr/pretty v0.3.0 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/rogpeppe/go-internal v1.6.1 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
-	github.com/satori/go.uuid v1.2.0 // indirect
-	github.com/shopspring/decimal v1.3.1 // indirect
-	github.com/stretchr/testify v1.8.0 // indirect
-	github.com/urfave/cli/v2 v2.16.3 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
-	gocloud.dev v0.26.0 // indirect
-	golang.org/x/crypto v0.0.0-20220331220935-ae2d96664a29 // indirect
 	golang.org/x/net v0.0.0-20220401154927-543a649e0bdd // indirect
 	golang.org/x/sys v0.0.0-20220330033206-e17cdc41300f // indirect
-	golang.org/x/text v0.3.7 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/api v0.74.0 // indirect
 	google.golang.org/genproto v0.0.0-20220401170504-314d38edb7de // indirect
-	google.golang.org/grpc v1.49.0 // indirect
 	google.golang.org/protobuf v1.28.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	mellium.im/sasl v0.3.0 // indirect
 )
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+)
+
+// readyTimeout limits how long all readiness checks may take together.
+const readyTimeout = 2 * time.Second
+
+// readyCheck is a dependency the server needs to serve requests.
+type readyCheck struct {
+	name  string
+	check func(ctx context.Context) *errdef.Error
+}
+
+// health is the response of the liveness and readiness probes. Checks
+// maps name of each check to "ok" or the reason it failed.
+type health struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// drain marks the server as shutting down, from now on the readiness
+// probe fails so no new traffic is routed to it.
+func (s *server) drain() {
+	atomic.StoreInt32(&s.draining, 1)
+}
+
+func (s *server) isDraining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+// healthz tells the process is alive, it doesn't check any dependency.
+func (s *server) healthz(w http.ResponseWriter, r *http.Request) {
+	httpio.WriteJSON(w, http.StatusOK, health{Status: "ok"})
+}
+
+// readyz tells the server is able to serve requests. It fails while
+// any of the checks fails or once the shutdown started.
+func (s *server) readyz(w http.ResponseWriter, r *http.Request) {
+	if s.isDraining() {
+		httpio.WriteJSON(w, http.StatusServiceUnavailable, health{Status: "shutting down"})
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	res := health{Status: "ok", Checks: map[string]string{}}
+	for _, c := range s.checks {
+		if errSet := c.check(ctx); errSet != nil {
+			res.Status = "unavailable"
+			res.Checks[c.name] = errSet.Detail
+			continue
+		}
+		res.Checks[c.name] = "ok"
+	}
+	status := http.StatusOK
+	if res.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	httpio.WriteJSON(w, status, res)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/investapp/backend/models/store/memstore"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func TestHealth(t *testing.T) {
+	var down bool
+	srv := newServer(memstore.New(), readyCheck{name: "database", check: func(ctx context.Context) *errdef.Error {
+		if down {
+			return errdef.ErrUnavailable("database is not reachable")
+		}
+		return nil
+	}})
+
+	var res health
+	w := do(t, srv, "GET", "/healthz", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do(t, srv, "GET", "/readyz", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	decode(t, w, &res)
+	assert.Equal(t, health{Status: "ok", Checks: map[string]string{"database": "ok"}}, res)
+
+	down = true
+	w = do(t, srv, "GET", "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	res = health{}
+	decode(t, w, &res)
+	assert.Equal(t, "database is not reachable", res.Checks["database"])
+
+	// once draining, readiness fails even if all checks pass
+	down = false
+	srv.drain()
+	w = do(t, srv, "GET", "/readyz", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	w = do(t, srv, "GET", "/healthz", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
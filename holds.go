@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// holdSweepInterval is how often ready holds are checked for expiry.
+const holdSweepInterval = time.Hour
+
+// Hold controllers
+
+type holdRequest struct {
+	PersonID uint
+	// PickupBranchID is the branch the person picks the book up at,
+	// the pickup branch of the person by default.
+	PickupBranchID *uint
+}
+
+func (s *server) placeHold(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	var req holdRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if req.PersonID == 0 {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("person is required").WithMeta("field", "person_id"))
+		return
+	}
+
+	var placed hold.Hold
+	err := s.store.Transaction(func(tx store.Store) error {
+		h, errSet := s.policies.addHold(tx, req.PersonID, bookID, req.PickupBranchID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		placed = h
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &placed)
+}
+
+func (s *server) cancelHold(w http.ResponseWriter, r *http.Request) {
+	holdID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var cancelled hold.Hold
+	err := s.store.Transaction(func(tx store.Store) error {
+		h, errSet := s.policies.dropHold(tx, holdID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		cancelled = h
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &cancelled)
+}
+
+func (s *server) getHold(w http.ResponseWriter, r *http.Request) {
+	holdID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	h, errSet := s.store.Holds().Get(holdID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &h)
+}
+
+// getPersonHolds returns holds placed by a person.
+func (s *server) getPersonHolds(w http.ResponseWriter, r *http.Request) {
+	s.listHolds(w, r, func(f *store.HoldFilter, id uint) { f.PersonID = id })
+}
+
+// getBookHolds returns hold queue of a book.
+func (s *server) getBookHolds(w http.ResponseWriter, r *http.Request) {
+	s.listHolds(w, r, func(f *store.HoldFilter, id uint) { f.BookID = id })
+}
+
+func (s *server) listHolds(w http.ResponseWriter, r *http.Request, byID func(f *store.HoldFilter, id uint)) {
+	id, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, hold.Sortable, "placed_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var filter store.HoldFilter
+	byID(&filter, id)
+	if filter.BranchID, errSet = branchParam(q); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if v := q.Get("status"); v != "" {
+		status, errSet := hold.ParseStatus(v)
+		if errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		filter.Status = &status
+	}
+
+	holds, total, errSet := s.store.Holds().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(holds), func(i int) []interface{} {
+		return holds[i].SortValues(params.Sort)
+	})
+	holds = holds[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &holds, Paging: page})
+}
+
+// sweepHolds periodically expires holds that were not picked up in time
+// and passes their books to the next person in the queue until ctx is done.
+func sweepHolds(ctx context.Context, s store.Store, p policies, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if errSet := p.expireHolds(s, time.Now().UTC()); errSet != nil {
+			log.Println("failed to expire holds:", errSet)
+		}
+	}
+}
+
+func (p policies) expireHolds(s store.Store, now time.Time) *errdef.Error {
+	bookIDs, errSet := s.Holds().ExpiredBookIDs(now)
+	if errSet != nil {
+		return errSet
+	}
+	for _, bookID := range bookIDs {
+		err := s.Transaction(func(tx store.Store) error {
+			if _, errSet := tx.Books().Lock(bookID); errSet != nil {
+				return errSet
+			}
+			if _, errSet := p.openBookHolds(tx, bookID, now); errSet != nil {
+				return errSet
+			}
+			return nil
+		})
+		if err != nil {
+			return errdef.FromError(err)
+		}
+	}
+	return nil
+}
+
+// Hold operations, they are expected to run inside of transaction.
+// Every operation locks the book row first, so the queue of the book
+// is never changed concurrently.
+
+// addHold puts the person to the end of the book queue. If a copy of
+// the book is on the shelf at the pickup branch and nobody waits for it,
+// the hold is ready right away.
+func (p policies) addHold(tx store.Store, personID, bookID uint, pickupBranchID *uint, now time.Time) (hold.Hold, *errdef.Error) {
+	if _, errSet := tx.Books().Lock(bookID); errSet != nil {
+		return hold.Hold{}, errSet
+	}
+	pn, errSet := tx.People().Get(personID)
+	if errSet != nil {
+		return hold.Hold{}, errSet
+	}
+	if pickupBranchID != nil {
+		if errSet := checkBranch(tx, *pickupBranchID, "pickup_branch_id"); errSet != nil {
+			return hold.Hold{}, errSet
+		}
+	} else if pn.PickupBranchID != nil {
+		// the branch of the person could be closed since
+		if _, errSet := tx.Branches().Get(*pn.PickupBranchID); errSet == nil {
+			pickupBranchID = pn.PickupBranchID
+		}
+	}
+	holds, errSet := p.openBookHolds(tx, bookID, now)
+	if errSet != nil {
+		return hold.Hold{}, errSet
+	}
+	if _, ok := holds.GetByPersonID(personID); ok {
+		return hold.Hold{}, errdef.ErrAlreadyExistsf("person %d already holds book %d", personID, bookID).WithProcess(hold.ProcessName)
+	}
+	active := true
+	loans, _, errSet := tx.Loans().Find(store.LoanFilter{PersonID: personID, BookID: bookID, Active: &active}, firstByID)
+	if errSet != nil {
+		return hold.Hold{}, errSet
+	}
+	if len(loans) > 0 {
+		return hold.Hold{}, errdef.ErrFailedPreconditionf("person %d already has book %d on loan", personID, bookID).WithProcess(hold.ProcessName)
+	}
+
+	h := hold.New(personID, bookID, now)
+	h.PickupBranchID = pickupBranchID
+	if errSet := tx.Holds().Save(&h); errSet != nil {
+		return h, errSet
+	}
+	holds, errSet = p.serveHolds(tx, bookID, append(holds, h), now)
+	if errSet != nil {
+		return h, errSet
+	}
+	h, _ = holds.GetByPersonID(personID)
+	return h, nil
+}
+
+// dropHold cancels the hold, if the book was set aside for it
+// the next person in the queue gets it.
+func (p policies) dropHold(tx store.Store, holdID uint, now time.Time) (hold.Hold, *errdef.Error) {
+	h, errSet := tx.Holds().Get(holdID)
+	if errSet != nil {
+		return h, errSet
+	}
+	if _, errSet := tx.Books().Lock(h.BookID); errSet != nil {
+		return h, errSet
+	}
+	// reload as the hold could change before the book was locked
+	h, errSet = tx.Holds().Get(holdID)
+	if errSet != nil {
+		return h, errSet
+	}
+	if errSet := h.Cancel(now); errSet != nil {
+		return h, errSet
+	}
+	if errSet := tx.Holds().Save(&h); errSet != nil {
+		return h, errSet
+	}
+	if errSet := releaseTransfer(tx, h, now); errSet != nil {
+		return h, errSet
+	}
+	if _, errSet := p.openBookHolds(tx, h.BookID, now); errSet != nil {
+		return h, errSet
+	}
+	return h, nil
+}
+
+// openBookHolds loads the queue of the book. Ready holds which were not
+// picked up in time expire and the next person in the queue is served.
+func (p policies) openBookHolds(tx store.Store, bookID uint, now time.Time) (hold.Holds, *errdef.Error) {
+	holds, errSet := tx.Holds().Open(bookID)
+	if errSet != nil {
+		return nil, errSet
+	}
+
+	open := hold.Holds{}
+	for _, h := range holds {
+		if h.Stale(now) {
+			if errSet := h.Expire(now); errSet != nil {
+				return nil, errSet
+			}
+			if errSet := tx.Holds().Save(&h); errSet != nil {
+				return nil, errSet
+			}
+			continue
+		}
+		open = append(open, h)
+	}
+
+	return p.serveHolds(tx, bookID, open, now)
+}
+
+// serveHolds sets free copies of the book aside for waiting people in
+// the queue order. A copy on the shelf at the pickup branch makes the hold
+// ready, otherwise a free copy from another branch is transferred there
+// and the hold becomes ready when it arrives. Holds whose copy can't be
+// lent anymore, e.g. it was lost or deleted, go back to the queue and
+// keep their place.
+func (p policies) serveHolds(tx store.Store, bookID uint, holds hold.Holds, now time.Time) (hold.Holds, *errdef.Error) {
+	copies, errSet := tx.Copies().OfBook(bookID)
+	if errSet != nil {
+		return holds, errSet
+	}
+	byID := make(map[uint]item.Copy, len(copies))
+	for _, c := range copies {
+		byID[c.ID] = c
+	}
+	sort.Sort(holds)
+
+	for i := range holds {
+		h := &holds[i]
+		if h.CopyID == nil {
+			continue
+		}
+		c, ok := byID[*h.CopyID]
+		switch {
+		case h.Status == hold.Ready && ok && c.Status == item.Available:
+			continue
+		case h.Status == hold.Waiting && ok && c.Status == item.Available && pickupAt(*h, c.LocationID):
+			// the copy arrived at the pickup branch
+			if errSet := h.MakeReady(p.hold, c.ID, now); errSet != nil {
+				return holds, errSet
+			}
+		case h.Status == hold.Waiting && ok && (c.Status == item.Available || c.Status == item.InTransit):
+			// the copy is on its way
+			continue
+		default:
+			if errSet := h.Requeue(); errSet != nil {
+				return holds, errSet
+			}
+			if errSet := releaseTransfer(tx, *h, now); errSet != nil {
+				return holds, errSet
+			}
+		}
+		if errSet := tx.Holds().Save(h); errSet != nil {
+			return holds, errSet
+		}
+	}
+
+	for i := range holds {
+		h := &holds[i]
+		if h.Status != hold.Waiting || h.CopyID != nil {
+			continue
+		}
+		free := freeCopies(copies, holds)
+		if len(free) == 0 {
+			break
+		}
+		c, ok := localCopy(free, *h)
+		if ok {
+			if errSet := h.MakeReady(p.hold, c.ID, now); errSet != nil {
+				return holds, errSet
+			}
+		} else {
+			if c, ok, errSet = movableCopy(tx, free); errSet != nil {
+				return holds, errSet
+			}
+			if !ok {
+				continue
+			}
+			if errSet := h.Assign(c.ID); errSet != nil {
+				return holds, errSet
+			}
+			if _, errSet := requestTransfer(tx, c.ID, c.LocationID, *h.PickupBranchID, &h.ID, now); errSet != nil {
+				return holds, errSet
+			}
+		}
+		if errSet := tx.Holds().Save(h); errSet != nil {
+			return holds, errSet
+		}
+	}
+	return holds, nil
+}
+
+// pickupAt tells if the hold can be picked up at the branch. Holds
+// without pickup branch are picked up where the copy is.
+func pickupAt(h hold.Hold, branchID uint) bool {
+	return h.PickupBranchID == nil || *h.PickupBranchID == branchID
+}
+
+// localCopy returns the first of the copies which is at the pickup
+// branch of the hold.
+func localCopy(copies []item.Copy, h hold.Hold) (item.Copy, bool) {
+	for _, c := range copies {
+		if pickupAt(h, c.LocationID) {
+			return c, true
+		}
+	}
+	return item.Copy{}, false
+}
+
+// movableCopy returns the first of the copies which is not being
+// transferred by staff already.
+func movableCopy(tx store.Store, copies []item.Copy) (item.Copy, bool, *errdef.Error) {
+	for _, c := range copies {
+		open, errSet := tx.Transfers().Open(c.ID)
+		if errSet != nil {
+			return c, false, errSet
+		}
+		if open == nil {
+			return c, true, nil
+		}
+	}
+	return item.Copy{}, false, nil
+}
+
+// freeCopies returns available copies which are not set aside for anyone.
+func freeCopies(copies []item.Copy, holds hold.Holds) []item.Copy {
+	var free []item.Copy
+	for _, c := range copies {
+		if _, reserved := holds.ByCopyID(c.ID); c.Status == item.Available && !reserved {
+			free = append(free, c)
+		}
+	}
+	return free
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/urfave/cli/v2"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/records"
+)
+
+// importer upserts rows of one model.
+type importer struct {
+	// row returns pointer to empty model the row is decoded into.
+	row func() interface{}
+	// upsert creates or updates the model by its natural key,
+	// only the given fields of the row are written.
+	upsert func(tx store.Store, row interface{}, fields []string) (bool, *errdef.Error)
+}
+
+// importers are models which can be imported by their plural name.
+var importers = map[string]importer{
+	"people": {
+		row:    func() interface{} { return &person.Person{} },
+		upsert: upsertPerson,
+	},
+	"books": {
+		row:    func() interface{} { return &book.Book{} },
+		upsert: upsertBook,
+	},
+}
+
+// importOptions control how rows are written.
+type importOptions struct {
+	// DryRun rolls back all changes, the report tells what would be done.
+	DryRun bool
+	// Chunk is number of rows committed together. If it is not positive
+	// the whole input is one transaction, so nothing is imported if any row fails.
+	Chunk int
+}
+
+// importReport is the result of import. Rows of chunks with a failed
+// row are rolled back and counted as RolledBack.
+type importReport struct {
+	DryRun     bool          `json:"dry_run"`
+	Rows       int           `json:"rows"`
+	Created    int           `json:"created"`
+	Updated    int           `json:"updated"`
+	RolledBack int           `json:"rolled_back"`
+	Failed     int           `json:"failed"`
+	Errors     []importError `json:"errors,omitempty"`
+}
+
+// importError tells why the row on the line was not imported.
+type importError struct {
+	Line  int           `json:"line"`
+	Error *errdef.Error `json:"error"`
+}
+
+// errRollback rolls back chunk of import without failing it.
+var errRollback = errors.New("rollback")
+
+// runImport reads all rows and upserts them chunk by chunk. Rows which
+// can't be decoded or saved are reported and rolled back to their
+// savepoint, the import goes on with the next row. It fails only if the input can't be read or on internal error.
+func runImport(s store.Store, rd *records.Reader, imp importer, opts importOptions) (importReport, *errdef.Error) {
+	report := importReport{DryRun: opts.DryRun}
+	for done := false; !done; {
+		var created, updated, rows int
+		var failed []importError
+		err := s.Transaction(func(tx store.Store) error {
+			for opts.Chunk <= 0 || rows < opts.Chunk {
+				v := imp.row()
+				row, err := rd.Next(v)
+				if err == io.EOF {
+					done = true
+					break
+				}
+				rows++
+				if err != nil {
+					errSet, ok := err.(*errdef.Error)
+					if !ok {
+						return errdef.Wrap(err, errdef.CodeInvalidArgument, err.Error()).WithProcess(records.ProcessName)
+					}
+					failed = append(failed, importError{Line: row.Line, Error: errSet})
+					continue
+				}
+				// every row runs in a savepoint, so a failed statement
+				// doesn't abort the rest of the chunk
+				var isNew bool
+				errSet := errdef.FromError(tx.Transaction(func(tx store.Store) error {
+					var errSet *errdef.Error
+					if isNew, errSet = imp.upsert(tx, v, row.Fields); errSet != nil {
+						return errSet
+					}
+					return nil
+				}))
+				switch {
+				case errSet != nil && errdef.IsInternal(errSet):
+					return errSet
+				case errSet != nil:
+					failed = append(failed, importError{Line: row.Line, Error: errSet.WithMeta("line", strconv.Itoa(row.Line))})
+				case isNew:
+					created++
+				default:
+					updated++
+				}
+			}
+			if len(failed) > 0 || opts.DryRun {
+				return errRollback
+			}
+			return nil
+		})
+		if err != nil && err != errRollback {
+			return report, errdef.FromError(err)
+		}
+		report.Rows += rows
+		report.Failed += len(failed)
+		report.Errors = append(report.Errors, failed...)
+		if len(failed) > 0 && !opts.DryRun {
+			report.RolledBack += created + updated
+			continue
+		}
+		report.Created += created
+		report.Updated += updated
+	}
+	return report, nil
+}
+
+// upsertPerson creates the person or updates the one with the same email.
+func upsertPerson(tx store.Store, row interface{}, fields []string) (bool, *errdef.Error) {
+	input := row.(*person.Person)
+	input.Sanitize()
+	var p person.Person
+	if input.Email != "" {
+		found, _, errSet := tx.People().Find(store.PersonFilter{Email: input.Email}, firstByID)
+		if errSet != nil {
+			return false, errSet
+		}
+		if len(found) > 0 {
+			p = found[0]
+		}
+	}
+	isNew := p.ID == 0
+	copyFields(&p, input, writable(fields, personFields))
+	return isNew, savePerson(tx, &p)
+}
+
+// upsertBook creates the book or updates the one with the same call number.
+func upsertBook(tx store.Store, row interface{}, fields []string) (bool, *errdef.Error) {
+	input := row.(*book.Book)
+	var b book.Book
+	if input.CallNumber != 0 {
+		found, _, errSet := tx.Books().Find(store.BookFilter{CallNumber: input.CallNumber}, firstByID)
+		if errSet != nil {
+			return false, errSet
+		}
+		if len(found) > 0 {
+			b = found[0]
+		}
+	}
+	isNew := b.ID == 0
+	copyFields(&b, input, writable(fields, bookFields))
+	return isNew, saveBook(tx, &b)
+}
+
+// writable returns the fields which are among the writable ones.
+func writable(fields, allowed []string) []string {
+	var result []string
+	for _, a := range allowed {
+		for _, f := range fields {
+			if f == a {
+				result = append(result, a)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// importRows imports people or books from the request body. The format
+// is taken from the format parameter or the Content-Type, the body may
+// be gzipped. It responds 422 with the report if any row failed.
+func (s *server) importRows(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	imp := importers[mux.Vars(r)["kind"]]
+
+	format, ok := records.FormatOf("", r.Header.Get("Content-Type"))
+	if v := q.Get("format"); v != "" {
+		var errSet *errdef.Error
+		if format, errSet = records.ParseFormat(v); errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+	} else if !ok {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("format is required, use the format parameter or Content-Type").WithMeta("field", "format"))
+		return
+	}
+	var opts importOptions
+	if v := q.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("dry_run must be a boolean").WithMeta("field", "dry_run"))
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	if v := q.Get("chunk"); v != "" {
+		chunk, err := strconv.Atoi(v)
+		if err != nil || chunk < 0 {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("chunk must be a positive number").WithMeta("field", "chunk"))
+			return
+		}
+		opts.Chunk = chunk
+	}
+
+	body := io.Reader(r.Body)
+	if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid gzip"))
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+	rd, errSet := records.NewReader(body, format, imp.row())
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	report, errSet := runImport(s.store, rd, imp, opts)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	status := http.StatusOK
+	if report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	httpio.WriteJSON(w, status, &report)
+}
+
+// ImportCMD imports people or books from a file.
+var ImportCMD = &cli.Command{
+	Name:      "import",
+	Usage:     "import people or books from CSV or NDJSON file, upserting by email or call number",
+	ArgsUsage: "people|books FILE",
+	Flags: append(configFlags(),
+		&cli.StringFlag{Name: "format", Usage: "csv or ndjson, guessed from the file extension by default"},
+		&cli.BoolFlag{Name: "dry-run", Usage: "report what would be imported without changing anything"},
+		&cli.IntFlag{Name: "chunk", Usage: "commit every N rows, the whole file is one transaction by default"},
+	),
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() != 2 {
+			return cli.Exit("kind and file are required", 1)
+		}
+		imp, ok := importers[ctx.Args().Get(0)]
+		if !ok {
+			return cli.Exit("kind must be people or books", 1)
+		}
+		path := ctx.Args().Get(1)
+		format, ok := records.FormatOf(path, "")
+		if ctx.IsSet("format") {
+			var errSet *errdef.Error
+			if format, errSet = records.ParseFormat(ctx.String("format")); errSet != nil {
+				return errSet
+			}
+		} else if !ok {
+			return cli.Exit("format can't be guessed from the file name, use --format", 1)
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input := io.Reader(f)
+		if strings.HasSuffix(path, ".gz") {
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				return err
+			}
+			defer zr.Close()
+			input = zr
+		}
+		rd, errSet := records.NewReader(input, format, imp.row())
+		if errSet != nil {
+			return errSet
+		}
+
+		return withStore(ctx, func(s store.Store) error {
+			report, errSet := runImport(s, rd, imp, importOptions{DryRun: ctx.Bool("dry-run"), Chunk: ctx.Int("chunk")})
+			if errSet != nil {
+				return errSet
+			}
+			for _, e := range report.Errors {
+				fmt.Printf("line %d: %s\n", e.Line, e.Error.Detail)
+			}
+			fmt.Printf("rows: %d, created: %d, updated: %d, rolled back: %d, failed: %d\n",
+				report.Rows, report.Created, report.Updated, report.RolledBack, report.Failed)
+			if report.DryRun {
+				fmt.Println("dry run, nothing was changed")
+			}
+			if report.Failed > 0 {
+				return cli.Exit(fmt.Sprintf("%d rows failed", report.Failed), 1)
+			}
+			return nil
+		})
+	},
+}
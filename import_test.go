@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/store/memstore"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+func TestImport(t *testing.T) {
+	st := memstore.New()
+	srv := newTestServer(t, st)
+
+	people := `{"name":"Jack","email":"jack@gmail.com"}` + "\n" +
+		`{"name":"Jill","email":"JILL@gmail.com "}` + "\n"
+	w := do(t, srv, "POST", "/import/people?format=ndjson", people)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var report importReport
+	decode(t, w, &report)
+	assert.Equal(t, importReport{Rows: 2, Created: 2}, report)
+
+	// upsert by email keeps fields missing in the row
+	w = do(t, srv, "POST", "/import/people?format=ndjson", `{"email":"jill@gmail.com","name":"Jill Hill"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	report = importReport{}
+	decode(t, w, &report)
+	assert.Equal(t, 1, report.Updated)
+	jill, errSet := st.People().Get(2)
+	require.Nil(t, errSet)
+	assert.Equal(t, "Jill Hill", jill.Name)
+
+	books := "title,author,call_number,person_id\n" +
+		"Go,Rob,1,1\n" +
+		"Rust,Graydon,2,9\n" +
+		"Zig,Andrew,x,\n" +
+		"Go 2,Rob,1,2\n"
+	// one transaction, nothing is imported if any row fails
+	w = do(t, srv, "POST", "/import/books?format=csv", books)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	report = importReport{}
+	decode(t, w, &report)
+	assert.Equal(t, 4, report.Rows)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, 2, report.RolledBack)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Equal(t, "person_id", report.Errors[0].Error.Meta["field"])
+	assert.Equal(t, 4, report.Errors[1].Line)
+	assert.Equal(t, "CallNumber", report.Errors[1].Error.Meta["field"])
+	assertBooks(t, st)
+
+	// dry run reports what would be done
+	w = do(t, srv, "POST", "/import/books?format=csv&dry_run=true", books)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	report = importReport{}
+	decode(t, w, &report)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assertBooks(t, st)
+
+	// chunks of two rows, the second chunk fails
+	books = "call_number,title\n1,Go\n2,Rust\n3,\n4,Zig\n"
+	w = do(t, srv, "POST", "/import/books?chunk=2", books)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "format is required")
+	w = do(t, srv, "POST", "/import/books?chunk=2&format=csv", books)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+	report = importReport{}
+	decode(t, w, &report)
+	assert.Equal(t, importReport{Rows: 4, Created: 2, RolledBack: 1, Failed: 1, Errors: report.Errors}, report)
+	assertBooks(t, st, "Go", "Rust")
+}
+
+// assertBooks checks titles of all books ordered by ID.
+func assertBooks(t *testing.T, st store.Store, titles ...string) {
+	t.Helper()
+	books, _, errSet := st.Books().Find(store.BookFilter{}, paging.Params{Limit: 10, Sort: firstByID.Sort})
+	require.Nil(t, errSet)
+	var got []string
+	for _, b := range books {
+		got = append(got, b.Title)
+	}
+	assert.Equal(t, titles, got)
+}
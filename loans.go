@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Loan controllers
+
+type checkoutRequest struct {
+	PersonID uint
+}
+
+// checkoutCopy lends the copy, e.g. the one scanned at the desk.
+func (s *server) checkoutCopy(w http.ResponseWriter, r *http.Request) {
+	s.lend(w, r, s.policies.checkout)
+}
+
+// checkoutBook lends the copy of the book set aside for the person
+// or any copy of the book which is free.
+func (s *server) checkoutBook(w http.ResponseWriter, r *http.Request) {
+	s.lend(w, r, s.policies.checkoutAny)
+}
+
+func (s *server) lend(w http.ResponseWriter, r *http.Request, op func(tx store.Store, personID, id uint, now time.Time) (loan.Loan, *errdef.Error)) {
+	id, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	var req checkoutRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if req.PersonID == 0 {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("person is required").WithMeta("field", "person_id"))
+		return
+	}
+
+	var created loan.Loan
+	err := s.store.Transaction(func(tx store.Store) error {
+		l, errSet := op(tx, req.PersonID, id, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		created = l
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
+
+// returnRequest is the optional body of return. BranchID is the branch
+// the copy is returned at, the copy stays where it is if it is not set.
+type returnRequest struct {
+	BranchID uint
+}
+
+func (s *server) returnCopy(w http.ResponseWriter, r *http.Request) {
+	copyID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
+	}
+	var req returnRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid json"))
+			return
+		}
+	}
+
+	var returned loan.Loan
+	err = s.store.Transaction(func(tx store.Store) error {
+		l, errSet := s.policies.giveBack(tx, copyID, req.BranchID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		returned = l
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &returned)
+}
+
+func (s *server) renewLoan(w http.ResponseWriter, r *http.Request) {
+	loanID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var renewed loan.Loan
+	err := s.store.Transaction(func(tx store.Store) error {
+		l, errSet := s.policies.renew(tx, loanID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		renewed = l
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &renewed)
+}
+
+func (s *server) getLoan(w http.ResponseWriter, r *http.Request) {
+	loanID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	l, errSet := s.store.Loans().Get(loanID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &l)
+}
+
+// getPersonLoans returns loan history of a person.
+func (s *server) getPersonLoans(w http.ResponseWriter, r *http.Request) {
+	s.listLoans(w, r, func(f *store.LoanFilter, id uint) { f.PersonID = id })
+}
+
+// getBookLoans returns loan history of all copies of a book.
+func (s *server) getBookLoans(w http.ResponseWriter, r *http.Request) {
+	s.listLoans(w, r, func(f *store.LoanFilter, id uint) { f.BookID = id })
+}
+
+// getCopyLoans returns loan history of a copy.
+func (s *server) getCopyLoans(w http.ResponseWriter, r *http.Request) {
+	s.listLoans(w, r, func(f *store.LoanFilter, id uint) { f.CopyID = id })
+}
+
+func (s *server) listLoans(w http.ResponseWriter, r *http.Request, byID func(f *store.LoanFilter, id uint)) {
+	id, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, loan.Sortable, "-checked_out_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	filter, errSet := loanFilter(q)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	byID(&filter, id)
+
+	loans, total, errSet := s.store.Loans().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(loans), func(i int) []interface{} {
+		return loans[i].SortValues(params.Sort)
+	})
+	loans = loans[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &loans, Paging: page})
+}
+
+// Loan operations, they are expected to run inside of transaction.
+
+// checkout lends the copy to the person. The book of the copy is locked
+// first, so its copies and hold queue are never changed concurrently.
+// A copy set aside for someone else can't be checked out, the hold
+// of the person borrowing the book is fulfilled. People with too
+// high fines balance can't borrow books.
+func (p policies) checkout(tx store.Store, personID, copyID uint, now time.Time) (loan.Loan, *errdef.Error) {
+	c, errSet := lockCopy(tx, copyID)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if _, errSet := tx.People().Get(personID); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if errSet := p.checkBalance(tx, personID); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if c.Status == item.OnLoan {
+		return loan.Loan{}, errdef.ErrAlreadyExistsf("copy %d is already on loan", copyID).WithProcess(loan.ProcessName)
+	}
+
+	holds, errSet := p.openBookHolds(tx, c.BookID, now)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if ready, ok := holds.ByCopyID(copyID); ok && ready.PersonID != personID {
+		return loan.Loan{}, errdef.ErrFailedPreconditionf("copy %d is held for another person", copyID).WithProcess(loan.ProcessName)
+	}
+	if errSet := c.Lend(); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if errSet := tx.Copies().Save(&c); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+
+	l := loan.New(personID, c.BookID, c.ID, p.loan, now)
+	if errSet := tx.Loans().Save(&l); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+
+	if h, ok := holds.GetByPersonID(personID); ok {
+		if errSet := h.Fulfill(now); errSet != nil {
+			return l, errSet
+		}
+		if errSet := tx.Holds().Save(&h); errSet != nil {
+			return l, errSet
+		}
+		if errSet := releaseTransfer(tx, h, now); errSet != nil {
+			return l, errSet
+		}
+		// another copy could be set aside for the person
+		if _, errSet := p.openBookHolds(tx, c.BookID, now); errSet != nil {
+			return l, errSet
+		}
+	}
+	return l, nil
+}
+
+// checkoutAny lends the person the copy of the book set aside for them,
+// or the first free copy if they have no ready hold.
+func (p policies) checkoutAny(tx store.Store, personID, bookID uint, now time.Time) (loan.Loan, *errdef.Error) {
+	if _, errSet := tx.Books().Lock(bookID); errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	holds, errSet := p.openBookHolds(tx, bookID, now)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if h, ok := holds.GetByPersonID(personID); ok && h.Status == hold.Ready {
+		return p.checkout(tx, personID, *h.CopyID, now)
+	}
+	copies, errSet := tx.Copies().OfBook(bookID)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	for _, c := range freeCopies(copies, holds) {
+		return p.checkout(tx, personID, c.ID, now)
+	}
+	return loan.Loan{}, errdef.ErrFailedPreconditionf("no copy of book %d is available, place a hold", bookID).WithProcess(loan.ProcessName)
+}
+
+// giveBack closes the active loan of the copy, charges the fine if
+// it is late and sets the copy aside for the next person in the hold queue.
+// The copy is located at the branch it is returned at, if it is known.
+func (p policies) giveBack(tx store.Store, copyID, branchID uint, now time.Time) (loan.Loan, *errdef.Error) {
+	c, errSet := lockCopy(tx, copyID)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if branchID != 0 {
+		if errSet := checkBranch(tx, branchID, "branch_id"); errSet != nil {
+			return loan.Loan{}, errSet
+		}
+		c.LocationID = branchID
+	}
+	active, errSet := tx.Loans().Active(copyID)
+	if errSet != nil {
+		return loan.Loan{}, errSet
+	}
+	if active == nil {
+		return loan.Loan{}, errdef.ErrFailedPreconditionf("copy %d is not on loan", copyID).WithProcess(loan.ProcessName)
+	}
+	l := *active
+	if errSet := l.Return(now); errSet != nil {
+		return l, errSet
+	}
+	if errSet := tx.Loans().Save(&l); errSet != nil {
+		return l, errSet
+	}
+	if errSet := c.GiveBack(); errSet != nil {
+		return l, errSet
+	}
+	if errSet := tx.Copies().Save(&c); errSet != nil {
+		return l, errSet
+	}
+	if errSet := p.chargeOverdue(tx, l); errSet != nil {
+		return l, errSet
+	}
+	if _, errSet := p.openBookHolds(tx, c.BookID, now); errSet != nil {
+		return l, errSet
+	}
+	return l, nil
+}
+
+// renew extends the loan, unless other people wait for the book. People
+// only wait when no copy of the book is free.
+func (p policies) renew(tx store.Store, loanID uint, now time.Time) (loan.Loan, *errdef.Error) {
+	l, errSet := tx.Loans().Get(loanID)
+	if errSet != nil {
+		return l, errSet
+	}
+	if _, errSet := tx.Books().Lock(l.BookID); errSet != nil {
+		return l, errSet
+	}
+	// reload as the loan could change before the book was locked
+	l, errSet = tx.Loans().Get(loanID)
+	if errSet != nil {
+		return l, errSet
+	}
+	holds, errSet := p.openBookHolds(tx, l.BookID, now)
+	if errSet != nil {
+		return l, errSet
+	}
+	if _, ok := holds.Next(); ok {
+		return l, errdef.ErrFailedPreconditionf("book %d is held by other people", l.BookID).WithProcess(loan.ProcessName)
+	}
+	if errSet := l.Renew(p.loan, now); errSet != nil {
+		return l, errSet
+	}
+	if errSet := tx.Loans().Save(&l); errSet != nil {
+		return l, errSet
+	}
+	return l, nil
+}
@@ -1,201 +1,744 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
 	"github.com/urfave/cli/v2"
 
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/store/sqlstore"
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/mergepatch"
+	"github.com/investapp/backend/pkg/paging"
+	"github.com/investapp/backend/pkg/valid"
 )
 
-
 var app = &cli.App{
-	Name:        "pokerai",
-	Usage:       "pokerai play",
-	Description: "PokerAI bot build, raw power, superhuman!",
+	Name:  "backend",
+	Usage: "library API server",
 
 	Commands: []*cli.Command{
-		BuildCMD,
+		ServeCMD,
+		ConfigCMD,
+		MigrateCMD,
+		ImportCMD,
+		ExportCMD,
+		UserCMD,
 	},
 }
 
-var BuildCMD = &cli.Command{
-	Name:  "build",
-	Usage: "will try to train the agent",
-	Flags: []cli.Flag{
-		&cli.StringFlag{Name: "cfr.db", Value: ".cfr.db"},
-	},
+// ServeCMD runs the API server.
+var ServeCMD = &cli.Command{
+	Name:  "serve",
+	Usage: "run the API server",
+	Flags: configFlags(),
 	Action: func(ctx *cli.Context) error {
-		
-		return nil
+		cfg, errSet := loadConfig(ctx)
+		if errSet != nil {
+			return errSet
+		}
+		return serve(cfg)
 	},
 }
 
-type Person struct {
-	gorm.Model
-	Name string
-	Email string `gorm:"typevarchar(100);unique_index"` // nastavení gormu, aby byl jen jeden email pro každého uživatele 
-	Books []Book
-}
+// personFields are the fields of Person clients can write.
+var personFields = []string{"Name", "Email", "PickupBranchID"}
 
-type Book struct {
-	gorm.Model 
+// bookFields are the fields of Book clients can write.
+var bookFields = []string{"Title", "Author", "CallNumber", "ISBN", "PersonID"}
 
-	Title string
-	Author string
-	CallNumber int `gorm:"unique_index"`
-	PersonID int
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
 }
 
-var (
-	person = &Person{
-		Name: "Jack", Email: "jack@gmail.com",
-	}
-	books = []Book{
-		{Title: "The rules of Thinking", Author:"Richard Templar", CallNumber: 1234, PersonID: 1},
-		{Title: "Happy world champion", Author:"Deko Montera", CallNumber: 12345, PersonID: 1},
+// openDB connects to the database and sets up its connection pool.
+func openDB(cfg config.DB) (*gorm.DB, error) {
+	db, err := gorm.Open("postgres", cfg.DSN())
+	if err != nil {
+		return nil, err
 	}
-)
-
-var db *gorm.DB
-var err error
+	db.DB().SetMaxOpenConns(cfg.MaxOpenConns)
+	db.DB().SetMaxIdleConns(cfg.MaxIdleConns)
+	db.DB().SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	return db, nil
+}
 
-func main () {
-	
-	// Loading env variables it needs fist call command (source .env) in terminal
-	dialect := os.Getenv("DIALECT")
-	host := os.Getenv("HOST")
-	dbPort := os.Getenv("DBPORT")
-	user := os.Getenv("USER")
-	dbName := os.Getenv("NAME")
-	password := os.Getenv("PASSWORD")
+// withStore connects to the database and runs fn with store on top of it.
+func withStore(ctx *cli.Context, fn func(s store.Store) error) error {
+	cfg, errSet := loadConfig(ctx)
+	if errSet != nil {
+		return errSet
+	}
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(sqlstore.New(db))
+}
 
-	//DAtabase connection string
-	dbURI := fmt.Sprintf("host=%s user=%s dbname=%s sslmode=disable password=%s port=%s", host, user, dbName, password, dbPort )
+// serve runs the API server until it gets SIGINT or SIGTERM. Then the
+// readiness probe starts to fail, in-flight requests are drained for up
+// to the shutdown timeout and the database is closed. The schema is not
+// changed, run `migrate up` before the first start and after upgrades.
+func serve(cfg config.Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Open connection to the database
-	db, err = gorm.Open(dialect, dbURI)
+	db, err := openDB(cfg.DB)
 	if err != nil {
-		log.Fatal(err)
-	} else {
-		fmt.Println("Successfully connected to database!")
+		return err
 	}
+	log.Printf("connected to database %s at %s:%d", cfg.DB.Name, cfg.DB.Host, cfg.DB.Port)
 
-	// Close connection to database when the main function finishes
+	// Close connection to database when the function finishes
 	defer db.Close()
 
-	// Make migration to the database it passes our struct ot the database
-	// so if my database will get request to create Person it will need to folow the defined struct if they have not already been created.
-	db.AutoMigrate(&Person{})
-	db.AutoMigrate(&Book{})
+	migrator, errSet := newMigrator(db)
+	if errSet != nil {
+		return errSet
+	}
+	pending, errSet := migrator.Pending(ctx)
+	if errSet != nil {
+		return errSet
+	}
+	if len(pending) > 0 {
+		log.Printf("%d migrations are not applied, run migrate up", len(pending))
+	}
+
+	st := sqlstore.New(db)
+	api := newServer(st,
+		readyCheck{name: "database", check: func(ctx context.Context) *errdef.Error {
+			if err := db.DB().PingContext(ctx); err != nil {
+				return errdef.Wrap(err, errdef.CodeUnavailable, "database is not reachable")
+			}
+			return nil
+		}},
+		readyCheck{name: "migrations", check: func(ctx context.Context) *errdef.Error {
+			pending, errSet := migrator.Pending(ctx)
+			if errSet != nil {
+				return errSet
+			}
+			if len(pending) > 0 {
+				return errdef.ErrFailedPreconditionf("%d migrations are not applied", len(pending))
+			}
+			return nil
+		}},
+	)
+	api.policies = newPolicies(cfg)
+	if api.auth, err = newAuthenticator(cfg.Auth); err != nil {
+		return err
+	}
+	if !api.auth.enabled() {
+		log.Println("auth.secret is not set, sign in is off and only the catalog can be read")
+	}
+	srv := &http.Server{Addr: cfg.HTTP.Addr, Handler: api}
+
+	// background jobs run until the server stops
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		sweepHolds(jobsCtx, st, api.policies, holdSweepInterval)
+	}()
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		refreshRecommendations(jobsCtx, st, &api.recs, recommendationInterval)
+	}()
+	if api.auth.keys != nil {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			rotateKeys(jobsCtx, api.auth.keys, cfg.Auth.KeyFile, cfg.Auth.KeyRotation)
+		}()
+	}
+	if days := cfg.Trash.PurgeAfterDays; days > 0 {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			purgeTrash(jobsCtx, st, time.Duration(days)*24*time.Hour, trashPurgeInterval)
+		}()
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		log.Printf("listening on %s", cfg.HTTP.Addr)
+		if cfg.HTTP.TLS() {
+			served <- srv.ListenAndServeTLS(cfg.HTTP.TLSCert, cfg.HTTP.TLSKey)
+			return
+		}
+		served <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		stopJobs()
+		jobs.Wait()
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	log.Printf("shutting down, draining requests for up to %s", cfg.HTTP.ShutdownTimeout)
+	api.drain()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	err = srv.Shutdown(drainCtx)
+	stopJobs()
+	jobs.Wait()
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeDeadlineExceeded, "failed to drain requests")
+	}
+	log.Println("server stopped")
+	return nil
+}
+
+// API controllers
+func (s *server) getPeople(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, person.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	
-	// API router
-	router := mux.NewRouter()
-	// returns all people
-	router.HandleFunc("/people", getPeople).Methods("GET")
-	// returns person by id
-	router.HandleFunc("/person/{id}", getPerson).Methods("GET")
-	// return book by id
-	router.HandleFunc("/book/{id}", getBook).Methods("GET")
-	// create person 
-	router.HandleFunc("/create/person", createPerson).Methods("POST")
-	// create book
-	router.HandleFunc("/create/book", createBook).Methods("POST")
-	// get all books
-	router.HandleFunc("/books", getBooks).Methods("GET")
-	// delete person by id
-	router.HandleFunc("/delete/person/{id}", deletePerson).Methods("DELETE")
-	// delete book by id
-	router.HandleFunc("/delete/book/{id}", deleteBook).Methods("DELETE")
+	people, total, errSet := s.store.People().Find(personFilter(q), params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(people), func(i int) []interface{} {
+		return people[i].SortValues(params.Sort)
+	})
+	people = people[:n]
+	page.Total = total
 
-	log.Fatal(http.ListenAndServe(":8080", router))
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &people, Paging: page})
 }
 
-// API controllers 
-func getPeople(w http.ResponseWriter, r *http.Request) {
-	var people []Person 
-	db.Find(&people)
+func (s *server) getPerson(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	p, errSet := s.store.People().Get(personID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	// It will find all books for person with sended id
+	books, errSet := s.store.Books().FindByPersonID(personID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	json.NewEncoder(w).Encode(&people)
+	p.Books = books
+	httpio.WriteJSON(w, http.StatusOK, &p)
 }
 
+func (s *server) createPerson(w http.ResponseWriter, r *http.Request) {
+	var input person.Person
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var created person.Person
+	copyFields(&created, &input, personFields)
+	if errSet := savePerson(s.store, &created); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
 
-func getPerson(w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
-	var books []Book
-	var person Person 
-	db.First(&person, params["id"])
-	db.Model(&person).Related(&books) // It will find all books for person with sended id 
+// replacePerson replaces all writable fields of the person.
+func (s *server) replacePerson(w http.ResponseWriter, r *http.Request) {
+	var input person.Person
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.updatePerson(w, r, func(p *person.Person) *errdef.Error {
+		copyFields(p, &input, personFields)
+		return nil
+	})
+}
 
-	person.Books = books 
-	json.NewEncoder(w).Encode(&person)
+// patchPerson applies JSON merge patch to the person.
+func (s *server) patchPerson(w http.ResponseWriter, r *http.Request) {
+	patch, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
+	}
+	s.updatePerson(w, r, func(p *person.Person) *errdef.Error {
+		return applyPatch(p, patch, personFields)
+	})
 }
 
-func createPerson(w http.ResponseWriter, r *http.Request) {
-	var person Person 
-	json.NewDecoder(r.Body).Decode(&person)
+func (s *server) updatePerson(w http.ResponseWriter, r *http.Request, change func(*person.Person) *errdef.Error) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	createdPerson := db.Create(&person)
-	err = createdPerson.Error
+	var updated person.Person
+	err := s.store.Transaction(func(tx store.Store) error {
+		p, errSet := tx.People().Lock(personID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := change(&p); errSet != nil {
+			return errSet
+		}
+		if errSet := savePerson(tx, &p); errSet != nil {
+			return errSet
+		}
+		updated = p
+		return nil
+	})
 	if err != nil {
-		json.NewEncoder(w).Encode(err)
-	} else {
-		json.NewEncoder(w).Encode(&person)
+		httpio.WriteErr(w, r, err)
+		return
 	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
 }
 
-func deletePerson(w http.ResponseWriter, r *http.Request){
-	params := mux.Vars(r)
+// deletePerson moves the person to the trash. The books parameter tells
+// what happens to their books: refuse (default) keeps the person if they
+// have any, cascade deletes the books too and reassign gives them to
+// the person in the to parameter.
+func (s *server) deletePerson(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	q := r.URL.Query()
+	policy := person.Refuse
+	if v := q.Get("books"); v != "" {
+		if policy, errSet = person.ParseBooksPolicy(v); errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+	}
+	var to uint
+	if policy == person.Reassign {
+		id, err := strconv.ParseUint(q.Get("to"), 10, 64)
+		if err != nil || id == 0 || uint(id) == personID {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("to must be ID of another person").WithMeta("field", "to"))
+			return
+		}
+		to = uint(id)
+	}
+
+	var deleted person.Person
+	err := s.store.Transaction(func(tx store.Store) error {
+		p, errSet := tx.People().Lock(personID)
+		if errSet != nil {
+			return errSet
+		}
+		books, errSet := tx.Books().FindByPersonID(personID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := handleBooks(tx, p, books, policy, to); errSet != nil {
+			return errSet
+		}
+		if errSet := tx.People().Delete(personID); errSet != nil {
+			return errSet
+		}
+		deleted = p
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
 
-	var person Person
-	db.First(&person, params["id"])
-	db.Delete(&person)
+	httpio.WriteJSON(w, http.StatusOK, &deleted)
+}
 
-	json.NewEncoder(w).Encode(&person)
+// handleBooks applies the policy to books of the person who is deleted.
+func handleBooks(tx store.Store, p person.Person, books []book.Book, policy person.BooksPolicy, to uint) *errdef.Error {
+	if len(books) == 0 {
+		return nil
+	}
+	switch policy {
+	case person.Cascade:
+		for _, b := range books {
+			if errSet := trashBook(tx, b.ID); errSet != nil {
+				return errSet
+			}
+		}
+	case person.Reassign:
+		if _, errSet := tx.People().Lock(to); errSet != nil {
+			if errdef.IsNotFound(errSet) {
+				return errdef.ErrInvalidArgumentf("person %d does not exist", to).WithMeta("field", "to")
+			}
+			return errSet
+		}
+		for _, b := range books {
+			b.PersonID = int(to)
+			if errSet := tx.Books().Save(&b); errSet != nil {
+				return errSet
+			}
+		}
+	default:
+		return errdef.ErrFailedPreconditionf("person %d has %d books, use books=cascade or books=reassign", p.ID, len(books)).
+			WithProcess(person.ProcessName).
+			WithMeta("field", "books")
+	}
+	return nil
 }
 
 // Books controllers
 
-func getBooks(w http.ResponseWriter, r *http.Request) {
-	var books []Book 
+func (s *server) getBooks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, book.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	filter, errSet := bookFilter(q)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	db.Find(&books)
+	books, total, errSet := s.store.Books().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(books), func(i int) []interface{} {
+		return books[i].SortValues(params.Sort)
+	})
+	books = books[:n]
+	page.Total = total
+	ptrs := make([]*book.Book, len(books))
+	for i := range books {
+		ptrs[i] = &books[i]
+	}
+	if errSet := loadAuthors(s.store, ptrs...); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	json.NewEncoder(w).Encode(&books)
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &books, Paging: page})
 }
 
-func getBook (w http.ResponseWriter, r *http.Request) {
-	params := mux.Vars(r)
+// getBookByISBN returns the book with the ISBN-10 or ISBN-13,
+// hyphens are ignored.
+func (s *server) getBookByISBN(w http.ResponseWriter, r *http.Request) {
+	isbn := book.NormalizeISBN(mux.Vars(r)["isbn"])
+	if !valid.ISBN13(isbn) {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgumentf("isbn '%s' is not valid", mux.Vars(r)["isbn"]).WithMeta("field", "isbn"))
+		return
+	}
+	b, errSet := s.store.Books().GetByISBN(isbn)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if errSet := loadAuthors(s.store, &b); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &b)
+}
+
+// searchBooks finds books by words of their title and author. Results are
+// ordered by relevance, so they are paged by offset and can't be sorted.
+func (s *server) searchBooks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("sort") != "" || q.Get("cursor") != "" {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("search results are ordered by relevance, page them by offset"))
+		return
+	}
+	params, errSet := paging.Parse(q, book.Sortable, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	query, errSet := book.ParseQuery(q.Get("q"))
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	matches, total, errSet := s.store.Books().Search(query, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewOffsetPage(params, len(matches))
+	matches = matches[:n]
+	page.Total = total
+	ptrs := make([]*book.Book, len(matches))
+	for i := range matches {
+		ptrs[i] = &matches[i].Book
+	}
+	if errSet := loadAuthors(s.store, ptrs...); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &matches, Paging: page})
+}
+
+func (s *server) getBook(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	b, errSet := s.store.Books().Get(bookID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if errSet := loadAuthors(s.store, &b); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	var book Book
+	httpio.WriteJSON(w, http.StatusOK, &b)
+}
 
-	db.First(&book, params["id"])
+func (s *server) createBook(w http.ResponseWriter, r *http.Request) {
+	var input book.Book
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	json.NewEncoder(w).Encode(&book)
+	created := book.Book{Authors: []author.Credit{}}
+	copyFields(&created, &input, bookFields)
+	if errSet := saveBook(s.store, &created); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
 }
 
-func createBook(w http.ResponseWriter, r *http.Request) {
-	var book Book 
-	json.NewDecoder(r.Body).Decode(&book)
+// replaceBook replaces all writable fields of the book.
+func (s *server) replaceBook(w http.ResponseWriter, r *http.Request) {
+	var input book.Book
+	if errSet := httpio.ReadJSON(r, &input); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.updateBook(w, r, func(b *book.Book) *errdef.Error {
+		copyFields(b, &input, bookFields)
+		return nil
+	})
+}
 
-	createdBook := db.Create(&book)
-	err = createdBook.Error
+// patchBook applies JSON merge patch to the book.
+func (s *server) patchBook(w http.ResponseWriter, r *http.Request) {
+	patch, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		json.NewEncoder(w).Encode(err)
-	} else {
-		json.NewEncoder(w).Encode(&book)
+		httpio.WriteErr(w, r, errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid"))
+		return
 	}
+	s.updateBook(w, r, func(b *book.Book) *errdef.Error {
+		return applyPatch(b, patch, bookFields)
+	})
 }
 
-func deleteBook(w http.ResponseWriter, r *http.Request){
-	params := mux.Vars(r)
+func (s *server) updateBook(w http.ResponseWriter, r *http.Request, change func(*book.Book) *errdef.Error) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var updated book.Book
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Books().Lock(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := change(&b); errSet != nil {
+			return errSet
+		}
+		if errSet := saveBook(tx, &b); errSet != nil {
+			return errSet
+		}
+		if errSet := loadAuthors(tx, &b); errSet != nil {
+			return errSet
+		}
+		updated = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}
 
-	var book Book
-	db.First(&book, params["id"])
-	db.Delete(&book)
+func (s *server) deleteBook(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
 
-	json.NewEncoder(w).Encode(&book)
-}
\ No newline at end of file
+	var deleted book.Book
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Books().Lock(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := trashBook(tx, bookID); errSet != nil {
+			return errSet
+		}
+		deleted = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &deleted)
+}
+
+// Write helpers
+
+// savePerson validates and stores the person. Email has to be unique
+// among all people, including the deleted ones.
+func savePerson(tx store.Store, p *person.Person) *errdef.Error {
+	p.Sanitize()
+	if errSet := p.Validate(); errSet != nil {
+		return errSet
+	}
+	if p.PickupBranchID != nil {
+		if errSet := checkBranch(tx, *p.PickupBranchID, "pickup_branch_id"); errSet != nil {
+			return errSet
+		}
+	}
+	return tx.People().Save(p)
+}
+
+// trashBook moves the book to the trash. Books with copies can't be
+// deleted, their copies have to be deleted first.
+func trashBook(tx store.Store, bookID uint) *errdef.Error {
+	copies, errSet := tx.Copies().OfBook(bookID)
+	if errSet != nil {
+		return errSet
+	}
+	if len(copies) > 0 {
+		return errdef.ErrFailedPreconditionf("book %d has %d copies, delete them first", bookID, len(copies)).WithProcess(book.ProcessName)
+	}
+	return tx.Books().Delete(bookID)
+}
+
+// saveBook validates and stores the book. Call number has to be unique
+// among all books, including the deleted ones. Byline of the book with
+// credits is rebuilt from them.
+func saveBook(tx store.Store, b *book.Book) *errdef.Error {
+	b.Sanitize()
+	if b.ID != 0 {
+		credits, errSet := tx.Authors().Credits(b.ID)
+		if errSet != nil {
+			return errSet
+		}
+		if len(credits[b.ID]) > 0 {
+			b.Author = author.Byline(credits[b.ID])
+		}
+	}
+	if errSet := b.Validate(); errSet != nil {
+		return errSet
+	}
+	if b.PersonID != 0 {
+		if _, errSet := tx.People().Get(uint(b.PersonID)); errSet != nil {
+			if errdef.IsNotFound(errSet) {
+				return errdef.ErrInvalidArgumentf("person %d does not exist", b.PersonID).WithMeta("field", "person_id")
+			}
+			return errSet
+		}
+	}
+	return tx.Books().Save(b)
+}
+
+// copyFields copies the named fields from src to dst struct pointer.
+func copyFields(dst, src interface{}, fields []string) {
+	d := reflect.ValueOf(dst).Elem()
+	s := reflect.ValueOf(src).Elem()
+	for _, name := range fields {
+		d.FieldByName(name).Set(s.FieldByName(name))
+	}
+}
+
+// applyPatch applies JSON merge patch to the writable fields of dst.
+// Patch member names are matched case insensitively like encoding/json does,
+// any other member is refused. Members set to null are reset to zero value.
+func applyPatch(dst interface{}, patch []byte, fields []string) *errdef.Error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &members); err != nil {
+		return errdef.Wrap(err, errdef.CodeInvalidArgument, "patch must be json object").WithProcess(mergepatch.ProcessName)
+	}
+	canonical := make(map[string]json.RawMessage, len(members))
+	for key, value := range members {
+		name := ""
+		for _, f := range fields {
+			if strings.EqualFold(f, key) {
+				name = f
+			}
+		}
+		if name == "" {
+			return errdef.ErrInvalidArgumentf("field %s can not be changed", key).WithMeta("field", key)
+		}
+		canonical[name] = value
+	}
+	patch, _ = json.Marshal(canonical)
+
+	doc, err := json.Marshal(dst)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to encode document")
+	}
+	merged, errSet := mergepatch.Apply(doc, patch)
+	if errSet != nil {
+		return errSet
+	}
+	patched := reflect.New(reflect.TypeOf(dst).Elem()).Interface()
+	if err := json.Unmarshal(merged, patched); err != nil {
+		return errdef.Wrap(err, errdef.CodeInvalidArgument, "patch is not valid").WithProcess(mergepatch.ProcessName)
+	}
+	copyFields(dst, patched, fields)
+	return nil
+}
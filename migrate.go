@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/jinzhu/gorm"
+	"github.com/urfave/cli/v2"
+
+	"github.com/investapp/backend/migrations"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/migrate"
+)
+
+// MigrateCMD manages the database schema.
+var MigrateCMD = &cli.Command{
+	Name:  "migrate",
+	Usage: "manage database schema migrations",
+	Flags: configFlags(),
+	Subcommands: []*cli.Command{
+		{
+			Name:      "up",
+			Usage:     "apply pending migrations",
+			ArgsUsage: "[N]",
+			Action: func(ctx *cli.Context) error {
+				n, err := countArg(ctx, 0)
+				if err != nil {
+					return err
+				}
+				return withMigrator(ctx, func(m *migrate.Migrator) error {
+					done, errSet := m.Up(ctx.Context, n)
+					for _, mig := range done {
+						fmt.Println("applied", mig)
+					}
+					if errSet != nil {
+						return errSet
+					}
+					if len(done) == 0 {
+						fmt.Println("no pending migrations")
+					}
+					return nil
+				})
+			},
+		},
+		{
+			Name:      "down",
+			Usage:     "revert N last applied migrations",
+			ArgsUsage: "N",
+			Action: func(ctx *cli.Context) error {
+				n, err := countArg(ctx, -1)
+				if err != nil {
+					return err
+				}
+				return withMigrator(ctx, func(m *migrate.Migrator) error {
+					done, errSet := m.Down(ctx.Context, n)
+					for _, mig := range done {
+						fmt.Println("reverted", mig)
+					}
+					if errSet != nil {
+						return errSet
+					}
+					return nil
+				})
+			},
+		},
+		{
+			Name:  "status",
+			Usage: "list migrations and when they were applied",
+			Action: func(ctx *cli.Context) error {
+				return withMigrator(ctx, func(m *migrate.Migrator) error {
+					status, errSet := m.Status(ctx.Context)
+					if errSet != nil {
+						return errSet
+					}
+					for _, s := range status {
+						applied := "pending"
+						if s.Applied() {
+							applied = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+						}
+						fmt.Printf("%-40s %s\n", s.Migration, applied)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			Name:      "create",
+			Usage:     "create empty up and down migration files",
+			ArgsUsage: "NAME",
+			Flags: []cli.Flag{
+				&cli.StringFlag{Name: "dir", Value: "migrations", Usage: "directory of the migration files"},
+			},
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 1 {
+					return cli.Exit("migration name is required", 1)
+				}
+				dir := ctx.String("dir")
+				mm, errSet := migrate.Load(os.DirFS(dir))
+				if errSet != nil {
+					return errSet
+				}
+				m, errSet := mm.NewMigration(ctx.Args().First())
+				if errSet != nil {
+					return errSet
+				}
+				up, down := m.FileNames()
+				files := []struct{ name, content string }{{up, m.Up}, {down, m.Down}}
+				for _, f := range files {
+					path := filepath.Join(dir, f.name)
+					if err := ioutil.WriteFile(path, []byte(f.content), 0644); err != nil {
+						return err
+					}
+					fmt.Println("created", path)
+				}
+				return nil
+			},
+		},
+	},
+}
+
+// newMigrator creates migrator of the embedded migrations.
+func newMigrator(db *gorm.DB) (*migrate.Migrator, *errdef.Error) {
+	mm, errSet := migrate.Load(migrations.FS)
+	if errSet != nil {
+		return nil, errSet
+	}
+	return migrate.New(db.DB(), mm), nil
+}
+
+// withMigrator connects to the database and runs fn with its migrator.
+func withMigrator(ctx *cli.Context, fn func(m *migrate.Migrator) error) error {
+	cfg, errSet := loadConfig(ctx)
+	if errSet != nil {
+		return errSet
+	}
+	db, err := openDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	m, errSet := newMigrator(db)
+	if errSet != nil {
+		return errSet
+	}
+	return fn(m)
+}
+
+// countArg parses the optional number of migrations. Negative def
+// makes the argument required.
+func countArg(ctx *cli.Context, def int) (int, error) {
+	if ctx.NArg() == 0 {
+		if def < 0 {
+			return 0, cli.Exit("number of migrations is required", 1)
+		}
+		return def, nil
+	}
+	n, err := strconv.Atoi(ctx.Args().First())
+	if err != nil || n < 1 {
+		return 0, cli.Exit("number of migrations must be a positive number", 1)
+	}
+	return n, nil
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS entries;
+DROP TABLE IF EXISTS holds;
+DROP TABLE IF EXISTS loans;
+DROP TABLE IF EXISTS books;
+DROP TABLE IF EXISTS people;
@@ -0,0 +1,75 @@
+-- Tables of the library models. They match the tables gorm AutoMigrate
+-- used to create, so databases created that way are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS people (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name varchar(255),
+    email varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_people_deleted_at ON people (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS uix_people_email ON people (email);
+
+CREATE TABLE IF NOT EXISTS books (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    title varchar(255),
+    author varchar(255),
+    call_number integer,
+    person_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_books_deleted_at ON books (deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS uix_books_call_number ON books (call_number);
+
+CREATE TABLE IF NOT EXISTS loans (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    person_id integer,
+    book_id integer,
+    checked_out_at timestamp with time zone,
+    due_at timestamp with time zone,
+    returned_at timestamp with time zone,
+    renewals integer
+);
+CREATE INDEX IF NOT EXISTS idx_loans_deleted_at ON loans (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_loans_person_id ON loans (person_id);
+CREATE INDEX IF NOT EXISTS idx_loans_book_id ON loans (book_id);
+
+CREATE TABLE IF NOT EXISTS holds (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    person_id integer,
+    book_id integer,
+    status varchar(20),
+    placed_at timestamp with time zone,
+    ready_at timestamp with time zone,
+    expires_at timestamp with time zone,
+    closed_at timestamp with time zone
+);
+CREATE INDEX IF NOT EXISTS idx_holds_deleted_at ON holds (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_holds_person_id ON holds (person_id);
+CREATE INDEX IF NOT EXISTS idx_holds_book_id ON holds (book_id);
+CREATE INDEX IF NOT EXISTS idx_holds_status ON holds (status);
+
+CREATE TABLE IF NOT EXISTS entries (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    person_id integer,
+    loan_id integer,
+    kind varchar(20),
+    amount numeric(12,2),
+    note varchar(255)
+);
+CREATE INDEX IF NOT EXISTS idx_entries_deleted_at ON entries (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_entries_person_id ON entries (person_id);
+CREATE INDEX IF NOT EXISTS idx_entries_loan_id ON entries (loan_id);
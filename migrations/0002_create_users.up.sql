@@ -0,0 +1,33 @@
+-- Tables of the go-pg user models, see models/user.
+
+CREATE TABLE users (
+    id bigserial PRIMARY KEY,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    last_signed_at timestamp with time zone,
+    firstname text NOT NULL,
+    lastname text NOT NULL,
+    username text NOT NULL,
+    password text NOT NULL,
+    creator_id bigint REFERENCES users (id) ON DELETE SET NULL,
+    picture_path text,
+    crypto_address_id bigint,
+    "2fa_id" bigint,
+    "2fa_verify_id" uuid
+);
+CREATE UNIQUE INDEX users_username_key ON users (lower(username));
+
+CREATE TABLE user_contact (
+    id bigserial PRIMARY KEY,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    channel varchar(20) NOT NULL,
+    contact varchar(250) NOT NULL,
+    verified boolean NOT NULL DEFAULT false,
+    verify_id uuid,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    confirmation_requests integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX user_contact_channel_contact_key ON user_contact (channel, contact);
+CREATE UNIQUE INDEX user_contact_verify_id_key ON user_contact (verify_id);
+CREATE INDEX user_contact_user_id_idx ON user_contact (user_id);
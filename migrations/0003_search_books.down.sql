@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_books_search;
+ALTER TABLE books DROP COLUMN IF EXISTS search;
+DROP TEXT SEARCH CONFIGURATION IF EXISTS library_search;
@@ -0,0 +1,17 @@
+-- Full-text search over titles and authors of books. The library_search
+-- configuration splits text into words like the simple one and strips
+-- accents, so "bozena nemcova" finds "Božena Němcová".
+
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE TEXT SEARCH CONFIGURATION library_search (COPY = simple);
+ALTER TEXT SEARCH CONFIGURATION library_search
+    ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+
+-- Words of the title weigh more than words of the author.
+ALTER TABLE books ADD COLUMN search tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('library_search', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('library_search', coalesce(author, '')), 'B')
+) STORED;
+
+CREATE INDEX idx_books_search ON books USING GIN (search);
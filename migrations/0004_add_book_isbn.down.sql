@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uix_books_isbn;
+ALTER TABLE books DROP COLUMN IF EXISTS isbn;
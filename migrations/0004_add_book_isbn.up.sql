@@ -0,0 +1,5 @@
+-- ISBN-13 of the book, empty if it is not known. It is unique among
+-- all books including the deleted ones, empty values excepted.
+
+ALTER TABLE books ADD COLUMN isbn varchar(13) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX uix_books_isbn ON books (isbn) WHERE isbn <> '';
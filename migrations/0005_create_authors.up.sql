@@ -0,0 +1,47 @@
+-- Authors credited on books. Credits of a book are ordered by position,
+-- the role is AUTHOR, EDITOR or TRANSLATOR. books.author is kept as the
+-- byline built from the credits, so search and the author filter work
+-- as they did.
+
+CREATE TABLE authors (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    name varchar(255)
+);
+CREATE INDEX idx_authors_deleted_at ON authors (deleted_at);
+
+CREATE TABLE book_authors (
+    book_id integer NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id integer NOT NULL REFERENCES authors (id),
+    role varchar(20) NOT NULL,
+    position integer NOT NULL,
+    PRIMARY KEY (book_id, author_id, role)
+);
+CREATE INDEX idx_book_authors_author_id ON book_authors (author_id);
+
+-- Authors of the existing books are taken from their author strings.
+-- Names are separated by ';', '&' or ' and ', the same name written
+-- with different case or spacing becomes one author. Variants like
+-- "R. Templar" and "Richard Templar" have to be merged by hand.
+CREATE TEMPORARY TABLE book_author_names ON COMMIT DROP AS
+SELECT book_id, name, row_number() OVER (PARTITION BY book_id ORDER BY ordinality) AS position
+FROM (
+    SELECT b.id AS book_id, trim(regexp_replace(n.name, '\s+', ' ', 'g')) AS name, n.ordinality
+    FROM books b,
+        LATERAL regexp_split_to_table(b.author, '\s*(;|&|\s+and\s+)\s*') WITH ORDINALITY AS n(name, ordinality)
+) names
+WHERE name <> '';
+
+INSERT INTO authors (created_at, updated_at, name)
+SELECT now(), now(), min(name)
+FROM book_author_names
+GROUP BY lower(name)
+ORDER BY min(name);
+
+INSERT INTO book_authors (book_id, author_id, role, position)
+SELECT DISTINCT ON (n.book_id, a.id) n.book_id, a.id, 'AUTHOR', n.position
+FROM book_author_names n
+JOIN authors a ON lower(a.name) = lower(n.name)
+ORDER BY n.book_id, a.id, n.position;
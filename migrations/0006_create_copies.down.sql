@@ -0,0 +1,4 @@
+ALTER TABLE holds DROP COLUMN IF EXISTS copy_id;
+DROP INDEX IF EXISTS idx_loans_copy_id;
+ALTER TABLE loans DROP COLUMN IF EXISTS copy_id;
+DROP TABLE IF EXISTS copies;
@@ -0,0 +1,35 @@
+-- Physical copies of books. A book is a title now, loans and ready holds
+-- refer to the copy lent or set aside. Every existing book gets one copy
+-- whose barcode is the call number of the book, or BOOK-<id> when the
+-- book has no call number.
+
+CREATE TABLE copies (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    book_id integer NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    barcode varchar(50) NOT NULL,
+    condition varchar(20) NOT NULL DEFAULT 'GOOD',
+    status varchar(20) NOT NULL DEFAULT 'AVAILABLE'
+);
+CREATE INDEX idx_copies_deleted_at ON copies (deleted_at);
+CREATE INDEX idx_copies_book_id ON copies (book_id);
+CREATE INDEX idx_copies_status ON copies (status);
+CREATE UNIQUE INDEX uix_copies_barcode ON copies (barcode);
+
+INSERT INTO copies (created_at, updated_at, deleted_at, book_id, barcode, status)
+SELECT b.created_at, b.updated_at, b.deleted_at, b.id, COALESCE(b.call_number::text, 'BOOK-' || b.id),
+    CASE WHEN EXISTS (
+        SELECT 1 FROM loans l
+        WHERE l.book_id = b.id AND l.returned_at IS NULL AND l.deleted_at IS NULL
+    ) THEN 'ON_LOAN' ELSE 'AVAILABLE' END
+FROM books b;
+
+ALTER TABLE loans ADD COLUMN copy_id integer;
+UPDATE loans SET copy_id = c.id FROM copies c WHERE c.book_id = loans.book_id;
+CREATE INDEX idx_loans_copy_id ON loans (copy_id);
+
+ALTER TABLE holds ADD COLUMN copy_id integer;
+UPDATE holds SET copy_id = c.id FROM copies c
+WHERE c.book_id = holds.book_id AND holds.status IN ('READY', 'FULFILLED');
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS transfers;
+DROP INDEX IF EXISTS idx_holds_pickup_branch_id;
+ALTER TABLE holds DROP COLUMN IF EXISTS pickup_branch_id;
+ALTER TABLE people DROP COLUMN IF EXISTS pickup_branch_id;
+UPDATE copies SET status = 'AVAILABLE' WHERE status = 'IN_TRANSIT';
+ALTER TABLE copies DROP COLUMN IF EXISTS location_id;
+ALTER TABLE copies DROP COLUMN IF EXISTS home_branch_id;
+DROP TABLE IF EXISTS branches;
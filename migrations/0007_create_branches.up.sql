@@ -0,0 +1,53 @@
+-- Library branches. Every copy belongs to its home branch and is located
+-- at one, transfers move copies between branches. Existing copies belong
+-- to and are located at the MAIN branch.
+
+CREATE TABLE branches (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    code varchar(20) NOT NULL,
+    name varchar(200) NOT NULL,
+    address text NOT NULL DEFAULT ''
+);
+CREATE INDEX idx_branches_deleted_at ON branches (deleted_at);
+CREATE UNIQUE INDEX uix_branches_code ON branches (code);
+
+INSERT INTO branches (created_at, updated_at, code, name)
+VALUES (now(), now(), 'MAIN', 'Main library');
+
+ALTER TABLE copies ADD COLUMN home_branch_id integer REFERENCES branches (id);
+ALTER TABLE copies ADD COLUMN location_id integer REFERENCES branches (id);
+UPDATE copies SET home_branch_id = b.id, location_id = b.id FROM branches b WHERE b.code = 'MAIN';
+ALTER TABLE copies ALTER COLUMN home_branch_id SET NOT NULL;
+ALTER TABLE copies ALTER COLUMN location_id SET NOT NULL;
+CREATE INDEX idx_copies_home_branch_id ON copies (home_branch_id);
+CREATE INDEX idx_copies_location_id ON copies (location_id);
+
+ALTER TABLE people ADD COLUMN pickup_branch_id integer REFERENCES branches (id);
+ALTER TABLE holds ADD COLUMN pickup_branch_id integer REFERENCES branches (id);
+CREATE INDEX idx_holds_pickup_branch_id ON holds (pickup_branch_id);
+
+CREATE TABLE transfers (
+    id serial PRIMARY KEY,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    deleted_at timestamp with time zone,
+    copy_id integer NOT NULL REFERENCES copies (id) ON DELETE CASCADE,
+    from_branch_id integer NOT NULL REFERENCES branches (id),
+    to_branch_id integer NOT NULL REFERENCES branches (id),
+    hold_id integer REFERENCES holds (id),
+    status varchar(20) NOT NULL,
+    requested_at timestamp with time zone NOT NULL,
+    shipped_at timestamp with time zone,
+    closed_at timestamp with time zone
+);
+CREATE INDEX idx_transfers_deleted_at ON transfers (deleted_at);
+CREATE INDEX idx_transfers_copy_id ON transfers (copy_id);
+CREATE INDEX idx_transfers_from_branch_id ON transfers (from_branch_id);
+CREATE INDEX idx_transfers_to_branch_id ON transfers (to_branch_id);
+CREATE INDEX idx_transfers_status ON transfers (status);
+-- a copy is transferred to one branch at a time
+CREATE UNIQUE INDEX uix_transfers_open_copy_id ON transfers (copy_id)
+WHERE status IN ('REQUESTED', 'IN_TRANSIT');
@@ -0,0 +1,15 @@
+-- Refresh tokens of users, see models/user.RefreshToken.
+
+CREATE TABLE refresh_tokens (
+    id bigserial PRIMARY KEY,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    family text NOT NULL,
+    hash text NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    rotated_at timestamp with time zone,
+    revoked_at timestamp with time zone
+);
+CREATE UNIQUE INDEX refresh_tokens_hash_key ON refresh_tokens (hash);
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
@@ -0,0 +1,4 @@
+-- Roles of users, see models/user.Role. Existing users become members,
+-- the first admin is set with the `user role` command.
+
+ALTER TABLE users ADD COLUMN role varchar(20) NOT NULL DEFAULT 'MEMBER';
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations of the database schema.
+// New migrations are created with the `migrate create` command.
+package migrations
+
+import "embed"
+
+// FS holds the migration files.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,61 @@
+package author
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "author"
+
+// Author is a database model of a person credited on books.
+type Author struct {
+	gorm.Model
+	Name string
+}
+
+// Sanitize will sanitize author
+func (a *Author) Sanitize() {
+	a.Name = strings.Join(strings.Fields(a.Name), " ")
+}
+
+// Validate validates struct content.
+func (a Author) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("author is not valid")
+	if len(a.Name) == 0 || len(a.Name) > 200 {
+		errSet.Detail = fmt.Sprintf("name out of range 1-200 characters: '%s'", a.Name)
+		return errSet.WithMeta("field", "name")
+	}
+	return nil
+}
+
+// Sortable maps fields authors can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// SortValues returns values of the sort fields.
+func (a Author) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, a.ID)
+		case "name":
+			values = append(values, a.Name)
+		case "created_at":
+			values = append(values, a.CreatedAt)
+		case "updated_at":
+			values = append(values, a.UpdatedAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,49 @@
+package author
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorValidate(t *testing.T) {
+	a := Author{Name: "  Richard \t Templar "}
+	a.Sanitize()
+	assert.Equal(t, "Richard Templar", a.Name)
+	assert.Nil(t, a.Validate())
+
+	a.Name = ""
+	errSet := a.Validate()
+	require.NotNil(t, errSet)
+	assert.Equal(t, "name", errSet.Meta["field"])
+}
+
+func TestCredits(t *testing.T) {
+	var credits []Credit
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"AuthorID": 3, "Role": "translator"},
+		{"AuthorID": 1},
+		{"AuthorID": 2, "Role": "AUTHOR"}
+	]`), &credits))
+	Order(7, credits)
+	assert.Equal(t, []Credit{
+		{BookID: 7, AuthorID: 3, Role: Translator, Position: 1},
+		{BookID: 7, AuthorID: 1, Role: Writer, Position: 2},
+		{BookID: 7, AuthorID: 2, Role: Writer, Position: 3},
+	}, credits)
+	assert.Nil(t, ValidateCredits(credits))
+
+	credits[0].Name, credits[1].Name, credits[2].Name = "Jan Zábrana", "Terry Pratchett", "Neil Gaiman"
+	assert.Equal(t, "Terry Pratchett, Neil Gaiman", Byline(credits))
+	assert.Equal(t, "Jan Zábrana", Byline(credits[:1]), "no author credit")
+
+	credits[0] = Credit{AuthorID: 1}
+	assert.NotNil(t, ValidateCredits(credits), "the same role twice")
+	credits[0].Role = Editor
+	assert.Nil(t, ValidateCredits(credits), "different roles")
+
+	var role Role
+	assert.Error(t, json.Unmarshal([]byte(`"illustrator"`), &role))
+}
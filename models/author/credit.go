@@ -0,0 +1,67 @@
+package author
+
+import (
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Credit links the author to the book in the role. Credits of a book
+// are ordered by Position starting at 1, so the first author is the
+// author credit with the lowest position. The author can have several
+// roles on the same book, but every role only once.
+type Credit struct {
+	BookID   uint
+	AuthorID uint
+	Role     Role
+	Position int
+	// Name of the author, it is read with the credit and not stored.
+	Name string `gorm:"-"`
+}
+
+// TableName sets the table of the book and author relation.
+func (Credit) TableName() string {
+	return "book_authors"
+}
+
+// Order sets positions of the credits of the book by their order.
+func Order(bookID uint, credits []Credit) {
+	for i := range credits {
+		credits[i].BookID = bookID
+		credits[i].Position = i + 1
+	}
+}
+
+// ValidateCredits validates credits of one book.
+func ValidateCredits(credits []Credit) *errdef.Error {
+	seen := make(map[Credit]bool, len(credits))
+	for _, c := range credits {
+		if c.AuthorID == 0 {
+			return errdef.ErrInvalidArgument("author id must be positive").WithProcess(ProcessName).WithMeta("field", "authors")
+		}
+		key := Credit{AuthorID: c.AuthorID, Role: c.Role}
+		if seen[key] {
+			return errdef.ErrInvalidArgumentf("author %d is credited as %s twice", c.AuthorID, c.Role).WithProcess(ProcessName).WithMeta("field", "authors")
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// Byline returns names of the authors in order, the way the book
+// credits them. Editors and translators are only listed if the book
+// has no author credit, e.g. anthologies credit their editors.
+func Byline(credits []Credit) string {
+	var names []string
+	for _, c := range credits {
+		if c.Role == Writer {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		for _, c := range credits {
+			names = append(names, c.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
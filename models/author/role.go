@@ -0,0 +1,100 @@
+package author
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Role is what the author did on the book.
+type Role int
+
+const (
+	// Writer wrote the book, it is the default role.
+	Writer Role = iota
+	// Editor edited the book, e.g. an anthology.
+	Editor
+	// Translator translated the book.
+	Translator
+)
+
+// String implements fmt.Stringer.
+func (r Role) String() string {
+	switch r {
+	case Writer:
+		return "author"
+	case Editor:
+		return "editor"
+	case Translator:
+		return "translator"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRole parses role from its string form.
+func ParseRole(s string) (Role, *errdef.Error) {
+	for _, role := range []Role{Writer, Editor, Translator} {
+		if strings.EqualFold(role.String(), s) {
+			return role, nil
+		}
+	}
+	return Writer, errdef.ErrInvalidArgument("invalid author role value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Writer
+
+// Value implements driver.Valuer interface.
+func (r Role) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(r.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmpr             = Writer
+	_    sql.Scanner = &tmpr
+)
+
+// Scan implements sql.Scanner interface.
+func (r *Role) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid author role type").WithProcess(ProcessName)
+	}
+	role, errSet := ParseRole(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown author role value: " + str).WithProcess(ProcessName)
+	}
+	*r = role
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Writer
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmpr
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (r *Role) UnmarshalText(text []byte) error {
+	role, errSet := ParseRole(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*r = role
+	return nil
+}
@@ -0,0 +1,138 @@
+package book
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+	"github.com/investapp/backend/pkg/valid"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "book"
+
+// Book is a database model of a book. CallNumber is unique among
+// all books, including the deleted ones, and so is ISBN if it is set.
+// ISBN is stored as ISBN-13 without hyphens. Author is the byline, it is
+// built from the credits of the authors once the book has some.
+type Book struct {
+	gorm.Model
+
+	Title      string
+	Author     string
+	CallNumber int    `gorm:"unique_index"`
+	ISBN       string `gorm:"type:varchar(13)"`
+	PersonID   int
+	// Authors are the credits of the book in order, they are
+	// stored separately and only loaded by the API.
+	Authors []author.Credit `gorm:"-"`
+}
+
+// Sanitize will sanitize book
+func (b *Book) Sanitize() {
+	b.Title = strings.TrimSpace(b.Title)
+	b.Author = strings.TrimSpace(b.Author)
+	b.ISBN = NormalizeISBN(b.ISBN)
+}
+
+// NormalizeISBN strips hyphens and spaces from the ISBN and converts
+// valid ISBN-10 to ISBN-13. Other values are returned without hyphens
+// and spaces, so they can be reported by Validate.
+func NormalizeISBN(isbn string) string {
+	isbn = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+	if !valid.ISBN10(isbn) {
+		return isbn
+	}
+	isbn = "978" + isbn[:9]
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(isbn[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return isbn + strconv.Itoa((10-sum%10)%10)
+}
+
+// Validate validates struct content.
+func (b Book) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("book is not valid")
+	switch {
+	case len(b.Title) == 0 || len(b.Title) > 250:
+		errSet.Detail = fmt.Sprintf("title out of range 1-250 characters: '%s'", b.Title)
+		return errSet.WithMeta("field", "title")
+	case len(b.Author) > 200:
+		errSet.Detail = fmt.Sprintf("author out of range 0-200 characters: '%s'", b.Author)
+		return errSet.WithMeta("field", "author")
+	case b.ISBN != "" && !valid.ISBN13(b.ISBN):
+		errSet.Detail = fmt.Sprintf("isbn is not valid ISBN-10 or ISBN-13: '%s'", b.ISBN)
+		return errSet.WithMeta("field", "isbn")
+	case b.CallNumber <= 0:
+		errSet.Detail = "call number must be positive"
+		return errSet.WithMeta("field", "call_number")
+	case b.PersonID < 0:
+		errSet.Detail = "person id must be positive"
+		return errSet.WithMeta("field", "person_id")
+	}
+	return nil
+}
+
+// Sortable maps fields books can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":          "id",
+	"title":       "title",
+	"author":      "author",
+	"call_number": "call_number",
+	"isbn":        "isbn",
+	"person_id":   "person_id",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+}
+
+// TrashSortable maps fields deleted books can be sorted by to their
+// columns. deleted_at is only sortable there, where it is never NULL.
+var TrashSortable = map[string]string{
+	"id":          "id",
+	"title":       "title",
+	"author":      "author",
+	"call_number": "call_number",
+	"isbn":        "isbn",
+	"person_id":   "person_id",
+	"created_at":  "created_at",
+	"updated_at":  "updated_at",
+	"deleted_at":  "deleted_at",
+}
+
+// SortValues returns values of the sort fields.
+func (b Book) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, b.ID)
+		case "title":
+			values = append(values, b.Title)
+		case "author":
+			values = append(values, b.Author)
+		case "call_number":
+			values = append(values, b.CallNumber)
+		case "isbn":
+			values = append(values, b.ISBN)
+		case "person_id":
+			values = append(values, b.PersonID)
+		case "created_at":
+			values = append(values, b.CreatedAt)
+		case "updated_at":
+			values = append(values, b.UpdatedAt)
+		case "deleted_at":
+			values = append(values, b.DeletedAt)
+		}
+	}
+	return values
+}
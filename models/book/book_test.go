@@ -0,0 +1,35 @@
+package book
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestISBN(t *testing.T) {
+	testCases := []struct {
+		input string
+		isbn  string
+		valid bool
+	}{
+		{input: "0-306-40615-2", isbn: "9780306406157", valid: true},
+		{input: "080442957x", isbn: "9780804429573", valid: true},
+		{input: "978-0-306-40615-7", isbn: "9780306406157", valid: true},
+		{input: "", isbn: "", valid: true},
+		{input: "978 0 306 40615 8", isbn: "9780306406158"},
+		{input: "0-306-40615-3", isbn: "0306406153"},
+	}
+	for _, tc := range testCases {
+		b := Book{Title: "Go", CallNumber: 1, ISBN: tc.input}
+		b.Sanitize()
+		assert.Equal(t, tc.isbn, b.ISBN, tc.input)
+		err := b.Validate()
+		if tc.valid {
+			assert.Nil(t, err, tc.input)
+			continue
+		}
+		require.NotNil(t, err, tc.input)
+		assert.Equal(t, "isbn", err.Meta["field"])
+	}
+}
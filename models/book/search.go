@@ -0,0 +1,168 @@
+package book
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+const (
+	// HighlightStart and HighlightStop wrap matched words in snippets.
+	// The text around them is not escaped.
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+
+	// TitleWeight and AuthorWeight are the weights of words matched in
+	// the title and in the author, the same as the default weights of
+	// the A and B labels in PostgreSQL.
+	TitleWeight  = 1.0
+	AuthorWeight = 0.4
+
+	// maxTerms limits number of words in a query.
+	maxTerms = 10
+)
+
+// Query is a parsed full-text search query. A book matches it if every
+// term is a prefix of some word of its title or author.
+type Query []string
+
+// ParseQuery splits the query into words ignoring punctuation, case
+// and accents.
+func ParseQuery(q string) (Query, *errdef.Error) {
+	terms := words(Fold(q))
+	switch {
+	case len(terms) == 0:
+		return nil, errdef.ErrInvalidArgument("search query must contain a word").WithProcess(ProcessName).WithMeta("field", "q")
+	case len(terms) > maxTerms:
+		return nil, errdef.ErrInvalidArgumentf("search query must contain at most %d words", maxTerms).WithProcess(ProcessName).WithMeta("field", "q")
+	}
+	return Query(terms), nil
+}
+
+// TSQuery returns the query in PostgreSQL tsquery syntax, every term
+// is matched as a prefix. Terms contain only letters and digits,
+// so the result is safe to pass to to_tsquery.
+func (q Query) TSQuery() string {
+	parts := make([]string, len(q))
+	for i, term := range q {
+		parts[i] = term + ":*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// Match is a book found by search. Rank tells how well the book
+// matches the query, the higher the better. Title and Author are
+// the fields of the book with matched words highlighted.
+type Match struct {
+	Book   Book
+	Rank   float64
+	Title  string
+	Author string
+}
+
+// Match matches the book against the query the same way the database
+// does. It returns false if the book doesn't match.
+func (q Query) Match(b Book) (Match, bool) {
+	title, author := words(Fold(b.Title)), words(Fold(b.Author))
+	var rank float64
+	for _, term := range q {
+		n := TitleWeight*float64(countPrefixed(title, term)) + AuthorWeight*float64(countPrefixed(author, term))
+		if n == 0 {
+			return Match{}, false
+		}
+		rank += n
+	}
+	return Match{
+		Book:   b,
+		Rank:   rank,
+		Title:  q.Highlight(b.Title),
+		Author: q.Highlight(b.Author),
+	}, true
+}
+
+// Highlight wraps words of the text which match any term
+// in HighlightStart and HighlightStop.
+func (q Query) Highlight(text string) string {
+	var sb strings.Builder
+	rest := text
+	for rest != "" {
+		i := strings.IndexFunc(rest, isWordRune)
+		if i < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		sb.WriteString(rest[:i])
+		rest = rest[i:]
+		j := strings.IndexFunc(rest, func(r rune) bool { return !isWordRune(r) })
+		if j < 0 {
+			j = len(rest)
+		}
+		word := rest[:j]
+		if q.matches(Fold(word)) {
+			sb.WriteString(HighlightStart + word + HighlightStop)
+		} else {
+			sb.WriteString(word)
+		}
+		rest = rest[j:]
+	}
+	return sb.String()
+}
+
+func (q Query) matches(word string) bool {
+	for _, term := range q {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// SortMatches orders matches by rank, the best first, and by ID.
+func SortMatches(mm []Match) {
+	sort.SliceStable(mm, func(i, j int) bool {
+		if mm[i].Rank != mm[j].Rank {
+			return mm[i].Rank > mm[j].Rank
+		}
+		return mm[i].Book.ID < mm[j].Book.ID
+	})
+}
+
+// ligatures are letters unaccent replaces which don't decompose
+// into a base letter and a combining mark.
+var ligatures = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ð", "d", "þ", "th", "ı", "i",
+)
+
+// Fold lowercases the text and strips accents from its letters.
+func Fold(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, strings.ToLower(s))
+	if err != nil {
+		folded = strings.ToLower(s)
+	}
+	return ligatures.Replace(folded)
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func countPrefixed(words []string, term string) int {
+	n := 0
+	for _, w := range words {
+		if strings.HasPrefix(w, term) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,50 @@
+package book
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery("  Němcová, BABIČKA!")
+	require.Nil(t, err)
+	assert.Equal(t, Query{"nemcova", "babicka"}, q)
+	assert.Equal(t, "nemcova:* & babicka:*", q.TSQuery())
+
+	for _, s := range []string{"", " -- ", "a b c d e f g h i j k"} {
+		_, err := ParseQuery(s)
+		require.NotNil(t, err, s)
+		assert.True(t, errdef.IsInvalidArgument(err))
+	}
+}
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "zlutoucky kun", Fold("Žluťoučký kůň"))
+	assert.Equal(t, "strasse lodz", Fold("Straße Łódź"))
+}
+
+func TestQueryMatch(t *testing.T) {
+	b := Book{Title: "Babička", Author: "Božena Němcová"}
+
+	q, _ := ParseQuery("bab nemc")
+	m, ok := q.Match(b)
+	require.True(t, ok)
+	assert.Equal(t, TitleWeight+AuthorWeight, m.Rank)
+	assert.Equal(t, "<mark>Babička</mark>", m.Title)
+	assert.Equal(t, "Božena <mark>Němcová</mark>", m.Author)
+
+	// every term has to match
+	q, _ = ParseQuery("babicka capek")
+	_, ok = q.Match(b)
+	assert.False(t, ok)
+
+	// title words rank higher than author words
+	q, _ = ParseQuery("bo")
+	byTitle, _ := q.Match(Book{Title: "Books", Author: "Anna"})
+	byAuthor, _ := q.Match(b)
+	assert.Greater(t, byTitle.Rank, byAuthor.Rank)
+}
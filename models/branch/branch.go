@@ -0,0 +1,75 @@
+// Package branch models the locations of the library and transfers of
+// copies between them.
+package branch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "branch"
+
+// Branch is a database model of a library location. Code is a short
+// unique name used on labels, e.g. MAIN.
+type Branch struct {
+	gorm.Model
+	Code    string `gorm:"type:varchar(20);unique_index"`
+	Name    string `gorm:"type:varchar(200)"`
+	Address string
+}
+
+// Sanitize will sanitize branch
+func (b *Branch) Sanitize() {
+	b.Code = strings.ToUpper(strings.TrimSpace(b.Code))
+	b.Name = strings.TrimSpace(b.Name)
+	b.Address = strings.TrimSpace(b.Address)
+}
+
+// Validate validates struct content.
+func (b Branch) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("branch is not valid")
+	switch {
+	case len(b.Code) == 0 || len(b.Code) > 20 || strings.ContainsAny(b.Code, " \t"):
+		errSet.Detail = fmt.Sprintf("code must be 1-20 characters without spaces: '%s'", b.Code)
+		return errSet.WithMeta("field", "code")
+	case len(b.Name) == 0 || len(b.Name) > 200:
+		errSet.Detail = fmt.Sprintf("name out of range 1-200 characters: '%s'", b.Name)
+		return errSet.WithMeta("field", "name")
+	}
+	return nil
+}
+
+// Sortable maps fields branches can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":         "id",
+	"code":       "code",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// SortValues returns values of the sort fields.
+func (b Branch) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, b.ID)
+		case "code":
+			values = append(values, b.Code)
+		case "name":
+			values = append(values, b.Name)
+		case "created_at":
+			values = append(values, b.CreatedAt)
+		case "updated_at":
+			values = append(values, b.UpdatedAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,57 @@
+package branch
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	b := Branch{Code: " main ", Name: " Main library "}
+	b.Sanitize()
+	require.Nil(t, b.Validate())
+	assert.Equal(t, "MAIN", b.Code)
+	assert.Equal(t, "Main library", b.Name)
+
+	b.Code = "NORTH SIDE"
+	assert.NotNil(t, b.Validate())
+	b.Code = "NORTH"
+	b.Name = ""
+	assert.NotNil(t, b.Validate())
+}
+
+func TestTransfer(t *testing.T) {
+	now := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	_, errSet := NewTransfer(1, 2, 2, nil, now)
+	assert.NotNil(t, errSet, "copy is already there")
+
+	tr, errSet := NewTransfer(1, 1, 2, nil, now)
+	require.Nil(t, errSet)
+	assert.True(t, tr.Open())
+	assert.NotNil(t, tr.Receive(now))
+	require.Nil(t, tr.Ship(now.Add(time.Hour)))
+	assert.Equal(t, InTransit, tr.Status)
+	assert.NotNil(t, tr.Cancel(now), "copy is already sent")
+	require.Nil(t, tr.Receive(now.Add(24*time.Hour)))
+	assert.False(t, tr.Open())
+	assert.Equal(t, now.Add(24*time.Hour), *tr.ClosedAt)
+
+	tr, _ = NewTransfer(1, 2, 1, nil, now)
+	require.Nil(t, tr.Cancel(now))
+	assert.Equal(t, Cancelled, tr.Status)
+	assert.NotNil(t, tr.Ship(now))
+}
+
+func TestStatusJSON(t *testing.T) {
+	data, err := json.Marshal(InTransit)
+	require.NoError(t, err)
+	assert.Equal(t, `"in_transit"`, string(data))
+
+	var s Status
+	require.NoError(t, json.Unmarshal([]byte(`"RECEIVED"`), &s))
+	assert.Equal(t, Received, s)
+	assert.Error(t, json.Unmarshal([]byte(`"lost"`), &s))
+}
@@ -0,0 +1,104 @@
+package branch
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Status is state of the transfer.
+type Status int
+
+const (
+	// Requested transfer waits for the copy to be sent.
+	Requested Status = iota
+	// InTransit transfer has the copy on its way.
+	InTransit
+	// Received transfer ended with the copy arriving.
+	Received
+	// Cancelled transfer was cancelled before the copy was sent.
+	Cancelled
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Requested:
+		return "requested"
+	case InTransit:
+		return "in_transit"
+	case Received:
+		return "received"
+	case Cancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseStatus parses status from its string form.
+func ParseStatus(s string) (Status, *errdef.Error) {
+	for _, status := range []Status{Requested, InTransit, Received, Cancelled} {
+		if strings.EqualFold(status.String(), s) {
+			return status, nil
+		}
+	}
+	return Requested, errdef.ErrInvalidArgument("invalid transfer status value: " + s).WithProcess(TransferProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Requested
+
+// Value implements driver.Valuer interface.
+func (s Status) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(s.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmps             = Requested
+	_    sql.Scanner = &tmps
+)
+
+// Scan implements sql.Scanner interface.
+func (s *Status) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid transfer status type").WithProcess(TransferProcessName)
+	}
+	status, errSet := ParseStatus(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown transfer status value: " + str).WithProcess(TransferProcessName)
+	}
+	*s = status
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Requested
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmps
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (s *Status) UnmarshalText(text []byte) error {
+	status, errSet := ParseStatus(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*s = status
+	return nil
+}
@@ -0,0 +1,98 @@
+package branch
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// TransferProcessName is the constant used to store the errdef key value.
+const TransferProcessName = "transfer"
+
+// Transfer is a database model of a copy moving between branches. It
+// is requested by staff or to bring the copy to the pickup branch of
+// a hold, then HoldID is set. A copy has at most one open transfer.
+type Transfer struct {
+	gorm.Model
+	CopyID       uint `gorm:"index"`
+	FromBranchID uint `gorm:"index"`
+	ToBranchID   uint `gorm:"index"`
+	HoldID       *uint
+	Status       Status `gorm:"type:varchar(20);index"`
+	RequestedAt  time.Time
+	ShippedAt    *time.Time
+	ClosedAt     *time.Time
+}
+
+// NewTransfer creates transfer of the copy requested now.
+func NewTransfer(copyID, from, to uint, holdID *uint, now time.Time) (Transfer, *errdef.Error) {
+	if from == to {
+		return Transfer{}, errdef.ErrInvalidArgumentf("copy %d is already at branch %d", copyID, to).WithProcess(TransferProcessName).WithMeta("field", "to_branch_id")
+	}
+	return Transfer{
+		CopyID:       copyID,
+		FromBranchID: from,
+		ToBranchID:   to,
+		HoldID:       holdID,
+		Status:       Requested,
+		RequestedAt:  now,
+	}, nil
+}
+
+// Open tells if the transfer has not ended yet.
+func (t Transfer) Open() bool {
+	return t.Status == Requested || t.Status == InTransit
+}
+
+// Ship marks the copy sent.
+func (t *Transfer) Ship(now time.Time) *errdef.Error {
+	if t.Status != Requested {
+		return errdef.ErrFailedPreconditionf("%s transfer can not be shipped", t.Status).WithProcess(TransferProcessName)
+	}
+	t.Status = InTransit
+	t.ShippedAt = &now
+	return nil
+}
+
+// Receive marks the copy arrived.
+func (t *Transfer) Receive(now time.Time) *errdef.Error {
+	if t.Status != InTransit {
+		return errdef.ErrFailedPreconditionf("%s transfer can not be received", t.Status).WithProcess(TransferProcessName)
+	}
+	t.Status = Received
+	t.ClosedAt = &now
+	return nil
+}
+
+// Cancel closes the transfer before the copy is sent.
+func (t *Transfer) Cancel(now time.Time) *errdef.Error {
+	if t.Status != Requested {
+		return errdef.ErrFailedPreconditionf("%s transfer can not be cancelled", t.Status).WithProcess(TransferProcessName)
+	}
+	t.Status = Cancelled
+	t.ClosedAt = &now
+	return nil
+}
+
+// TransferSortable maps fields transfers can be sorted by to their columns.
+var TransferSortable = map[string]string{
+	"id":           "id",
+	"requested_at": "requested_at",
+}
+
+// SortValues returns values of the sort fields.
+func (t Transfer) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, t.ID)
+		case "requested_at":
+			values = append(values, t.RequestedAt)
+		}
+	}
+	return values
+}
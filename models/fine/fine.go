@@ -0,0 +1,141 @@
+package fine
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/shopspring/decimal"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "fine"
+
+// Policy defines how overdue fines are computed.
+type Policy struct {
+	// DailyRate is charged for every started day after the due date.
+	DailyRate decimal.Decimal
+	// GracePeriod is how late the book can be returned without a fine.
+	// Once it is exceeded, every day after the due date is charged.
+	GracePeriod time.Duration
+	// MaxPerItem caps the fine of one loan, zero means no cap.
+	MaxPerItem decimal.Decimal
+	// BlockThreshold is the balance over which new checkouts are refused.
+	BlockThreshold decimal.Decimal
+}
+
+// DefaultPolicy charges 0.25 a day, at most 10.00 per item,
+// and blocks checkouts with balance over 5.00.
+var DefaultPolicy = Policy{
+	DailyRate:      decimal.New(25, -2),
+	GracePeriod:    24 * time.Hour,
+	MaxPerItem:     decimal.New(10, 0),
+	BlockThreshold: decimal.New(5, 0),
+}
+
+// Compute returns the fine for a book due at due and returned at returned.
+func (p Policy) Compute(due, returned time.Time) decimal.Decimal {
+	late := returned.Sub(due)
+	if late <= p.GracePeriod {
+		return decimal.Zero
+	}
+	days := int64(late / (24 * time.Hour))
+	if late%(24*time.Hour) != 0 {
+		days++
+	}
+	amount := p.DailyRate.Mul(decimal.NewFromInt(days))
+	if p.MaxPerItem.IsPositive() && amount.GreaterThan(p.MaxPerItem) {
+		amount = p.MaxPerItem
+	}
+	return amount.Round(2)
+}
+
+// Blocks tells you if the balance is too high to borrow more books.
+func (p Policy) Blocks(balance decimal.Decimal) bool {
+	return balance.GreaterThan(p.BlockThreshold)
+}
+
+// Entry is a database model of one record in the fines ledger of a person.
+// Amount is always positive, the Kind tells if it adds to the balance or not.
+type Entry struct {
+	gorm.Model
+	PersonID uint            `gorm:"index"`
+	LoanID   *uint           `gorm:"index"`
+	Kind     Kind            `gorm:"type:varchar(20)"`
+	Amount   decimal.Decimal `gorm:"type:numeric(12,2)"`
+	Note     string
+}
+
+// NewCharge creates overdue fine for the loan.
+func NewCharge(personID, loanID uint, amount decimal.Decimal) Entry {
+	return Entry{PersonID: personID, LoanID: &loanID, Kind: Charge, Amount: amount}
+}
+
+// Signed returns the amount as it changes the balance.
+func (e Entry) Signed() decimal.Decimal {
+	if e.Kind == Charge {
+		return e.Amount
+	}
+	return e.Amount.Neg()
+}
+
+// Validate validates struct content.
+func (e Entry) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("amount must be positive with at most 2 decimal places").WithProcess(ProcessName)
+	if !e.Amount.IsPositive() {
+		return errSet
+	}
+	if !e.Amount.Equal(e.Amount.Round(2)) {
+		return errSet
+	}
+	return nil
+}
+
+// Ledger is list of entries of a person.
+type Ledger []Entry
+
+// Balance returns how much the person owes.
+func (l Ledger) Balance() decimal.Decimal {
+	balance := decimal.Zero
+	for _, e := range l {
+		balance = balance.Add(e.Signed())
+	}
+	return balance
+}
+
+// Credit checks that the waive or payment entry can be added
+// without the balance going below zero.
+func (l Ledger) Credit(e Entry) *errdef.Error {
+	if errSet := e.Validate(); errSet != nil {
+		return errSet
+	}
+	if e.Kind == Charge {
+		return errdef.ErrInvalidArgument("charges are only created for overdue loans").WithProcess(ProcessName)
+	}
+	if e.Amount.GreaterThan(l.Balance()) {
+		return errdef.ErrFailedPreconditionf("%s of %s exceeds the balance %s", e.Kind, e.Amount.StringFixed(2), l.Balance().StringFixed(2)).WithProcess(ProcessName)
+	}
+	return nil
+}
+
+// Sortable maps fields ledger entries can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":         "id",
+	"created_at": "created_at",
+}
+
+// SortValues returns values of the sort fields.
+func (e Entry) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, e.ID)
+		case "created_at":
+			values = append(values, e.CreatedAt)
+		}
+	}
+	return values
+}
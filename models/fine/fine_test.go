@@ -0,0 +1,82 @@
+package fine
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var due = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestCompute(t *testing.T) {
+	p := Policy{
+		DailyRate:   dec("0.25"),
+		GracePeriod: 24 * time.Hour,
+		MaxPerItem:  dec("2"),
+	}
+	testCases := []struct {
+		label    string
+		returned time.Time
+		expected string
+	}{
+		{label: "early", returned: due.Add(-time.Hour), expected: "0"},
+		{label: "on time", returned: due, expected: "0"},
+		{label: "within grace", returned: due.Add(24 * time.Hour), expected: "0"},
+		{label: "after grace", returned: due.Add(25 * time.Hour), expected: "0.5"},
+		{label: "three days", returned: due.Add(72 * time.Hour), expected: "0.75"},
+		{label: "capped", returned: due.Add(30 * 24 * time.Hour), expected: "2"},
+	}
+	for _, tc := range testCases {
+		assert.True(t, dec(tc.expected).Equal(p.Compute(due, tc.returned)), tc.label)
+	}
+
+	p.MaxPerItem = decimal.Zero
+	assert.True(t, dec("7.5").Equal(p.Compute(due, due.Add(30*24*time.Hour))))
+}
+
+func TestBlocks(t *testing.T) {
+	p := Policy{BlockThreshold: dec("5")}
+	assert.False(t, p.Blocks(dec("5")))
+	assert.True(t, p.Blocks(dec("5.01")))
+}
+
+func TestLedger(t *testing.T) {
+	l := Ledger{
+		NewCharge(1, 1, dec("1.25")),
+		NewCharge(1, 2, dec("3")),
+		{PersonID: 1, Kind: Waive, Amount: dec("1")},
+		{PersonID: 1, Kind: Payment, Amount: dec("0.25")},
+	}
+	assert.Equal(t, "3.00", l.Balance().StringFixed(2))
+
+	assert.Nil(t, l.Credit(Entry{Kind: Payment, Amount: dec("3")}))
+	assert.NotNil(t, l.Credit(Entry{Kind: Payment, Amount: dec("3.01")}))
+	assert.NotNil(t, l.Credit(Entry{Kind: Waive, Amount: dec("0")}))
+	assert.NotNil(t, l.Credit(Entry{Kind: Waive, Amount: dec("0.001")}))
+	assert.NotNil(t, l.Credit(Entry{Kind: Charge, Amount: dec("1")}))
+}
+
+func TestKind(t *testing.T) {
+	data, err := json.Marshal(Waive)
+	require.NoError(t, err)
+	assert.Equal(t, `"waive"`, string(data))
+
+	var k Kind
+	require.NoError(t, json.Unmarshal([]byte(`"payment"`), &k))
+	assert.Equal(t, Payment, k)
+	assert.Error(t, json.Unmarshal([]byte(`"refund"`), &k))
+
+	v, err := Charge.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "CHARGE", v)
+	require.NoError(t, k.Scan([]byte("WAIVE")))
+	assert.Equal(t, Waive, k)
+}
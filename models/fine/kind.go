@@ -0,0 +1,100 @@
+package fine
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Kind is type of the ledger entry.
+type Kind int
+
+const (
+	// Charge is overdue fine added to the balance.
+	Charge Kind = iota
+	// Waive forgives part of the balance.
+	Waive
+	// Payment is money paid by the person.
+	Payment
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case Charge:
+		return "charge"
+	case Waive:
+		return "waive"
+	case Payment:
+		return "payment"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseKind parses kind from its string form.
+func ParseKind(s string) (Kind, *errdef.Error) {
+	for _, kind := range []Kind{Charge, Waive, Payment} {
+		if strings.EqualFold(kind.String(), s) {
+			return kind, nil
+		}
+	}
+	return Charge, errdef.ErrInvalidArgument("invalid fine kind value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Charge
+
+// Value implements driver.Valuer interface.
+func (k Kind) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(k.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmpk             = Charge
+	_    sql.Scanner = &tmpk
+)
+
+// Scan implements sql.Scanner interface.
+func (k *Kind) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid fine kind type").WithProcess(ProcessName)
+	}
+	kind, errSet := ParseKind(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown fine kind value: " + str).WithProcess(ProcessName)
+	}
+	*k = kind
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Charge
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmpk
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (k *Kind) UnmarshalText(text []byte) error {
+	kind, errSet := ParseKind(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*k = kind
+	return nil
+}
@@ -0,0 +1,212 @@
+package hold
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "hold"
+
+// Policy defines how long a book is kept for pickup once the hold is ready.
+type Policy struct {
+	PickupWindow time.Duration
+}
+
+// DefaultPolicy keeps the book for a week.
+var DefaultPolicy = Policy{
+	PickupWindow: 7 * 24 * time.Hour,
+}
+
+// Hold is a database model of a person waiting for a book.
+// Holds for the same book are served first come first served by PlacedAt,
+// any copy of the book can serve the hold. CopyID is the copy set aside
+// once the hold is ready. A waiting hold has CopyID set while the copy
+// is brought to PickupBranchID, the branch the person picks it up at.
+type Hold struct {
+	gorm.Model
+	PersonID       uint `gorm:"index"`
+	BookID         uint `gorm:"index"`
+	CopyID         *uint
+	PickupBranchID *uint  `gorm:"index"`
+	Status         Status `gorm:"type:varchar(20);index"`
+	PlacedAt       time.Time
+	ReadyAt        *time.Time
+	ExpiresAt      *time.Time
+	ClosedAt       *time.Time
+}
+
+// New creates waiting hold placed now.
+func New(personID, bookID uint, now time.Time) Hold {
+	return Hold{
+		PersonID: personID,
+		BookID:   bookID,
+		Status:   Waiting,
+		PlacedAt: now,
+	}
+}
+
+// Open tells you if the hold is still in the queue.
+func (h Hold) Open() bool {
+	return h.Status == Waiting || h.Status == Ready
+}
+
+// Stale tells you if the ready hold was not picked up in time.
+func (h Hold) Stale(now time.Time) bool {
+	return h.Status == Ready && h.ExpiresAt != nil && now.After(*h.ExpiresAt)
+}
+
+// MakeReady sets the copy aside for the person until the pickup window ends.
+func (h *Hold) MakeReady(policy Policy, copyID uint, now time.Time) *errdef.Error {
+	if h.Status != Waiting {
+		return errdef.ErrFailedPreconditionf("%s hold can not become ready", h.Status).WithProcess(ProcessName)
+	}
+	expires := now.Add(policy.PickupWindow)
+	h.Status = Ready
+	h.CopyID = &copyID
+	h.ReadyAt = &now
+	h.ExpiresAt = &expires
+	return nil
+}
+
+// Assign sets the copy aside for the waiting hold while it is brought
+// to the pickup branch, the hold becomes ready when the copy arrives.
+func (h *Hold) Assign(copyID uint) *errdef.Error {
+	if h.Status != Waiting || h.CopyID != nil {
+		return errdef.ErrFailedPreconditionf("%s hold can not be assigned a copy", h.Status).WithProcess(ProcessName)
+	}
+	h.CopyID = &copyID
+	return nil
+}
+
+// Requeue puts the hold back to the queue when its copy can't be lent
+// anymore, e.g. it was lost. The hold keeps its place.
+func (h *Hold) Requeue() *errdef.Error {
+	if h.CopyID == nil || !h.Open() {
+		return errdef.ErrFailedPreconditionf("%s hold can not be requeued", h.Status).WithProcess(ProcessName)
+	}
+	h.Status = Waiting
+	h.CopyID = nil
+	h.ReadyAt = nil
+	h.ExpiresAt = nil
+	return nil
+}
+
+// Fulfill closes the hold when the person checks the book out.
+func (h *Hold) Fulfill(now time.Time) *errdef.Error {
+	return h.close(Fulfilled, now)
+}
+
+// Cancel closes the hold on request.
+func (h *Hold) Cancel(now time.Time) *errdef.Error {
+	return h.close(Cancelled, now)
+}
+
+// Expire closes the hold which was not picked up.
+func (h *Hold) Expire(now time.Time) *errdef.Error {
+	if h.Status != Ready {
+		return errdef.ErrFailedPreconditionf("%s hold can not expire", h.Status).WithProcess(ProcessName)
+	}
+	return h.close(Expired, now)
+}
+
+func (h *Hold) close(status Status, now time.Time) *errdef.Error {
+	if !h.Open() {
+		return errdef.ErrFailedPreconditionf("hold is already %s", h.Status).WithProcess(ProcessName)
+	}
+	h.Status = status
+	h.ClosedAt = &now
+	return nil
+}
+
+// Holds is list of holds
+type Holds []Hold
+
+// Len is part of sort.Interface.
+func (hh Holds) Len() int {
+	return len(hh)
+}
+
+// Swap is part of sort.Interface.
+func (hh Holds) Swap(i, j int) {
+	hh[i], hh[j] = hh[j], hh[i]
+}
+
+// Less is part of sort.Interface, holds are ordered as they were placed.
+func (hh Holds) Less(i, j int) bool {
+	if hh[i].PlacedAt.Equal(hh[j].PlacedAt) {
+		return hh[i].ID < hh[j].ID
+	}
+	return hh[i].PlacedAt.Before(hh[j].PlacedAt)
+}
+
+// Ready returns the hold the book is set aside for.
+func (hh Holds) Ready() (hold Hold, found bool) {
+	for _, h := range hh {
+		if h.Status == Ready {
+			return h, true
+		}
+	}
+	return
+}
+
+// ByCopyID returns the open hold the copy is set aside for.
+func (hh Holds) ByCopyID(copyID uint) (hold Hold, found bool) {
+	for _, h := range hh {
+		if h.Open() && h.CopyID != nil && *h.CopyID == copyID {
+			return h, true
+		}
+	}
+	return
+}
+
+// Next returns the first waiting hold in the queue which has no copy
+// assigned yet.
+func (hh Holds) Next() (hold Hold, found bool) {
+	waiting := Holds{}
+	for _, h := range hh {
+		if h.Status == Waiting && h.CopyID == nil {
+			waiting = append(waiting, h)
+		}
+	}
+	if len(waiting) == 0 {
+		return
+	}
+	sort.Sort(waiting)
+	return waiting[0], true
+}
+
+// GetByPersonID returns open hold of the person.
+func (hh Holds) GetByPersonID(personID uint) (hold Hold, found bool) {
+	for _, h := range hh {
+		if h.PersonID == personID && h.Open() {
+			return h, true
+		}
+	}
+	return
+}
+
+// Sortable maps fields holds can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":        "id",
+	"placed_at": "placed_at",
+}
+
+// SortValues returns values of the sort fields.
+func (h Hold) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, h.ID)
+		case "placed_at":
+			values = append(values, h.PlacedAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,131 @@
+package hold
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestLifecycle(t *testing.T) {
+	h := New(1, 2, now)
+	assert.True(t, h.Open())
+	assert.False(t, h.Stale(now))
+	assert.NotNil(t, h.Expire(now))
+
+	require.Nil(t, h.MakeReady(DefaultPolicy, 5, now))
+	assert.Equal(t, Ready, h.Status)
+	assert.Equal(t, uint(5), *h.CopyID)
+	assert.Equal(t, now.Add(DefaultPolicy.PickupWindow), *h.ExpiresAt)
+	assert.False(t, h.Stale(now))
+	assert.True(t, h.Stale(now.Add(DefaultPolicy.PickupWindow+time.Second)))
+	assert.NotNil(t, h.MakeReady(DefaultPolicy, 5, now))
+
+	require.Nil(t, h.Fulfill(now))
+	assert.False(t, h.Open())
+	assert.NotNil(t, h.Cancel(now))
+}
+
+func TestExpire(t *testing.T) {
+	h := New(1, 2, now)
+	require.Nil(t, h.MakeReady(DefaultPolicy, 5, now))
+	require.Nil(t, h.Expire(now))
+	assert.Equal(t, Expired, h.Status)
+	assert.Equal(t, now, *h.ClosedAt)
+}
+
+func TestRequeue(t *testing.T) {
+	h := New(1, 2, now)
+	assert.NotNil(t, h.Requeue())
+	require.Nil(t, h.MakeReady(DefaultPolicy, 5, now))
+	require.Nil(t, h.Requeue())
+	assert.Equal(t, Waiting, h.Status)
+	assert.Nil(t, h.CopyID)
+	assert.Nil(t, h.ExpiresAt)
+	assert.False(t, h.Stale(now.Add(DefaultPolicy.PickupWindow+time.Second)))
+}
+
+func TestAssign(t *testing.T) {
+	first := New(1, 2, now)
+	first.ID = 1
+	second := New(2, 2, now.Add(time.Minute))
+	second.ID = 2
+	hh := Holds{first, second}
+
+	require.Nil(t, hh[0].Assign(5))
+	assert.NotNil(t, hh[0].Assign(6), "copy is already assigned")
+	assert.Equal(t, Waiting, hh[0].Status)
+	h, ok := hh.ByCopyID(5)
+	require.True(t, ok)
+	assert.Equal(t, uint(1), h.ID)
+	next, ok := hh.Next()
+	require.True(t, ok)
+	assert.Equal(t, uint(2), next.ID, "holds with a copy on its way are skipped")
+
+	require.Nil(t, hh[0].MakeReady(DefaultPolicy, 5, now))
+	assert.NotNil(t, hh[0].Assign(6))
+	require.Nil(t, hh[1].Assign(7))
+	require.Nil(t, hh[1].Requeue())
+	assert.Nil(t, hh[1].CopyID)
+}
+
+func TestHoldsQueue(t *testing.T) {
+	first := New(1, 9, now)
+	first.ID = 2
+	second := New(2, 9, now)
+	second.ID = 3
+	third := New(3, 9, now.Add(time.Minute))
+	third.ID = 1
+	hh := Holds{third, second, first}
+
+	next, ok := hh.Next()
+	require.True(t, ok)
+	assert.Equal(t, uint(1), next.PersonID)
+
+	_, ok = hh.Ready()
+	assert.False(t, ok)
+
+	require.Nil(t, hh[2].MakeReady(DefaultPolicy, 5, now))
+	ready, ok := hh.Ready()
+	require.True(t, ok)
+	assert.Equal(t, uint(1), ready.PersonID)
+	ready, ok = hh.ByCopyID(5)
+	require.True(t, ok)
+	assert.Equal(t, uint(1), ready.PersonID)
+	_, ok = hh.ByCopyID(6)
+	assert.False(t, ok)
+
+	next, ok = hh.Next()
+	require.True(t, ok)
+	assert.Equal(t, uint(2), next.PersonID)
+
+	h, ok := hh.GetByPersonID(3)
+	require.True(t, ok)
+	assert.Equal(t, uint(1), h.ID)
+	_, ok = hh.GetByPersonID(4)
+	assert.False(t, ok)
+}
+
+func TestStatus(t *testing.T) {
+	data, err := json.Marshal(Ready)
+	require.NoError(t, err)
+	assert.Equal(t, `"ready"`, string(data))
+
+	var s Status
+	require.NoError(t, json.Unmarshal([]byte(`"expired"`), &s))
+	assert.Equal(t, Expired, s)
+	assert.Error(t, json.Unmarshal([]byte(`"lost"`), &s))
+
+	v, err := Fulfilled.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "FULFILLED", v)
+	require.NoError(t, s.Scan([]byte("CANCELLED")))
+	assert.Equal(t, Cancelled, s)
+	require.NoError(t, s.Scan("WAITING"))
+	assert.Equal(t, Waiting, s)
+	assert.Error(t, s.Scan(1))
+}
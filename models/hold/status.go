@@ -0,0 +1,108 @@
+package hold
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Status is state of the hold in the queue.
+type Status int
+
+const (
+	// Waiting hold is in the queue for the book to come back.
+	Waiting Status = iota
+	// Ready hold has the book set aside for pickup.
+	Ready
+	// Fulfilled hold ended with the book being checked out.
+	Fulfilled
+	// Cancelled hold was cancelled by the patron or staff.
+	Cancelled
+	// Expired hold was not picked up in time.
+	Expired
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Waiting:
+		return "waiting"
+	case Ready:
+		return "ready"
+	case Fulfilled:
+		return "fulfilled"
+	case Cancelled:
+		return "cancelled"
+	case Expired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseStatus parses status from its string form.
+func ParseStatus(s string) (Status, *errdef.Error) {
+	for _, status := range []Status{Waiting, Ready, Fulfilled, Cancelled, Expired} {
+		if strings.EqualFold(status.String(), s) {
+			return status, nil
+		}
+	}
+	return Waiting, errdef.ErrInvalidArgument("invalid hold status value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Waiting
+
+// Value implements driver.Valuer interface.
+func (s Status) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(s.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmps             = Waiting
+	_    sql.Scanner = &tmps
+)
+
+// Scan implements sql.Scanner interface.
+func (s *Status) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid hold status type").WithProcess(ProcessName)
+	}
+	status, errSet := ParseStatus(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown hold status value: " + str).WithProcess(ProcessName)
+	}
+	*s = status
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Waiting
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmps
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (s *Status) UnmarshalText(text []byte) error {
+	status, errSet := ParseStatus(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*s = status
+	return nil
+}
@@ -0,0 +1,104 @@
+package item
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Condition is physical state of the copy.
+type Condition int
+
+const (
+	// Good copy shows little wear, it is the default.
+	Good Condition = iota
+	// Fair copy is worn but complete.
+	Fair
+	// Poor copy is heavily worn and should be replaced.
+	Poor
+	// Damaged copy has missing or torn pages.
+	Damaged
+)
+
+// String implements fmt.Stringer.
+func (c Condition) String() string {
+	switch c {
+	case Good:
+		return "good"
+	case Fair:
+		return "fair"
+	case Poor:
+		return "poor"
+	case Damaged:
+		return "damaged"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseCondition parses condition from its string form.
+func ParseCondition(s string) (Condition, *errdef.Error) {
+	for _, condition := range []Condition{Good, Fair, Poor, Damaged} {
+		if strings.EqualFold(condition.String(), s) {
+			return condition, nil
+		}
+	}
+	return Good, errdef.ErrInvalidArgument("invalid copy condition value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Good
+
+// Value implements driver.Valuer interface.
+func (c Condition) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(c.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmpc             = Good
+	_    sql.Scanner = &tmpc
+)
+
+// Scan implements sql.Scanner interface.
+func (c *Condition) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid copy condition type").WithProcess(ProcessName)
+	}
+	condition, errSet := ParseCondition(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown copy condition value: " + str).WithProcess(ProcessName)
+	}
+	*c = condition
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Good
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (c Condition) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmpc
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (c *Condition) UnmarshalText(text []byte) error {
+	condition, errSet := ParseCondition(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*c = condition
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package item models the physical copies of books. A book is a title,
+// the library can have any number of copies of it and every copy is
+// lent on its own.
+package item
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "copy"
+
+// Copy is a database model of a physical copy of a book. Barcode is
+// unique among all copies, including the deleted ones. Status of the
+// copy is changed by its loans and transfers, staff can only set the
+// other statuses. The copy belongs to its home branch and LocationID
+// is the branch where it is now, or where it is heading while in transit.
+type Copy struct {
+	gorm.Model
+	BookID       uint      `gorm:"index"`
+	Barcode      string    `gorm:"type:varchar(50);unique_index"`
+	Condition    Condition `gorm:"type:varchar(20)"`
+	Status       Status    `gorm:"type:varchar(20);index"`
+	HomeBranchID uint      `gorm:"index"`
+	LocationID   uint      `gorm:"index"`
+}
+
+// Sanitize will sanitize copy
+func (c *Copy) Sanitize() {
+	c.Barcode = strings.TrimSpace(c.Barcode)
+}
+
+// Validate validates struct content.
+func (c Copy) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("copy is not valid")
+	switch {
+	case len(c.Barcode) == 0 || len(c.Barcode) > 50:
+		errSet.Detail = fmt.Sprintf("barcode out of range 1-50 characters: '%s'", c.Barcode)
+		return errSet.WithMeta("field", "barcode")
+	case c.BookID == 0:
+		errSet.Detail = "book id must be positive"
+		return errSet.WithMeta("field", "book_id")
+	case c.HomeBranchID == 0:
+		errSet.Detail = "home branch id must be positive"
+		return errSet.WithMeta("field", "home_branch_id")
+	}
+	return nil
+}
+
+// ChangeStatus validates status change made by staff. Copies go on
+// and off loan only by being checked out and returned, in and out of
+// transit by being shipped and received.
+func ChangeStatus(from, to Status) *errdef.Error {
+	switch {
+	case from == to:
+		return nil
+	case from == OnLoan:
+		return errdef.ErrFailedPrecondition("copy is on loan, return it first").WithProcess(ProcessName).WithMeta("field", "status")
+	case from == InTransit:
+		return errdef.ErrFailedPrecondition("copy is in transit, receive it first").WithProcess(ProcessName).WithMeta("field", "status")
+	case to == OnLoan:
+		return errdef.ErrInvalidArgument("copy is put on loan by checkout").WithProcess(ProcessName).WithMeta("field", "status")
+	case to == InTransit:
+		return errdef.ErrInvalidArgument("copy is put in transit by shipping a transfer").WithProcess(ProcessName).WithMeta("field", "status")
+	}
+	return nil
+}
+
+// Lend marks the copy checked out.
+func (c *Copy) Lend() *errdef.Error {
+	if c.Status != Available {
+		return errdef.ErrFailedPreconditionf("copy %d is %s", c.ID, c.Status).WithProcess(ProcessName)
+	}
+	c.Status = OnLoan
+	return nil
+}
+
+// GiveBack marks the copy returned.
+func (c *Copy) GiveBack() *errdef.Error {
+	if c.Status != OnLoan {
+		return errdef.ErrFailedPreconditionf("copy %d is not on loan", c.ID).WithProcess(ProcessName)
+	}
+	c.Status = Available
+	return nil
+}
+
+// Ship marks the copy sent to the branch.
+func (c *Copy) Ship(to uint) *errdef.Error {
+	if c.Status != Available {
+		return errdef.ErrFailedPreconditionf("copy %d is %s", c.ID, c.Status).WithProcess(ProcessName)
+	}
+	c.Status = InTransit
+	c.LocationID = to
+	return nil
+}
+
+// Receive marks the copy arrived at its location.
+func (c *Copy) Receive() *errdef.Error {
+	if c.Status != InTransit {
+		return errdef.ErrFailedPreconditionf("copy %d is not in transit", c.ID).WithProcess(ProcessName)
+	}
+	c.Status = Available
+	return nil
+}
+
+// Availability sums up copies of the book. Available copies can be
+// checked out right away, reserved ones are set aside for ready holds.
+type Availability struct {
+	BookID    uint
+	Copies    int
+	Available int
+	Reserved  int
+	OnLoan    int
+	Lost      int
+	Repair    int
+	InTransit int
+	// Waiting is number of holds waiting for a copy.
+	Waiting int
+}
+
+// Count computes availability of the book from its copies, the reserved
+// ones and the number of waiting holds.
+func Count(bookID uint, copies []Copy, reserved map[uint]bool, waiting int) Availability {
+	a := Availability{BookID: bookID, Copies: len(copies), Waiting: waiting}
+	for _, c := range copies {
+		switch {
+		case c.Status == Available && reserved[c.ID]:
+			a.Reserved++
+		case c.Status == Available:
+			a.Available++
+		case c.Status == OnLoan:
+			a.OnLoan++
+		case c.Status == Lost:
+			a.Lost++
+		case c.Status == Repair:
+			a.Repair++
+		case c.Status == InTransit:
+			a.InTransit++
+		}
+	}
+	return a
+}
+
+// Sortable maps fields copies can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":         "id",
+	"barcode":    "barcode",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// SortValues returns values of the sort fields.
+func (c Copy) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, c.ID)
+		case "barcode":
+			values = append(values, c.Barcode)
+		case "created_at":
+			values = append(values, c.CreatedAt)
+		case "updated_at":
+			values = append(values, c.UpdatedAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package item
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLendAndGiveBack(t *testing.T) {
+	c := Copy{BookID: 1, Barcode: " 0001 ", HomeBranchID: 1}
+	c.Sanitize()
+	require.Nil(t, c.Validate())
+	assert.Equal(t, "0001", c.Barcode)
+
+	assert.NotNil(t, c.GiveBack())
+	require.Nil(t, c.Lend())
+	assert.Equal(t, OnLoan, c.Status)
+	assert.NotNil(t, c.Lend())
+	require.Nil(t, c.GiveBack())
+	assert.Equal(t, Available, c.Status)
+
+	c.Status = Repair
+	assert.NotNil(t, c.Lend())
+
+	c.HomeBranchID = 0
+	assert.NotNil(t, c.Validate())
+}
+
+func TestShipAndReceive(t *testing.T) {
+	c := Copy{BookID: 1, Barcode: "0001", HomeBranchID: 1, LocationID: 1}
+	assert.NotNil(t, c.Receive())
+	require.Nil(t, c.Ship(2))
+	assert.Equal(t, InTransit, c.Status)
+	assert.Equal(t, uint(2), c.LocationID)
+	assert.NotNil(t, c.Lend())
+	assert.NotNil(t, c.Ship(3))
+	require.Nil(t, c.Receive())
+	assert.Equal(t, Available, c.Status)
+
+	c.Status = OnLoan
+	assert.NotNil(t, c.Ship(1))
+}
+
+func TestChangeStatus(t *testing.T) {
+	assert.Nil(t, ChangeStatus(Available, Lost))
+	assert.Nil(t, ChangeStatus(Repair, Available))
+	assert.Nil(t, ChangeStatus(OnLoan, OnLoan))
+	assert.NotNil(t, ChangeStatus(OnLoan, Lost))
+	assert.NotNil(t, ChangeStatus(Available, OnLoan))
+	assert.NotNil(t, ChangeStatus(InTransit, Available))
+	assert.NotNil(t, ChangeStatus(Available, InTransit))
+}
+
+func TestCount(t *testing.T) {
+	copies := []Copy{
+		{Status: Available},
+		{Status: Available},
+		{Status: OnLoan},
+		{Status: Lost},
+		{Status: Repair},
+		{Status: InTransit},
+	}
+	for i := range copies {
+		copies[i].ID = uint(i + 1)
+	}
+	a := Count(7, copies, map[uint]bool{2: true}, 3)
+	assert.Equal(t, Availability{BookID: 7, Copies: 6, Available: 1, Reserved: 1, OnLoan: 1, Lost: 1, Repair: 1, InTransit: 1, Waiting: 3}, a)
+}
+
+func TestStatusJSON(t *testing.T) {
+	var c Copy
+	require.NoError(t, json.Unmarshal([]byte(`{"Status":"ON_LOAN","Condition":"Fair"}`), &c))
+	assert.Equal(t, OnLoan, c.Status)
+	assert.Equal(t, Fair, c.Condition)
+	assert.Error(t, json.Unmarshal([]byte(`{"Condition":"mint"}`), &c))
+}
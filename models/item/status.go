@@ -0,0 +1,108 @@
+package item
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Status tells if the copy can be lent.
+type Status int
+
+const (
+	// Available copy is on the shelf or set aside for a hold.
+	Available Status = iota
+	// OnLoan copy is checked out.
+	OnLoan
+	// Lost copy is missing.
+	Lost
+	// Repair copy is being repaired.
+	Repair
+	// InTransit copy is on its way to another branch.
+	InTransit
+)
+
+// String implements fmt.Stringer.
+func (s Status) String() string {
+	switch s {
+	case Available:
+		return "available"
+	case OnLoan:
+		return "on_loan"
+	case Lost:
+		return "lost"
+	case Repair:
+		return "repair"
+	case InTransit:
+		return "in_transit"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseStatus parses status from its string form.
+func ParseStatus(s string) (Status, *errdef.Error) {
+	for _, status := range []Status{Available, OnLoan, Lost, Repair, InTransit} {
+		if strings.EqualFold(status.String(), s) {
+			return status, nil
+		}
+	}
+	return Available, errdef.ErrInvalidArgument("invalid copy status value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Available
+
+// Value implements driver.Valuer interface.
+func (s Status) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(s.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmps             = Available
+	_    sql.Scanner = &tmps
+)
+
+// Scan implements sql.Scanner interface.
+func (s *Status) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid copy status type").WithProcess(ProcessName)
+	}
+	status, errSet := ParseStatus(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown copy status value: " + str).WithProcess(ProcessName)
+	}
+	*s = status
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Available
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmps
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (s *Status) UnmarshalText(text []byte) error {
+	status, errSet := ParseStatus(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*s = status
+	return nil
+}
@@ -0,0 +1,126 @@
+package loan
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "loan"
+
+// Policy defines how long a book can be borrowed and how many times
+// the loan can be renewed.
+type Policy struct {
+	Period      time.Duration
+	MaxRenewals int
+}
+
+// DefaultPolicy lends a book for three weeks with two renewals.
+var DefaultPolicy = Policy{
+	Period:      21 * 24 * time.Hour,
+	MaxRenewals: 2,
+}
+
+// Loan is a database model of a copy of a book lent to a person.
+// The loan is active until ReturnedAt is set.
+type Loan struct {
+	gorm.Model
+	PersonID     uint `gorm:"index"`
+	BookID       uint `gorm:"index"`
+	CopyID       uint `gorm:"index"`
+	CheckedOutAt time.Time
+	DueAt        time.Time
+	ReturnedAt   *time.Time
+	Renewals     int
+}
+
+// New creates loan of the copy of the book checked out now.
+func New(personID, bookID, copyID uint, policy Policy, now time.Time) Loan {
+	return Loan{
+		PersonID:     personID,
+		BookID:       bookID,
+		CopyID:       copyID,
+		CheckedOutAt: now,
+		DueAt:        now.Add(policy.Period),
+	}
+}
+
+// Active tells you if the book was not returned yet.
+func (l Loan) Active() bool {
+	return l.ReturnedAt == nil
+}
+
+// Overdue tells you if the book is still out after its due date.
+func (l Loan) Overdue(now time.Time) bool {
+	return l.Active() && now.After(l.DueAt)
+}
+
+// Renew extends the due date by the policy period counted from now.
+// The due date never moves backwards. Overdue loans can't be renewed,
+// the fine is computed from the due date once the book is returned.
+func (l *Loan) Renew(policy Policy, now time.Time) *errdef.Error {
+	if !l.Active() {
+		return errdef.ErrFailedPrecondition("loan is already returned").WithProcess(ProcessName)
+	}
+	if l.Overdue(now) {
+		return errdef.ErrFailedPrecondition("overdue loan can not be renewed").WithProcess(ProcessName)
+	}
+	if l.Renewals >= policy.MaxRenewals {
+		return errdef.ErrFailedPreconditionf("loan can be renewed at most %d times", policy.MaxRenewals).WithProcess(ProcessName)
+	}
+	due := now.Add(policy.Period)
+	if due.After(l.DueAt) {
+		l.DueAt = due
+	}
+	l.Renewals++
+	return nil
+}
+
+// Return marks the loan as returned.
+func (l *Loan) Return(now time.Time) *errdef.Error {
+	if !l.Active() {
+		return errdef.ErrFailedPrecondition("loan is already returned").WithProcess(ProcessName)
+	}
+	l.ReturnedAt = &now
+	return nil
+}
+
+// Loans is list of loans
+type Loans []Loan
+
+// Active returns loans that were not returned yet.
+func (ll Loans) Active() (results Loans) {
+	for _, l := range ll {
+		if l.Active() {
+			results = append(results, l)
+		}
+	}
+	return
+}
+
+// Sortable maps fields loans can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":             "id",
+	"checked_out_at": "checked_out_at",
+	"due_at":         "due_at",
+}
+
+// SortValues returns values of the sort fields.
+func (l Loan) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, l.ID)
+		case "checked_out_at":
+			values = append(values, l.CheckedOutAt)
+		case "due_at":
+			values = append(values, l.DueAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,75 @@
+package loan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+var now = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+func TestNew(t *testing.T) {
+	l := New(1, 2, 3, DefaultPolicy, now)
+	assert.Equal(t, uint(1), l.PersonID)
+	assert.Equal(t, uint(2), l.BookID)
+	assert.Equal(t, uint(3), l.CopyID)
+	assert.Equal(t, now.Add(DefaultPolicy.Period), l.DueAt)
+	assert.True(t, l.Active())
+	assert.False(t, l.Overdue(now))
+	assert.True(t, l.Overdue(l.DueAt.Add(time.Second)))
+}
+
+func TestRenew(t *testing.T) {
+	policy := Policy{Period: 24 * time.Hour, MaxRenewals: 1}
+	l := New(1, 2, 2, policy, now)
+
+	later := now.Add(12 * time.Hour)
+	require.Nil(t, l.Renew(policy, later))
+	assert.Equal(t, later.Add(policy.Period), l.DueAt)
+	assert.Equal(t, 1, l.Renewals)
+
+	err := l.Renew(policy, later)
+	require.NotNil(t, err)
+	assert.Equal(t, ProcessName, err.Process)
+}
+
+func TestRenewKeepsLaterDueDate(t *testing.T) {
+	policy := Policy{Period: 24 * time.Hour, MaxRenewals: 1}
+	l := New(1, 2, 2, Policy{Period: 72 * time.Hour}, now)
+	due := l.DueAt
+	require.Nil(t, l.Renew(policy, now))
+	assert.Equal(t, due, l.DueAt)
+}
+
+func TestRenewOverdue(t *testing.T) {
+	policy := Policy{Period: 24 * time.Hour, MaxRenewals: 1}
+	l := New(1, 2, 2, policy, now)
+	due := l.DueAt
+	err := l.Renew(policy, due.Add(time.Minute))
+	require.NotNil(t, err)
+	assert.True(t, errdef.IsFailedPrecondition(err))
+	assert.Equal(t, due, l.DueAt)
+	assert.Equal(t, 0, l.Renewals)
+	require.Nil(t, l.Renew(policy, due))
+}
+
+func TestReturn(t *testing.T) {
+	l := New(1, 2, 2, DefaultPolicy, now)
+	require.Nil(t, l.Return(now))
+	assert.False(t, l.Active())
+	assert.False(t, l.Overdue(now.Add(DefaultPolicy.Period*2)))
+	assert.NotNil(t, l.Return(now))
+	assert.NotNil(t, l.Renew(DefaultPolicy, now))
+}
+
+func TestLoansActive(t *testing.T) {
+	returned := New(1, 1, 1, DefaultPolicy, now)
+	require.Nil(t, returned.Return(now))
+	active := New(1, 2, 2, DefaultPolicy, now)
+	ll := Loans{returned, active}
+	assert.Equal(t, Loans{active}, ll.Active())
+}
@@ -0,0 +1,89 @@
+package person
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+	"github.com/investapp/backend/pkg/valid"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "person"
+
+// Person is a database model of a library patron. Email is unique among
+// all people, including the deleted ones. Holds of the person are picked
+// up at PickupBranchID unless the hold says otherwise.
+type Person struct {
+	gorm.Model
+	Name           string
+	Email          string `gorm:"typevarchar(100);unique_index"` // nastavení gormu, aby byl jen jeden email pro každého uživatele
+	PickupBranchID *uint
+	Books          []book.Book
+}
+
+// Sanitize will sanitize person
+func (p *Person) Sanitize() {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Email = strings.ToLower(strings.TrimSpace(p.Email))
+}
+
+// Validate validates struct content.
+func (p Person) Validate() *errdef.Error {
+	errSet := errdef.ErrInvalidArgument("person is not valid")
+	switch {
+	case len(p.Name) == 0 || len(p.Name) > 200:
+		errSet.Detail = fmt.Sprintf("name out of range 1-200 characters: '%s'", p.Name)
+		return errSet.WithMeta("field", "name")
+	case len(p.Email) > 100 || !valid.Email(p.Email):
+		errSet.Detail = fmt.Sprintf("email is not valid: '%s'", p.Email)
+		return errSet.WithMeta("field", "email")
+	}
+	return nil
+}
+
+// Sortable maps fields people can be sorted by to their columns.
+var Sortable = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// TrashSortable maps fields deleted people can be sorted by to their
+// columns. deleted_at is only sortable there, where it is never NULL.
+var TrashSortable = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"email":      "email",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"deleted_at": "deleted_at",
+}
+
+// SortValues returns values of the sort fields.
+func (p Person) SortValues(sort paging.Sort) []interface{} {
+	values := make([]interface{}, 0, len(sort))
+	for _, f := range sort {
+		switch f.Name {
+		case "id":
+			values = append(values, p.ID)
+		case "name":
+			values = append(values, p.Name)
+		case "email":
+			values = append(values, p.Email)
+		case "created_at":
+			values = append(values, p.CreatedAt)
+		case "updated_at":
+			values = append(values, p.UpdatedAt)
+		case "deleted_at":
+			values = append(values, p.DeletedAt)
+		}
+	}
+	return values
+}
@@ -0,0 +1,43 @@
+package person
+
+import (
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// BooksPolicy defines what happens to books of a person who is deleted.
+type BooksPolicy int
+
+const (
+	// Refuse keeps the person if they have any books.
+	Refuse BooksPolicy = iota
+	// Cascade deletes the books together with the person.
+	Cascade
+	// Reassign gives the books to another person.
+	Reassign
+)
+
+// String implements fmt.Stringer.
+func (p BooksPolicy) String() string {
+	switch p {
+	case Refuse:
+		return "refuse"
+	case Cascade:
+		return "cascade"
+	case Reassign:
+		return "reassign"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseBooksPolicy parses policy from its string form.
+func ParseBooksPolicy(s string) (BooksPolicy, *errdef.Error) {
+	for _, policy := range []BooksPolicy{Refuse, Cascade, Reassign} {
+		if strings.EqualFold(policy.String(), s) {
+			return policy, nil
+		}
+	}
+	return Refuse, errdef.ErrInvalidArgument("invalid books policy value: "+s).WithProcess(ProcessName).WithMeta("field", "books")
+}
@@ -0,0 +1,174 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MaxNeighbours is how many similar books are kept for every book.
+const MaxNeighbours = 50
+
+// Reason tells why the book is recommended.
+type Reason string
+
+const (
+	// Similar books were borrowed by people who borrowed the same books.
+	Similar Reason = "similar"
+	// Popular books were borrowed by the most people. They fill in
+	// when there is not enough history to find similar books.
+	Popular Reason = "popular"
+)
+
+// Recommendation is a recommended book. Similar books are ranked
+// by their score, popular books by the number of borrowers.
+type Recommendation struct {
+	BookID uint
+	Score  float64
+	Reason Reason
+}
+
+// Builder collects who borrowed what. Repeated loans of the same book
+// by the same person count once.
+type Builder struct {
+	borrowed map[uint]map[uint]bool
+	readers  map[uint]int
+}
+
+// NewBuilder returns an empty builder.
+func NewBuilder() *Builder {
+	return &Builder{borrowed: map[uint]map[uint]bool{}, readers: map[uint]int{}}
+}
+
+// Add records that the person borrowed the book.
+func (b *Builder) Add(personID, bookID uint) {
+	books, ok := b.borrowed[personID]
+	if !ok {
+		books = map[uint]bool{}
+		b.borrowed[personID] = books
+	}
+	if books[bookID] {
+		return
+	}
+	books[bookID] = true
+	b.readers[bookID]++
+}
+
+// Build computes the model. Similarity of two books is the cosine of
+// their sets of borrowers, the number of people who borrowed both
+// divided by the geometric mean of their numbers of borrowers.
+func (b *Builder) Build(now time.Time) *Model {
+	together := map[uint]map[uint]int{}
+	for _, books := range b.borrowed {
+		for x := range books {
+			pairs, ok := together[x]
+			if !ok {
+				pairs = map[uint]int{}
+				together[x] = pairs
+			}
+			for y := range books {
+				if x != y {
+					pairs[y]++
+				}
+			}
+		}
+	}
+
+	m := &Model{BuiltAt: now, similar: map[uint][]Recommendation{}, borrowed: map[uint][]uint{}}
+	for x, pairs := range together {
+		var similar []Recommendation
+		for y, n := range pairs {
+			score := float64(n) / math.Sqrt(float64(b.readers[x]*b.readers[y]))
+			similar = append(similar, Recommendation{BookID: y, Score: score, Reason: Similar})
+		}
+		rank(similar)
+		if len(similar) > MaxNeighbours {
+			similar = similar[:MaxNeighbours]
+		}
+		if len(similar) > 0 {
+			m.similar[x] = similar
+		}
+	}
+	for bookID, n := range b.readers {
+		m.popular = append(m.popular, Recommendation{BookID: bookID, Score: float64(n), Reason: Popular})
+	}
+	rank(m.popular)
+	for personID, books := range b.borrowed {
+		for bookID := range books {
+			m.borrowed[personID] = append(m.borrowed[personID], bookID)
+		}
+	}
+	return m
+}
+
+// Model answers recommendations from the loan history it was built
+// from. It is not changed once built and can be shared.
+type Model struct {
+	// BuiltAt is the time the history was read.
+	BuiltAt  time.Time
+	similar  map[uint][]Recommendation
+	popular  []Recommendation
+	borrowed map[uint][]uint
+}
+
+// Similar returns up to limit books most often borrowed together with
+// the book, topped up with popular books.
+func (m *Model) Similar(bookID uint, limit int) []Recommendation {
+	skip := map[uint]bool{bookID: true}
+	return m.topUp(m.similar[bookID], skip, limit)
+}
+
+// ForPerson returns up to limit books the person did not borrow yet.
+// Books are scored by the sum of their similarities to the books the
+// person borrowed, people with no history get popular books.
+func (m *Model) ForPerson(personID uint, limit int) []Recommendation {
+	skip := map[uint]bool{}
+	for _, bookID := range m.borrowed[personID] {
+		skip[bookID] = true
+	}
+	scores := map[uint]float64{}
+	for _, bookID := range m.borrowed[personID] {
+		for _, r := range m.similar[bookID] {
+			if !skip[r.BookID] {
+				scores[r.BookID] += r.Score
+			}
+		}
+	}
+	var similar []Recommendation
+	for bookID, score := range scores {
+		similar = append(similar, Recommendation{BookID: bookID, Score: score, Reason: Similar})
+	}
+	rank(similar)
+	return m.topUp(similar, skip, limit)
+}
+
+// topUp returns up to limit of the ranked books, the rest is filled with
+// popular books. Books in skip are left out.
+func (m *Model) topUp(ranked []Recommendation, skip map[uint]bool, limit int) []Recommendation {
+	results := []Recommendation{}
+	seen := map[uint]bool{}
+	for _, list := range [][]Recommendation{ranked, m.popular} {
+		for _, r := range list {
+			if len(results) == limit {
+				return results
+			}
+			if skip[r.BookID] || seen[r.BookID] {
+				continue
+			}
+			seen[r.BookID] = true
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+// rank sorts by score, ties are broken by the book ID so the order
+// does not depend on map iteration.
+func rank(list []Recommendation) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].BookID < list[j].BookID
+	})
+}
@@ -0,0 +1,67 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+// model is built from people 1-3 borrowing books 1-3 together,
+// and many people borrowing book 4 alone.
+func model() *Model {
+	b := NewBuilder()
+	b.Add(1, 1)
+	b.Add(1, 2)
+	b.Add(1, 2)
+	b.Add(2, 1)
+	b.Add(2, 2)
+	b.Add(3, 1)
+	b.Add(3, 3)
+	for p := uint(10); p < 15; p++ {
+		b.Add(p, 4)
+	}
+	return b.Build(now)
+}
+
+func bookIDs(list []Recommendation) []uint {
+	ids := []uint{}
+	for _, r := range list {
+		ids = append(ids, r.BookID)
+	}
+	return ids
+}
+
+func TestSimilar(t *testing.T) {
+	m := model()
+	assert.Equal(t, now, m.BuiltAt)
+
+	similar := m.Similar(2, 10)
+	require.Len(t, similar, 3)
+	assert.Equal(t, []uint{1, 4, 3}, bookIDs(similar))
+	assert.Equal(t, Similar, similar[0].Reason)
+	assert.InDelta(t, 2/(1.7320508*1.4142136), similar[0].Score, 1e-6)
+	assert.Equal(t, Popular, similar[1].Reason)
+
+	assert.Equal(t, []uint{2, 3}, bookIDs(m.Similar(1, 2)))
+	assert.Equal(t, []uint{1, 2}, bookIDs(m.Similar(4, 2)), "nobody borrowed it with other books")
+}
+
+func TestForPerson(t *testing.T) {
+	m := model()
+
+	recs := m.ForPerson(2, 10)
+	assert.Equal(t, []uint{3, 4}, bookIDs(recs), "borrowed books are left out")
+	assert.Equal(t, Similar, recs[0].Reason)
+
+	recs = m.ForPerson(99, 2)
+	assert.Equal(t, []uint{4, 1}, bookIDs(recs))
+	for _, r := range recs {
+		assert.Equal(t, Popular, r.Reason)
+	}
+
+	assert.Empty(t, NewBuilder().Build(now).ForPerson(1, 10))
+}
@@ -0,0 +1,114 @@
+// Package report defines the rows of circulation reports. The numbers
+// are aggregated by the store, the same way in the database and in memory.
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "report"
+
+// Group is how loans are grouped.
+type Group int
+
+const (
+	// Month groups loans by the month they were checked out.
+	Month Group = iota
+	// Week groups loans by the week they were checked out,
+	// weeks start on Monday.
+	Week
+	// Day groups loans by the day they were checked out.
+	Day
+	// Branch groups loans by the home branch of the copy.
+	Branch
+	// Author groups loans by the authors of the book, a loan of a book
+	// with several authors counts for each of them.
+	Author
+)
+
+// String implements fmt.Stringer.
+func (g Group) String() string {
+	switch g {
+	case Month:
+		return "month"
+	case Week:
+		return "week"
+	case Day:
+		return "day"
+	case Branch:
+		return "branch"
+	case Author:
+		return "author"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseGroup parses group from its string form.
+func ParseGroup(s string) (Group, *errdef.Error) {
+	for _, g := range []Group{Month, Week, Day, Branch, Author} {
+		if strings.EqualFold(g.String(), s) {
+			return g, nil
+		}
+	}
+	return Month, errdef.ErrInvalidArgument("invalid group value: "+s).WithProcess(ProcessName).WithMeta("field", "group")
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (g Group) MarshalText() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+// Period returns the key of the period t falls in, the date it starts
+// on, "2006-01" for months. It is empty for groups which are not periods.
+func (g Group) Period(t time.Time) string {
+	t = t.UTC()
+	switch g {
+	case Month:
+		return t.Format("2006-01")
+	case Week:
+		days := (int(t.Weekday()) + 6) % 7
+		return t.AddDate(0, 0, -days).Format("2006-01-02")
+	case Day:
+		return t.Format("2006-01-02")
+	default:
+		return ""
+	}
+}
+
+// LoanCount is the number of loans checked out in a group and how
+// many of them were returned late or are still out after the due date.
+type LoanCount struct {
+	// Group is the period, branch code or author name. Loans of books
+	// with no authors have empty group.
+	Group       string
+	Loans       int64
+	Overdue     int64
+	OverdueRate float64
+}
+
+// Rate computes the overdue rate from the counts.
+func (c *LoanCount) Rate() {
+	c.OverdueRate = 0
+	if c.Loans > 0 {
+		c.OverdueRate = float64(c.Overdue) / float64(c.Loans)
+	}
+}
+
+// BookCount is the number of loans of a book.
+type BookCount struct {
+	BookID uint
+	Title  string
+	Loans  int64
+}
+
+// PersonCount is the number of loans of a person.
+type PersonCount struct {
+	PersonID uint
+	Name     string
+	Loans    int64
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroup(t *testing.T) {
+	for _, g := range []Group{Month, Week, Day, Branch, Author} {
+		parsed, errSet := ParseGroup(g.String())
+		require.Nil(t, errSet)
+		assert.Equal(t, g, parsed)
+	}
+	_, errSet := ParseGroup("year")
+	require.NotNil(t, errSet)
+	assert.Equal(t, ProcessName, errSet.Process)
+}
+
+func TestPeriod(t *testing.T) {
+	// Sunday, late in the evening
+	at := time.Date(2020, 3, 1, 23, 30, 0, 0, time.UTC)
+	assert.Equal(t, "2020-03", Month.Period(at))
+	assert.Equal(t, "2020-02-24", Week.Period(at))
+	assert.Equal(t, "2020-03-01", Day.Period(at))
+	assert.Equal(t, "2020-03-02", Week.Period(at.Add(time.Hour)))
+	assert.Equal(t, "2020-03-01", Day.Period(at.In(time.FixedZone("CET", 3600))), "periods are in UTC")
+	assert.Equal(t, "", Branch.Period(at))
+}
+
+func TestRate(t *testing.T) {
+	c := LoanCount{Loans: 4, Overdue: 1}
+	c.Rate()
+	assert.Equal(t, 0.25, c.OverdueRate)
+	c = LoanCount{}
+	c.Rate()
+	assert.Equal(t, 0.0, c.OverdueRate)
+}
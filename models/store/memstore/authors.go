@@ -0,0 +1,145 @@
+package memstore
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type authors struct {
+	s *Store
+}
+
+func (r authors) Find(f store.AuthorFilter, p paging.Params) ([]author.Author, *int64, *errdef.Error) {
+	var (
+		results []author.Author
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []author.Author
+		for _, row := range t.authors {
+			if row.DeletedAt != nil {
+				continue
+			}
+			if f.Name != "" && !strings.Contains(strings.ToLower(row.Name), strings.ToLower(f.Name)) {
+				continue
+			}
+			rows = append(rows, row)
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r authors) Get(id uint) (author.Author, *errdef.Error) {
+	var (
+		row author.Author
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.authors[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return author.Author{}, errdef.ErrNotFoundf("author %d not found", id).WithProcess(author.ProcessName)
+	}
+	return row, nil
+}
+
+// Lock is the same as Get, transactions don't run concurrently.
+func (r authors) Lock(id uint) (author.Author, *errdef.Error) {
+	return r.Get(id)
+}
+
+func (r authors) Save(a *author.Author) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		t.stamp("authors", &a.Model)
+		t.authors[a.ID] = *a
+		return nil
+	})
+}
+
+func (r authors) Delete(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.authors[id]
+		if !ok || row.DeletedAt != nil {
+			return errdef.ErrNotFoundf("author %d not found", id).WithProcess(author.ProcessName)
+		}
+		softDelete(&row.Model)
+		t.authors[id] = row
+		return nil
+	})
+}
+
+func (r authors) Credits(bookIDs ...uint) (map[uint][]author.Credit, *errdef.Error) {
+	results := make(map[uint][]author.Credit, len(bookIDs))
+	r.s.read(func(t *tables) {
+		for _, id := range bookIDs {
+			credits := t.credits[id]
+			if len(credits) == 0 {
+				continue
+			}
+			list := make([]author.Credit, len(credits))
+			for i, c := range credits {
+				c.Name = t.authors[c.AuthorID].Name
+				list[i] = c
+			}
+			results[id] = list
+		}
+	})
+	return results, nil
+}
+
+func (r authors) SetCredits(bookID uint, credits []author.Credit) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		if len(credits) == 0 {
+			delete(t.credits, bookID)
+			return nil
+		}
+		list := make([]author.Credit, len(credits))
+		for i, c := range credits {
+			c.BookID = bookID
+			c.Name = ""
+			list[i] = c
+		}
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Position < list[j].Position
+		})
+		t.credits[bookID] = list
+		return nil
+	})
+}
+
+func (r authors) BookIDs(authorID uint) ([]uint, *errdef.Error) {
+	var ids []uint
+	r.s.read(func(t *tables) {
+		for bookID, credits := range t.credits {
+			if credited(credits, authorID) {
+				ids = append(ids, bookID)
+			}
+		}
+	})
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids, nil
+}
+
+// credited tells if the author has any of the credits.
+func credited(credits []author.Credit, authorID uint) bool {
+	for _, c := range credits {
+		if c.AuthorID == authorID {
+			return true
+		}
+	}
+	return false
+}
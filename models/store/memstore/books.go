@@ -0,0 +1,276 @@
+package memstore
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type books struct {
+	s *Store
+}
+
+func (r books) Find(f store.BookFilter, p paging.Params) ([]book.Book, *int64, *errdef.Error) {
+	var (
+		results []book.Book
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []book.Book
+		for _, row := range t.books {
+			if matchBook(t, f, row) {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r books) Search(q book.Query, p paging.Params) ([]book.Match, *int64, *errdef.Error) {
+	var matches []book.Match
+	r.s.read(func(t *tables) {
+		for _, row := range t.books {
+			if row.DeletedAt != nil {
+				continue
+			}
+			if m, ok := q.Match(row); ok {
+				matches = append(matches, m)
+			}
+		}
+	})
+	book.SortMatches(matches)
+
+	var total *int64
+	if p.WithTotal {
+		count := int64(len(matches))
+		total = &count
+	}
+	if p.Offset >= len(matches) {
+		return nil, total, nil
+	}
+	matches = matches[p.Offset:]
+	if len(matches) > p.Limit+1 {
+		matches = matches[:p.Limit+1]
+	}
+	return matches, total, nil
+}
+
+func (r books) FindByPersonID(personID uint) ([]book.Book, *errdef.Error) {
+	var results []book.Book
+	r.s.read(func(t *tables) {
+		for _, row := range t.books {
+			if row.DeletedAt == nil && row.PersonID == int(personID) {
+				results = append(results, row)
+			}
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+func (r books) Each(f store.BookFilter, fn func(b book.Book) *errdef.Error) *errdef.Error {
+	var rows []book.Book
+	r.s.read(func(t *tables) {
+		for _, row := range t.books {
+			if matchBook(t, f, row) {
+				rows = append(rows, row)
+			}
+		}
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+	for _, row := range rows {
+		if errSet := fn(row); errSet != nil {
+			return errSet
+		}
+	}
+	return nil
+}
+
+// matchBook tells if the row is not deleted and matches the filter.
+func matchBook(t *tables, f store.BookFilter, row book.Book) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if f.Author != "" && !strings.Contains(strings.ToLower(row.Author), strings.ToLower(f.Author)) {
+		return false
+	}
+	if f.Title != "" && !strings.HasPrefix(strings.ToLower(row.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	if f.PersonID != nil && row.PersonID != int(*f.PersonID) {
+		return false
+	}
+	if f.CallNumber != 0 && row.CallNumber != f.CallNumber {
+		return false
+	}
+	if f.AuthorID != 0 && !credited(t.credits[row.ID], f.AuthorID) {
+		return false
+	}
+	if f.BranchID != 0 && !t.locatedAt(row.ID, f.BranchID) {
+		return false
+	}
+	return true
+}
+
+func (r books) Get(id uint) (book.Book, *errdef.Error) {
+	var (
+		row book.Book
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.books[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return book.Book{}, errdef.ErrNotFoundf("book %d not found", id).WithProcess(book.ProcessName)
+	}
+	return row, nil
+}
+
+func (r books) GetByISBN(isbn string) (book.Book, *errdef.Error) {
+	var (
+		found book.Book
+		ok    bool
+	)
+	r.s.read(func(t *tables) {
+		for _, row := range t.books {
+			if row.DeletedAt == nil && row.ISBN == isbn {
+				found, ok = row, true
+				return
+			}
+		}
+	})
+	if !ok || isbn == "" {
+		return book.Book{}, errdef.ErrNotFoundf("book with isbn %s not found", isbn).WithProcess(book.ProcessName)
+	}
+	return found, nil
+}
+
+// Lock is the same as Get, transactions don't run concurrently.
+func (r books) Lock(id uint) (book.Book, *errdef.Error) {
+	return r.Get(id)
+}
+
+func (r books) Save(b *book.Book) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.books {
+			if row.ID != b.ID && row.CallNumber == b.CallNumber {
+				return errdef.ErrAlreadyExistsf("book with call number %d already exists", b.CallNumber).WithMeta("field", "call_number")
+			}
+			if row.ID != b.ID && b.ISBN != "" && row.ISBN == b.ISBN {
+				return errdef.ErrAlreadyExistsf("book with isbn %s already exists", b.ISBN).WithMeta("field", "isbn")
+			}
+		}
+		t.stamp("books", &b.Model)
+		row := *b
+		row.Authors = nil
+		t.books[row.ID] = row
+		return nil
+	})
+}
+
+func (r books) Delete(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.books[id]
+		if !ok || row.DeletedAt != nil {
+			return errdef.ErrNotFoundf("book %d not found", id).WithProcess(book.ProcessName)
+		}
+		softDelete(&row.Model)
+		t.books[id] = row
+		return nil
+	})
+}
+
+func (r books) Trash(p paging.Params) ([]book.Book, *int64, *errdef.Error) {
+	var (
+		results []book.Book
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []book.Book
+		for _, row := range t.books {
+			if row.DeletedAt != nil {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r books) GetDeleted(id uint) (book.Book, *errdef.Error) {
+	var (
+		row book.Book
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.books[id]
+	})
+	if !ok || row.DeletedAt == nil {
+		return book.Book{}, errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+	}
+	return row, nil
+}
+
+func (r books) Restore(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.books[id]
+		if !ok || row.DeletedAt == nil {
+			return errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+		}
+		row.DeletedAt = nil
+		t.stamp("books", &row.Model)
+		t.books[id] = row
+		return nil
+	})
+}
+
+func (r books) Purge(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.books[id]
+		if !ok || row.DeletedAt == nil {
+			return errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+		}
+		delete(t.books, id)
+		delete(t.credits, id)
+		t.purgeCopies(id)
+		return nil
+	})
+}
+
+func (r books) PurgeDeletedBefore(before time.Time) (int64, *errdef.Error) {
+	var n int64
+	errSet := r.s.write(func(t *tables) *errdef.Error {
+		for id, row := range t.books {
+			if row.DeletedAt != nil && row.DeletedAt.Before(before) {
+				delete(t.books, id)
+				delete(t.credits, id)
+				t.purgeCopies(id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, errSet
+}
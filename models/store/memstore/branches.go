@@ -0,0 +1,86 @@
+package memstore
+
+import (
+	"strings"
+
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type branches struct {
+	s *Store
+}
+
+func (r branches) Find(f store.BranchFilter, p paging.Params) ([]branch.Branch, *int64, *errdef.Error) {
+	var (
+		results []branch.Branch
+		total   *int64
+	)
+	name := strings.ToLower(f.Name)
+	r.s.read(func(t *tables) {
+		var rows []branch.Branch
+		for _, row := range t.branches {
+			if row.DeletedAt != nil {
+				continue
+			}
+			if name != "" && !strings.Contains(strings.ToLower(row.Name), name) && !strings.Contains(strings.ToLower(row.Code), name) {
+				continue
+			}
+			rows = append(rows, row)
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r branches) Get(id uint) (branch.Branch, *errdef.Error) {
+	var (
+		row branch.Branch
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.branches[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return branch.Branch{}, errdef.ErrNotFoundf("branch %d not found", id).WithProcess(branch.ProcessName)
+	}
+	return row, nil
+}
+
+// Lock is the same as Get, transactions don't run concurrently.
+func (r branches) Lock(id uint) (branch.Branch, *errdef.Error) {
+	return r.Get(id)
+}
+
+func (r branches) Save(b *branch.Branch) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.branches {
+			if row.ID != b.ID && row.Code == b.Code {
+				return errdef.ErrAlreadyExistsf("branch with code %s already exists", b.Code).WithMeta("field", "code")
+			}
+		}
+		t.stamp("branches", &b.Model)
+		t.branches[b.ID] = *b
+		return nil
+	})
+}
+
+func (r branches) Delete(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.branches[id]
+		if !ok || row.DeletedAt != nil {
+			return errdef.ErrNotFoundf("branch %d not found", id).WithProcess(branch.ProcessName)
+		}
+		softDelete(&row.Model)
+		t.branches[id] = row
+		return nil
+	})
+}
@@ -0,0 +1,161 @@
+package memstore
+
+import (
+	"sort"
+
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type copies struct {
+	s *Store
+}
+
+func (r copies) Find(f store.CopyFilter, p paging.Params) ([]item.Copy, *int64, *errdef.Error) {
+	var (
+		results []item.Copy
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []item.Copy
+		for _, row := range t.copies {
+			if matchCopy(f, row) {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+// matchCopy tells if the row is not deleted and matches the filter.
+func matchCopy(f store.CopyFilter, row item.Copy) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if f.BookID != 0 && row.BookID != f.BookID {
+		return false
+	}
+	if f.Status != nil && row.Status != *f.Status {
+		return false
+	}
+	if f.BranchID != 0 && row.LocationID != f.BranchID {
+		return false
+	}
+	if f.HomeBranchID != 0 && row.HomeBranchID != f.HomeBranchID {
+		return false
+	}
+	return true
+}
+
+func (r copies) OfBook(bookID uint) ([]item.Copy, *errdef.Error) {
+	var results []item.Copy
+	r.s.read(func(t *tables) {
+		for _, row := range t.copies {
+			if matchCopy(store.CopyFilter{BookID: bookID}, row) {
+				results = append(results, row)
+			}
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+func (r copies) Get(id uint) (item.Copy, *errdef.Error) {
+	var (
+		row item.Copy
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.copies[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return item.Copy{}, errdef.ErrNotFoundf("copy %d not found", id).WithProcess(item.ProcessName)
+	}
+	return row, nil
+}
+
+func (r copies) GetByBarcode(barcode string) (item.Copy, *errdef.Error) {
+	var (
+		found item.Copy
+		ok    bool
+	)
+	r.s.read(func(t *tables) {
+		for _, row := range t.copies {
+			if row.DeletedAt == nil && row.Barcode == barcode {
+				found, ok = row, true
+				return
+			}
+		}
+	})
+	if !ok {
+		return item.Copy{}, errdef.ErrNotFoundf("copy with barcode %s not found", barcode).WithProcess(item.ProcessName)
+	}
+	return found, nil
+}
+
+// Lock is the same as Get, transactions don't run concurrently.
+func (r copies) Lock(id uint) (item.Copy, *errdef.Error) {
+	return r.Get(id)
+}
+
+func (r copies) Save(c *item.Copy) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.copies {
+			if row.ID != c.ID && row.Barcode == c.Barcode {
+				return errdef.ErrAlreadyExistsf("copy with barcode %s already exists", c.Barcode).WithMeta("field", "barcode")
+			}
+		}
+		t.stamp("copies", &c.Model)
+		t.copies[c.ID] = *c
+		return nil
+	})
+}
+
+func (r copies) Delete(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.copies[id]
+		if !ok || row.DeletedAt != nil {
+			return errdef.ErrNotFoundf("copy %d not found", id).WithProcess(item.ProcessName)
+		}
+		softDelete(&row.Model)
+		t.copies[id] = row
+		return nil
+	})
+}
+
+// purgeCopies removes all copies of the book and their transfers,
+// the database does it when the book is purged.
+func (t *tables) purgeCopies(bookID uint) {
+	for id, row := range t.copies {
+		if row.BookID != bookID {
+			continue
+		}
+		delete(t.copies, id)
+		for transferID, tr := range t.transfers {
+			if tr.CopyID == id {
+				delete(t.transfers, transferID)
+			}
+		}
+	}
+}
+
+// locatedAt tells if the book has a copy located at the branch.
+func (t *tables) locatedAt(bookID, branchID uint) bool {
+	for _, row := range t.copies {
+		if row.BookID == bookID && matchCopy(store.CopyFilter{BranchID: branchID}, row) {
+			return true
+		}
+	}
+	return false
+}
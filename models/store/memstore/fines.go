@@ -0,0 +1,67 @@
+package memstore
+
+import (
+	"sort"
+
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type fines struct {
+	s *Store
+}
+
+func (r fines) Find(f store.FineFilter, p paging.Params) (fine.Ledger, *int64, *errdef.Error) {
+	var (
+		results fine.Ledger
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows fine.Ledger
+		for _, row := range t.fines {
+			if row.DeletedAt != nil {
+				continue
+			}
+			if f.PersonID != 0 && row.PersonID != f.PersonID {
+				continue
+			}
+			if f.Kind != nil && row.Kind != *f.Kind {
+				continue
+			}
+			rows = append(rows, row)
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r fines) Ledger(personID uint) (fine.Ledger, *errdef.Error) {
+	var ledger fine.Ledger
+	r.s.read(func(t *tables) {
+		for _, row := range t.fines {
+			if row.DeletedAt == nil && row.PersonID == personID {
+				ledger = append(ledger, row)
+			}
+		}
+	})
+	sort.Slice(ledger, func(i, j int) bool {
+		return ledger[i].ID < ledger[j].ID
+	})
+	return ledger, nil
+}
+
+func (r fines) Save(e *fine.Entry) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		t.stamp("fines", &e.Model)
+		t.fines[e.ID] = *e
+		return nil
+	})
+}
@@ -0,0 +1,103 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type holds struct {
+	s *Store
+}
+
+func (r holds) Find(f store.HoldFilter, p paging.Params) (hold.Holds, *int64, *errdef.Error) {
+	var (
+		results hold.Holds
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows hold.Holds
+		for _, row := range t.holds {
+			if row.DeletedAt != nil {
+				continue
+			}
+			if f.PersonID != 0 && row.PersonID != f.PersonID {
+				continue
+			}
+			if f.BookID != 0 && row.BookID != f.BookID {
+				continue
+			}
+			if f.Status != nil && row.Status != *f.Status {
+				continue
+			}
+			if f.BranchID != 0 && (row.PickupBranchID == nil || *row.PickupBranchID != f.BranchID) {
+				continue
+			}
+			rows = append(rows, row)
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r holds) Get(id uint) (hold.Hold, *errdef.Error) {
+	var (
+		row hold.Hold
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.holds[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return hold.Hold{}, errdef.ErrNotFoundf("hold %d not found", id).WithProcess(hold.ProcessName)
+	}
+	return row, nil
+}
+
+func (r holds) Open(bookID uint) (hold.Holds, *errdef.Error) {
+	var results hold.Holds
+	r.s.read(func(t *tables) {
+		for _, row := range t.holds {
+			if row.DeletedAt == nil && row.BookID == bookID && row.Open() {
+				results = append(results, row)
+			}
+		}
+	})
+	sort.Sort(results)
+	return results, nil
+}
+
+func (r holds) ExpiredBookIDs(now time.Time) ([]uint, *errdef.Error) {
+	seen := map[uint]bool{}
+	var bookIDs []uint
+	r.s.read(func(t *tables) {
+		for _, row := range t.holds {
+			if row.DeletedAt != nil || row.Status != hold.Ready || row.ExpiresAt == nil {
+				continue
+			}
+			if row.ExpiresAt.Before(now) && !seen[row.BookID] {
+				seen[row.BookID] = true
+				bookIDs = append(bookIDs, row.BookID)
+			}
+		}
+	})
+	return bookIDs, nil
+}
+
+func (r holds) Save(h *hold.Hold) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		t.stamp("holds", &h.Model)
+		t.holds[h.ID] = *h
+		return nil
+	})
+}
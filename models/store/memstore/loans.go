@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+	"sort"
+)
+
+type loans struct {
+	s *Store
+}
+
+func (r loans) Find(f store.LoanFilter, p paging.Params) (loan.Loans, *int64, *errdef.Error) {
+	var (
+		results loan.Loans
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows loan.Loans
+		for _, row := range t.loans {
+			if matchLoan(f, row) {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r loans) Each(f store.LoanFilter, fn func(l loan.Loan) *errdef.Error) *errdef.Error {
+	var rows []loan.Loan
+	r.s.read(func(t *tables) {
+		for _, row := range t.loans {
+			if matchLoan(f, row) {
+				rows = append(rows, row)
+			}
+		}
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+	for _, row := range rows {
+		if errSet := fn(row); errSet != nil {
+			return errSet
+		}
+	}
+	return nil
+}
+
+// matchLoan tells if the row is not deleted and matches the filter.
+func matchLoan(f store.LoanFilter, row loan.Loan) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if f.PersonID != 0 && row.PersonID != f.PersonID {
+		return false
+	}
+	if f.BookID != 0 && row.BookID != f.BookID {
+		return false
+	}
+	if f.CopyID != 0 && row.CopyID != f.CopyID {
+		return false
+	}
+	if f.Active != nil && row.Active() != *f.Active {
+		return false
+	}
+	return true
+}
+
+func (r loans) Get(id uint) (loan.Loan, *errdef.Error) {
+	var (
+		row loan.Loan
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.loans[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return loan.Loan{}, errdef.ErrNotFoundf("loan %d not found", id).WithProcess(loan.ProcessName)
+	}
+	return row, nil
+}
+
+func (r loans) Active(copyID uint) (*loan.Loan, *errdef.Error) {
+	var active *loan.Loan
+	r.s.read(func(t *tables) {
+		for _, row := range t.loans {
+			if row.DeletedAt == nil && row.CopyID == copyID && row.Active() {
+				row := row
+				active = &row
+				return
+			}
+		}
+	})
+	return active, nil
+}
+
+func (r loans) Save(l *loan.Loan) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		t.stamp("loans", &l.Model)
+		t.loans[l.ID] = *l
+		return nil
+	})
+}
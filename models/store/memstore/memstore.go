@@ -0,0 +1,247 @@
+// Package memstore implements store.Store in memory. It is safe for
+// concurrent use, transactions are serialized and work on a copy of
+// the data which replaces the original on commit.
+package memstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Store is store.Store keeping the data in memory.
+type Store struct {
+	mu *sync.RWMutex
+	t  *tables
+	// tx is set for stores bound to a transaction, they already
+	// hold the write lock.
+	tx bool
+}
+
+var _ store.Store = &Store{}
+
+// tables holds the rows of every model by their ID.
+type tables struct {
+	people  map[uint]person.Person
+	books   map[uint]book.Book
+	authors map[uint]author.Author
+	// credits are lists of credits by book ID, they are replaced
+	// as a whole, never changed in place.
+	credits   map[uint][]author.Credit
+	branches  map[uint]branch.Branch
+	copies    map[uint]item.Copy
+	transfers map[uint]branch.Transfer
+	loans     map[uint]loan.Loan
+	holds     map[uint]hold.Hold
+	fines     map[uint]fine.Entry
+	users     map[uint]user.User
+	refresh   map[uint]user.RefreshToken
+	lastID    map[string]uint
+}
+
+// New creates empty store.
+func New() *Store {
+	return &Store{
+		mu: &sync.RWMutex{},
+		t: &tables{
+			people:    map[uint]person.Person{},
+			books:     map[uint]book.Book{},
+			authors:   map[uint]author.Author{},
+			credits:   map[uint][]author.Credit{},
+			branches:  map[uint]branch.Branch{},
+			copies:    map[uint]item.Copy{},
+			transfers: map[uint]branch.Transfer{},
+			loans:     map[uint]loan.Loan{},
+			holds:     map[uint]hold.Hold{},
+			fines:     map[uint]fine.Entry{},
+			users:     map[uint]user.User{},
+			refresh:   map[uint]user.RefreshToken{},
+			lastID:    map[string]uint{},
+		},
+	}
+}
+
+// People returns the repository of people.
+func (s *Store) People() store.People {
+	return people{s}
+}
+
+// Books returns the repository of books.
+func (s *Store) Books() store.Books {
+	return books{s}
+}
+
+// Authors returns the repository of authors.
+func (s *Store) Authors() store.Authors {
+	return authors{s}
+}
+
+// Branches returns the repository of branches.
+func (s *Store) Branches() store.Branches {
+	return branches{s}
+}
+
+// Copies returns the repository of copies.
+func (s *Store) Copies() store.Copies {
+	return copies{s}
+}
+
+// Transfers returns the repository of transfers.
+func (s *Store) Transfers() store.Transfers {
+	return transfers{s}
+}
+
+// Loans returns the repository of loans.
+func (s *Store) Loans() store.Loans {
+	return loans{s}
+}
+
+// Holds returns the repository of holds.
+func (s *Store) Holds() store.Holds {
+	return holds{s}
+}
+
+// Fines returns the repository of the fines ledger.
+func (s *Store) Fines() store.Fines {
+	return fines{s}
+}
+
+// Reports returns the circulation reports.
+func (s *Store) Reports() store.Reports {
+	return reports{s}
+}
+
+// Users returns the repository of user accounts.
+func (s *Store) Users() store.Users {
+	return users{s}
+}
+
+// RefreshTokens returns the repository of refresh tokens.
+func (s *Store) RefreshTokens() store.RefreshTokens {
+	return refreshTokens{s}
+}
+
+// Transaction runs fn with exclusive access to a copy of the data.
+// The copy replaces the data only if fn succeeds. Nested transactions
+// work on a copy of the outer one, like savepoints.
+func (s *Store) Transaction(fn func(tx store.Store) error) error {
+	if !s.tx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	t := s.t.clone()
+	if err := fn(&Store{mu: s.mu, t: t, tx: true}); err != nil {
+		return err
+	}
+	*s.t = *t
+	return nil
+}
+
+// read runs fn with shared access to the data.
+func (s *Store) read(fn func(t *tables)) {
+	if !s.tx {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+	}
+	fn(s.t)
+}
+
+// write runs fn with exclusive access to the data.
+func (s *Store) write(fn func(t *tables) *errdef.Error) *errdef.Error {
+	if !s.tx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+	return fn(s.t)
+}
+
+func (t *tables) clone() *tables {
+	c := &tables{
+		people:    make(map[uint]person.Person, len(t.people)),
+		books:     make(map[uint]book.Book, len(t.books)),
+		authors:   make(map[uint]author.Author, len(t.authors)),
+		credits:   make(map[uint][]author.Credit, len(t.credits)),
+		branches:  make(map[uint]branch.Branch, len(t.branches)),
+		copies:    make(map[uint]item.Copy, len(t.copies)),
+		transfers: make(map[uint]branch.Transfer, len(t.transfers)),
+		loans:     make(map[uint]loan.Loan, len(t.loans)),
+		holds:     make(map[uint]hold.Hold, len(t.holds)),
+		fines:     make(map[uint]fine.Entry, len(t.fines)),
+		users:     make(map[uint]user.User, len(t.users)),
+		refresh:   make(map[uint]user.RefreshToken, len(t.refresh)),
+		lastID:    make(map[string]uint, len(t.lastID)),
+	}
+	for k, v := range t.people {
+		c.people[k] = v
+	}
+	for k, v := range t.books {
+		c.books[k] = v
+	}
+	for k, v := range t.authors {
+		c.authors[k] = v
+	}
+	for k, v := range t.credits {
+		c.credits[k] = v
+	}
+	for k, v := range t.branches {
+		c.branches[k] = v
+	}
+	for k, v := range t.copies {
+		c.copies[k] = v
+	}
+	for k, v := range t.transfers {
+		c.transfers[k] = v
+	}
+	for k, v := range t.loans {
+		c.loans[k] = v
+	}
+	for k, v := range t.holds {
+		c.holds[k] = v
+	}
+	for k, v := range t.fines {
+		c.fines[k] = v
+	}
+	for k, v := range t.users {
+		c.users[k] = v
+	}
+	for k, v := range t.refresh {
+		c.refresh[k] = v
+	}
+	for k, v := range t.lastID {
+		c.lastID[k] = v
+	}
+	return c
+}
+
+// stamp sets ID and timestamps of the row before it is stored,
+// the same way the database does.
+func (t *tables) stamp(table string, m *gorm.Model) {
+	now := time.Now().UTC()
+	if m.ID == 0 {
+		t.lastID[table]++
+		m.ID = t.lastID[table]
+	}
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+}
+
+// softDelete marks the row deleted.
+func softDelete(m *gorm.Model) {
+	now := time.Now().UTC()
+	m.DeletedAt = &now
+}
@@ -0,0 +1,257 @@
+package memstore
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+func TestPeople(t *testing.T) {
+	s := New()
+	jack := person.Person{Name: "Jack", Email: "jack@gmail.com"}
+	require.Nil(t, s.People().Save(&jack))
+	assert.NotZero(t, jack.ID)
+	assert.False(t, jack.CreatedAt.IsZero())
+
+	dup := person.Person{Name: "Other Jack", Email: "jack@gmail.com"}
+	err := s.People().Save(&dup)
+	require.NotNil(t, err)
+	assert.True(t, errdef.IsAlreadyExists(err))
+
+	got, err := s.People().Get(jack.ID)
+	require.Nil(t, err)
+	assert.Equal(t, "Jack", got.Name)
+
+	require.Nil(t, s.People().Delete(jack.ID))
+	_, err = s.People().Get(jack.ID)
+	assert.True(t, errdef.IsNotFound(err))
+	assert.True(t, errdef.IsNotFound(s.People().Delete(jack.ID)))
+
+	// email stays taken by the deleted person
+	err = s.People().Save(&dup)
+	assert.True(t, errdef.IsAlreadyExists(err))
+}
+
+func TestPeopleTrashCursor(t *testing.T) {
+	s := New()
+	for _, p := range []person.Person{{Name: "Jack", Email: "jack@gmail.com"}, {Name: "Jill", Email: "jill@gmail.com"}} {
+		require.Nil(t, s.People().Save(&p))
+		require.Nil(t, s.People().Delete(p.ID))
+	}
+
+	params, err := paging.Parse(url.Values{"limit": {"1"}}, person.TrashSortable, "-deleted_at")
+	require.Nil(t, err)
+	people, _, err := s.People().Trash(params)
+	require.Nil(t, err)
+	page, n := paging.NewPage(params, len(people), func(i int) []interface{} {
+		return people[i].SortValues(params.Sort)
+	})
+	require.Equal(t, 1, n)
+	require.NotEmpty(t, page.NextCursor)
+	assert.Equal(t, "Jill", people[0].Name)
+
+	params, err = paging.Parse(url.Values{"limit": {"1"}, "cursor": {page.NextCursor}}, person.TrashSortable, "-deleted_at")
+	require.Nil(t, err)
+	people, _, err = s.People().Trash(params)
+	require.Nil(t, err)
+	require.Len(t, people, 1, "second page is empty")
+	assert.Equal(t, "Jack", people[0].Name)
+}
+
+func TestRefreshTokens(t *testing.T) {
+	s := New()
+	now := time.Now().UTC()
+	first := user.RefreshToken{UserID: 1, Family: "a", Hash: "1", ExpiresAt: now.Add(time.Hour)}
+	second := user.RefreshToken{UserID: 1, Family: "b", Hash: "2", ExpiresAt: now.Add(time.Hour)}
+	require.Nil(t, s.RefreshTokens().Create(&first))
+	require.Nil(t, s.RefreshTokens().Create(&second))
+	assert.True(t, errdef.IsAlreadyExists(s.RefreshTokens().Create(&user.RefreshToken{Hash: "1"})))
+
+	require.Nil(t, s.RefreshTokens().Rotate(first.ID, now))
+	assert.True(t, errdef.IsFailedPrecondition(s.RefreshTokens().Rotate(first.ID, now)))
+
+	require.Nil(t, s.RefreshTokens().RevokeFamily("b", now))
+	got, err := s.RefreshTokens().ByHash("2")
+	require.Nil(t, err)
+	assert.False(t, got.Active(now))
+	assert.True(t, errdef.IsFailedPrecondition(s.RefreshTokens().Rotate(second.ID, now)))
+
+	require.Nil(t, s.RefreshTokens().RevokeUser(1, now.Add(time.Minute)))
+	got, err = s.RefreshTokens().ByHash("1")
+	require.Nil(t, err)
+	require.NotNil(t, got.RevokedAt)
+	got, err = s.RefreshTokens().ByHash("2")
+	require.Nil(t, err)
+	assert.Equal(t, now, *got.RevokedAt)
+}
+
+func TestBooksFind(t *testing.T) {
+	s := New()
+	for i, title := range []string{"Go", "Gorm", "Rust", "Godot"} {
+		b := book.Book{Title: title, Author: "Author", CallNumber: i + 1, PersonID: i % 2}
+		require.Nil(t, s.Books().Save(&b))
+	}
+	sort, err := paging.ParseSort("-title", book.Sortable)
+	require.Nil(t, err)
+
+	p := paging.Params{Limit: 2, Sort: sort, WithTotal: true}
+	books, total, err := s.Books().Find(store.BookFilter{Title: "go"}, p)
+	require.Nil(t, err)
+	require.NotNil(t, total)
+	assert.Equal(t, int64(3), *total)
+	require.Len(t, books, 3)
+	assert.Equal(t, "Gorm", books[0].Title)
+	assert.Equal(t, "Godot", books[1].Title)
+
+	personID := uint(1)
+	books, _, err = s.Books().Find(store.BookFilter{PersonID: &personID}, p)
+	require.Nil(t, err)
+	assert.Len(t, books, 2)
+
+	books, err = s.Books().FindByPersonID(0)
+	require.Nil(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, "Go", books[0].Title)
+}
+
+func TestBooksSearch(t *testing.T) {
+	s := New()
+	for i, b := range []book.Book{
+		{Title: "Babička", Author: "Božena Němcová"},
+		{Title: "Válka s mloky", Author: "Karel Čapek"},
+		{Title: "Bílá nemoc", Author: "Karel Čapek"},
+		{Title: "Karel a jeho babička", Author: "Anonym"},
+	} {
+		b.CallNumber = i + 1
+		require.Nil(t, s.Books().Save(&b))
+	}
+	require.Nil(t, s.Books().Delete(2))
+
+	q, err := book.ParseQuery("karel")
+	require.Nil(t, err)
+	matches, total, err := s.Books().Search(q, paging.Params{Limit: 10, WithTotal: true})
+	require.Nil(t, err)
+	require.NotNil(t, total)
+	assert.Equal(t, int64(2), *total)
+	require.Len(t, matches, 2)
+	// the title match ranks above the author match
+	assert.Equal(t, uint(4), matches[0].Book.ID)
+	assert.Equal(t, uint(3), matches[1].Book.ID)
+	assert.Equal(t, "<mark>Karel</mark> Čapek", matches[1].Author)
+
+	q, err = book.ParseQuery("BABI")
+	require.Nil(t, err)
+	matches, _, err = s.Books().Search(q, paging.Params{Limit: 1, Offset: 1})
+	require.Nil(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, uint(4), matches[0].Book.ID)
+}
+
+func TestTransaction(t *testing.T) {
+	s := New()
+	jack := person.Person{Name: "Jack", Email: "jack@gmail.com"}
+	require.Nil(t, s.People().Save(&jack))
+
+	failed := errors.New("failed")
+	err := s.Transaction(func(tx store.Store) error {
+		p, errSet := tx.People().Lock(jack.ID)
+		if errSet != nil {
+			return errSet
+		}
+		p.Name = "John"
+		if errSet := tx.People().Save(&p); errSet != nil {
+			return errSet
+		}
+		got, _ := tx.People().Get(jack.ID)
+		assert.Equal(t, "John", got.Name)
+		return failed
+	})
+	assert.Equal(t, failed, err)
+	got, _ := s.People().Get(jack.ID)
+	assert.Equal(t, "Jack", got.Name)
+
+	err = s.Transaction(func(tx store.Store) error {
+		p, _ := tx.People().Lock(jack.ID)
+		p.Name = "John"
+		if errSet := tx.People().Save(&p); errSet != nil {
+			return errSet
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	got, _ = s.People().Get(jack.ID)
+	assert.Equal(t, "John", got.Name)
+}
+
+func TestNestedTransaction(t *testing.T) {
+	s := New()
+	failed := errors.New("failed")
+	err := s.Transaction(func(tx store.Store) error {
+		jack := person.Person{Name: "Jack", Email: "jack@gmail.com"}
+		if errSet := tx.People().Save(&jack); errSet != nil {
+			return errSet
+		}
+		err := tx.Transaction(func(tx store.Store) error {
+			jane := person.Person{Name: "Jane", Email: "jane@gmail.com"}
+			if errSet := tx.People().Save(&jane); errSet != nil {
+				return errSet
+			}
+			return failed
+		})
+		assert.Equal(t, failed, err)
+		return tx.Transaction(func(tx store.Store) error {
+			john := person.Person{Name: "John", Email: "john@gmail.com"}
+			if errSet := tx.People().Save(&john); errSet != nil {
+				return errSet
+			}
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	people, _, errSet := s.People().Find(store.PersonFilter{}, paging.Params{Limit: 10, Sort: paging.Sort{{Name: "id", Column: "id"}}})
+	require.Nil(t, errSet)
+	require.Len(t, people, 2)
+	assert.Equal(t, "Jack", people[0].Name)
+	assert.Equal(t, "John", people[1].Name)
+}
+
+func TestTransactionConcurrent(t *testing.T) {
+	s := New()
+	b := book.Book{Title: "Go", CallNumber: 1}
+	require.Nil(t, s.Books().Save(&b))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = s.Transaction(func(tx store.Store) error {
+				got, errSet := tx.Books().Lock(b.ID)
+				if errSet != nil {
+					return errSet
+				}
+				got.CallNumber++
+				if errSet := tx.Books().Save(&got); errSet != nil {
+					return errSet
+				}
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+	got, err := s.Books().Get(b.ID)
+	require.Nil(t, err)
+	assert.Equal(t, 51, got.CallNumber)
+}
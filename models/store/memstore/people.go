@@ -0,0 +1,194 @@
+package memstore
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type people struct {
+	s *Store
+}
+
+func (r people) Find(f store.PersonFilter, p paging.Params) ([]person.Person, *int64, *errdef.Error) {
+	var (
+		results []person.Person
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []person.Person
+		for _, row := range t.people {
+			if matchPerson(f, row) {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r people) Each(f store.PersonFilter, fn func(p person.Person) *errdef.Error) *errdef.Error {
+	var rows []person.Person
+	r.s.read(func(t *tables) {
+		for _, row := range t.people {
+			if matchPerson(f, row) {
+				rows = append(rows, row)
+			}
+		}
+	})
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+	for _, row := range rows {
+		if errSet := fn(row); errSet != nil {
+			return errSet
+		}
+	}
+	return nil
+}
+
+// matchPerson tells if the row is not deleted and matches the filter.
+func matchPerson(f store.PersonFilter, row person.Person) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if f.Name != "" && !strings.Contains(strings.ToLower(row.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.Email != "" && !strings.EqualFold(row.Email, f.Email) {
+		return false
+	}
+	return true
+}
+
+func (r people) Get(id uint) (person.Person, *errdef.Error) {
+	var (
+		row person.Person
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.people[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return person.Person{}, errdef.ErrNotFoundf("person %d not found", id).WithProcess(person.ProcessName)
+	}
+	return row, nil
+}
+
+// Lock is the same as Get, transactions don't run concurrently.
+func (r people) Lock(id uint) (person.Person, *errdef.Error) {
+	return r.Get(id)
+}
+
+func (r people) Save(p *person.Person) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.people {
+			if row.ID != p.ID && row.Email == p.Email {
+				return errdef.ErrAlreadyExistsf("person with email %s already exists", p.Email).WithMeta("field", "email")
+			}
+		}
+		t.stamp("people", &p.Model)
+		row := *p
+		row.Books = nil
+		t.people[row.ID] = row
+		return nil
+	})
+}
+
+func (r people) Delete(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.people[id]
+		if !ok || row.DeletedAt != nil {
+			return errdef.ErrNotFoundf("person %d not found", id).WithProcess(person.ProcessName)
+		}
+		softDelete(&row.Model)
+		t.people[id] = row
+		return nil
+	})
+}
+
+func (r people) Trash(p paging.Params) ([]person.Person, *int64, *errdef.Error) {
+	var (
+		results []person.Person
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []person.Person
+		for _, row := range t.people {
+			if row.DeletedAt != nil {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+func (r people) GetDeleted(id uint) (person.Person, *errdef.Error) {
+	var (
+		row person.Person
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.people[id]
+	})
+	if !ok || row.DeletedAt == nil {
+		return person.Person{}, errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+	}
+	return row, nil
+}
+
+func (r people) Restore(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.people[id]
+		if !ok || row.DeletedAt == nil {
+			return errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+		}
+		row.DeletedAt = nil
+		t.stamp("people", &row.Model)
+		t.people[id] = row
+		return nil
+	})
+}
+
+func (r people) Purge(id uint) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.people[id]
+		if !ok || row.DeletedAt == nil {
+			return errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+		}
+		delete(t.people, id)
+		return nil
+	})
+}
+
+func (r people) PurgeDeletedBefore(before time.Time) (int64, *errdef.Error) {
+	var n int64
+	errSet := r.s.write(func(t *tables) *errdef.Error {
+		for id, row := range t.people {
+			if row.DeletedAt != nil && row.DeletedAt.Before(before) {
+				delete(t.people, id)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, errSet
+}
@@ -0,0 +1,82 @@
+package memstore
+
+import (
+	"time"
+
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type refreshTokens struct {
+	s *Store
+}
+
+func (r refreshTokens) Create(rt *user.RefreshToken) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.refresh {
+			if row.Hash == rt.Hash {
+				return errdef.ErrAlreadyExists("refresh token already exists").WithProcess(user.ProcessName)
+			}
+		}
+		t.lastID["refresh_tokens"]++
+		rt.ID = t.lastID["refresh_tokens"]
+		rt.CreatedAt = time.Now().UTC()
+		t.refresh[rt.ID] = *rt
+		return nil
+	})
+}
+
+func (r refreshTokens) ByHash(hash string) (user.RefreshToken, *errdef.Error) {
+	var (
+		found user.RefreshToken
+		ok    bool
+	)
+	r.s.read(func(t *tables) {
+		for _, row := range t.refresh {
+			if row.Hash == hash {
+				found, ok = row, true
+				return
+			}
+		}
+	})
+	if !ok {
+		return user.RefreshToken{}, errdef.ErrNotFound("refresh token not found").WithProcess(user.ProcessName)
+	}
+	return found, nil
+}
+
+func (r refreshTokens) Rotate(id uint, at time.Time) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.refresh[id]
+		if !ok {
+			return errdef.ErrNotFoundf("refresh token %d not found", id).WithProcess(user.ProcessName)
+		}
+		if row.RotatedAt != nil || row.RevokedAt != nil {
+			return errdef.ErrFailedPrecondition("refresh token was already used").WithProcess(user.ProcessName)
+		}
+		row.RotatedAt = &at
+		t.refresh[id] = row
+		return nil
+	})
+}
+
+func (r refreshTokens) RevokeFamily(family string, at time.Time) *errdef.Error {
+	return r.revoke(func(row user.RefreshToken) bool { return row.Family == family }, at)
+}
+
+func (r refreshTokens) RevokeUser(userID uint, at time.Time) *errdef.Error {
+	return r.revoke(func(row user.RefreshToken) bool { return row.UserID == userID }, at)
+}
+
+// revoke revokes the tokens which match and are not revoked yet.
+func (r refreshTokens) revoke(match func(user.RefreshToken) bool, at time.Time) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for id, row := range t.refresh {
+			if row.RevokedAt == nil && match(row) {
+				row.RevokedAt = &at
+				t.refresh[id] = row
+			}
+		}
+		return nil
+	})
+}
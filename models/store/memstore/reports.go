@@ -0,0 +1,172 @@
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type reports struct {
+	s *Store
+}
+
+func (r reports) Loans(f store.ReportFilter, g report.Group, now time.Time) ([]report.LoanCount, *errdef.Error) {
+	counts := map[string]*report.LoanCount{}
+	r.s.read(func(t *tables) {
+		for _, row := range t.loans {
+			if !t.reported(f, row) {
+				continue
+			}
+			for _, key := range t.groupKeys(g, row) {
+				c, ok := counts[key]
+				if !ok {
+					c = &report.LoanCount{Group: key}
+					counts[key] = c
+				}
+				c.Loans++
+				if (row.ReturnedAt != nil && row.ReturnedAt.After(row.DueAt)) || row.Overdue(now) {
+					c.Overdue++
+				}
+			}
+		}
+	})
+	results := make([]report.LoanCount, 0, len(counts))
+	for _, c := range counts {
+		c.Rate()
+		results = append(results, *c)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Group < results[j].Group
+	})
+	return results, nil
+}
+
+func (r reports) MostBorrowed(f store.ReportFilter, limit int) ([]report.BookCount, *errdef.Error) {
+	var results []report.BookCount
+	r.s.read(func(t *tables) {
+		for bookID, n := range t.countLoans(f, func(l loan.Loan) uint { return l.BookID }) {
+			results = append(results, report.BookCount{BookID: bookID, Title: t.books[bookID].Title, Loans: n})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Loans != results[j].Loans {
+			return results[i].Loans > results[j].Loans
+		}
+		return results[i].BookID < results[j].BookID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (r reports) ActivePatrons(f store.ReportFilter, limit int) ([]report.PersonCount, *errdef.Error) {
+	var results []report.PersonCount
+	r.s.read(func(t *tables) {
+		for personID, n := range t.countLoans(f, func(l loan.Loan) uint { return l.PersonID }) {
+			results = append(results, report.PersonCount{PersonID: personID, Name: t.people[personID].Name, Loans: n})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Loans != results[j].Loans {
+			return results[i].Loans > results[j].Loans
+		}
+		return results[i].PersonID < results[j].PersonID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (r reports) NeverBorrowed(f store.ReportFilter, limit int) ([]report.BookCount, *errdef.Error) {
+	var results []report.BookCount
+	r.s.read(func(t *tables) {
+		borrowed := t.countLoans(f, func(l loan.Loan) uint { return l.BookID })
+		for _, row := range t.books {
+			if row.DeletedAt != nil || borrowed[row.ID] > 0 {
+				continue
+			}
+			if !f.To.IsZero() && !row.CreatedAt.Before(f.To) {
+				continue
+			}
+			if f.BranchID != 0 && !t.ownedBy(row.ID, f.BranchID) {
+				continue
+			}
+			results = append(results, report.BookCount{BookID: row.ID, Title: row.Title})
+		}
+	})
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].BookID < results[j].BookID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// reported tells if the loan is not deleted and matches the filter.
+func (t *tables) reported(f store.ReportFilter, row loan.Loan) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if !f.From.IsZero() && row.CheckedOutAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !row.CheckedOutAt.Before(f.To) {
+		return false
+	}
+	if f.BranchID != 0 && t.copies[row.CopyID].HomeBranchID != f.BranchID {
+		return false
+	}
+	return true
+}
+
+// countLoans counts the reported loans by the key.
+func (t *tables) countLoans(f store.ReportFilter, key func(l loan.Loan) uint) map[uint]int64 {
+	counts := map[uint]int64{}
+	for _, row := range t.loans {
+		if t.reported(f, row) {
+			counts[key(row)]++
+		}
+	}
+	return counts
+}
+
+// groupKeys returns the groups the loan counts in.
+func (t *tables) groupKeys(g report.Group, row loan.Loan) []string {
+	switch g {
+	case report.Branch:
+		return []string{t.branches[t.copies[row.CopyID].HomeBranchID].Code}
+	case report.Author:
+		var keys []string
+		seen := map[uint]bool{}
+		for _, c := range t.credits[row.BookID] {
+			if !seen[c.AuthorID] {
+				seen[c.AuthorID] = true
+				keys = append(keys, t.authors[c.AuthorID].Name)
+			}
+		}
+		if len(keys) == 0 {
+			return []string{""}
+		}
+		return keys
+	default:
+		return []string{g.Period(row.CheckedOutAt)}
+	}
+}
+
+// ownedBy tells if the book has a copy, which is not deleted, whose
+// home branch it is.
+func (t *tables) ownedBy(bookID, branchID uint) bool {
+	for _, c := range t.copies {
+		if c.DeletedAt == nil && c.BookID == bookID && c.HomeBranchID == branchID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package memstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/store"
+)
+
+var jan = time.Date(2020, 1, 30, 10, 0, 0, 0, time.UTC)
+
+// circulation stores two branches with a copy of Go each, a copy of Rust
+// at the second branch and Zig with no copies. Jack borrowed Go from
+// both branches and Rust, late, Jane borrowed Go in February and still has it.
+func circulation(t *testing.T) *Store {
+	s := New()
+	for _, code := range []string{"MAIN", "NORTH"} {
+		b := branch.Branch{Code: code, Name: code}
+		require.Nil(t, s.Branches().Save(&b))
+	}
+	for _, name := range []string{"Jack", "Jane"} {
+		p := person.Person{Name: name, Email: name + "@gmail.com"}
+		require.Nil(t, s.People().Save(&p))
+	}
+	for i, title := range []string{"Go", "Rust", "Zig"} {
+		b := book.Book{Title: title, CallNumber: i + 1}
+		require.Nil(t, s.Books().Save(&b))
+	}
+	for _, name := range []string{"Pike", "Thompson"} {
+		a := author.Author{Name: name}
+		require.Nil(t, s.Authors().Save(&a))
+	}
+	require.Nil(t, s.Authors().SetCredits(1, []author.Credit{
+		{BookID: 1, AuthorID: 1, Role: author.Writer, Position: 1},
+		{BookID: 1, AuthorID: 2, Role: author.Writer, Position: 2},
+		{BookID: 1, AuthorID: 2, Role: author.Editor, Position: 3},
+	}))
+	for _, c := range []item.Copy{
+		{BookID: 1, Barcode: "M1", HomeBranchID: 1},
+		{BookID: 1, Barcode: "N1", HomeBranchID: 2},
+		{BookID: 2, Barcode: "N2", HomeBranchID: 2},
+	} {
+		require.Nil(t, s.Copies().Save(&c))
+	}
+	returned := func(l loan.Loan, after time.Duration) loan.Loan {
+		at := l.CheckedOutAt.Add(after)
+		l.ReturnedAt = &at
+		return l
+	}
+	day := 24 * time.Hour
+	for _, l := range []loan.Loan{
+		returned(loan.New(1, 1, 1, loan.DefaultPolicy, jan), day),
+		returned(loan.New(1, 1, 2, loan.DefaultPolicy, jan.Add(day)), day),
+		returned(loan.New(1, 2, 3, loan.DefaultPolicy, jan.Add(-day)), 30*day),
+		loan.New(2, 1, 1, loan.DefaultPolicy, jan.AddDate(0, 0, 5)),
+	} {
+		require.Nil(t, s.Loans().Save(&l))
+	}
+	return s
+}
+
+func TestReportsLoans(t *testing.T) {
+	s := circulation(t)
+	now := jan.AddDate(0, 1, 0)
+
+	counts, err := s.Reports().Loans(store.ReportFilter{}, report.Month, now)
+	require.Nil(t, err)
+	assert.Equal(t, []report.LoanCount{
+		{Group: "2020-01", Loans: 3, Overdue: 1, OverdueRate: 1.0 / 3},
+		{Group: "2020-02", Loans: 1, Overdue: 1, OverdueRate: 1},
+	}, counts)
+
+	counts, err = s.Reports().Loans(store.ReportFilter{From: jan, To: jan.AddDate(0, 0, 5)}, report.Week, now)
+	require.Nil(t, err)
+	assert.Equal(t, []report.LoanCount{{Group: "2020-01-27", Loans: 2}}, counts)
+
+	counts, err = s.Reports().Loans(store.ReportFilter{}, report.Branch, jan)
+	require.Nil(t, err)
+	assert.Equal(t, []report.LoanCount{
+		{Group: "MAIN", Loans: 2},
+		{Group: "NORTH", Loans: 2, Overdue: 1, OverdueRate: 0.5},
+	}, counts)
+
+	counts, err = s.Reports().Loans(store.ReportFilter{BranchID: 1}, report.Author, jan)
+	require.Nil(t, err)
+	assert.Equal(t, []report.LoanCount{{Group: "Pike", Loans: 2}, {Group: "Thompson", Loans: 2}}, counts, "authors in several roles count once")
+}
+
+func TestReportsRankings(t *testing.T) {
+	s := circulation(t)
+
+	books, err := s.Reports().MostBorrowed(store.ReportFilter{}, 10)
+	require.Nil(t, err)
+	assert.Equal(t, []report.BookCount{{BookID: 1, Title: "Go", Loans: 3}, {BookID: 2, Title: "Rust", Loans: 1}}, books)
+	books, err = s.Reports().MostBorrowed(store.ReportFilter{BranchID: 2}, 1)
+	require.Nil(t, err)
+	assert.Equal(t, []report.BookCount{{BookID: 1, Title: "Go", Loans: 1}}, books, "ties in ID order")
+
+	people, err := s.Reports().ActivePatrons(store.ReportFilter{From: jan}, 10)
+	require.Nil(t, err)
+	assert.Equal(t, []report.PersonCount{{PersonID: 1, Name: "Jack", Loans: 2}, {PersonID: 2, Name: "Jane", Loans: 1}}, people)
+
+	books, err = s.Reports().NeverBorrowed(store.ReportFilter{}, 10)
+	require.Nil(t, err)
+	assert.Equal(t, []report.BookCount{{BookID: 3, Title: "Zig"}}, books)
+	books, err = s.Reports().NeverBorrowed(store.ReportFilter{From: jan, BranchID: 2}, 10)
+	require.Nil(t, err)
+	assert.Equal(t, []report.BookCount{{BookID: 2, Title: "Rust"}}, books, "only books with a copy at the branch")
+	books, err = s.Reports().NeverBorrowed(store.ReportFilter{To: jan.Add(-time.Hour)}, 10)
+	require.Nil(t, err)
+	assert.Empty(t, books, "books were added later")
+}
@@ -0,0 +1,94 @@
+package memstore
+
+import (
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type transfers struct {
+	s *Store
+}
+
+func (r transfers) Find(f store.TransferFilter, p paging.Params) ([]branch.Transfer, *int64, *errdef.Error) {
+	var (
+		results []branch.Transfer
+		total   *int64
+	)
+	r.s.read(func(t *tables) {
+		var rows []branch.Transfer
+		for _, row := range t.transfers {
+			if matchTransfer(f, row) {
+				rows = append(rows, row)
+			}
+		}
+		var idx []int
+		idx, total = paging.Slice(p, len(rows), func(i int) []interface{} {
+			return rows[i].SortValues(p.Sort)
+		})
+		for _, i := range idx {
+			results = append(results, rows[i])
+		}
+	})
+	return results, total, nil
+}
+
+// matchTransfer tells if the row is not deleted and matches the filter.
+func matchTransfer(f store.TransferFilter, row branch.Transfer) bool {
+	if row.DeletedAt != nil {
+		return false
+	}
+	if f.CopyID != 0 && row.CopyID != f.CopyID {
+		return false
+	}
+	if f.BranchID != 0 && row.FromBranchID != f.BranchID && row.ToBranchID != f.BranchID {
+		return false
+	}
+	if f.HoldID != 0 && (row.HoldID == nil || *row.HoldID != f.HoldID) {
+		return false
+	}
+	if f.Status != nil && row.Status != *f.Status {
+		return false
+	}
+	if f.Open != nil && row.Open() != *f.Open {
+		return false
+	}
+	return true
+}
+
+func (r transfers) Get(id uint) (branch.Transfer, *errdef.Error) {
+	var (
+		row branch.Transfer
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.transfers[id]
+	})
+	if !ok || row.DeletedAt != nil {
+		return branch.Transfer{}, errdef.ErrNotFoundf("transfer %d not found", id).WithProcess(branch.TransferProcessName)
+	}
+	return row, nil
+}
+
+func (r transfers) Open(copyID uint) (*branch.Transfer, *errdef.Error) {
+	var found *branch.Transfer
+	r.s.read(func(t *tables) {
+		for _, row := range t.transfers {
+			if row.DeletedAt == nil && row.CopyID == copyID && row.Open() {
+				row := row
+				found = &row
+				return
+			}
+		}
+	})
+	return found, nil
+}
+
+func (r transfers) Save(tr *branch.Transfer) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		t.stamp("transfers", &tr.Model)
+		t.transfers[tr.ID] = *tr
+		return nil
+	})
+}
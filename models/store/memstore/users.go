@@ -0,0 +1,125 @@
+package memstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/models/user/contact"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type users struct {
+	s *Store
+}
+
+func (r users) Get(id uint) (user.User, *errdef.Error) {
+	var (
+		row user.User
+		ok  bool
+	)
+	r.s.read(func(t *tables) {
+		row, ok = t.users[id]
+	})
+	if !ok {
+		return user.User{}, errdef.ErrNotFoundf("user %d not found", id).WithProcess(user.ProcessName)
+	}
+	return row, nil
+}
+
+func (r users) ByLogin(login string) (user.User, *errdef.Error) {
+	var (
+		found user.User
+		ok    bool
+	)
+	login = strings.ToLower(strings.TrimSpace(login))
+	r.s.read(func(t *tables) {
+		for _, row := range t.users {
+			if strings.ToLower(row.Username) == login {
+				found, ok = row, true
+				return
+			}
+			for _, c := range row.Contacts {
+				if c.Channel == contact.Email && c.Verified && strings.ToLower(c.Contact) == login {
+					found, ok = row, true
+					return
+				}
+			}
+		}
+	})
+	if !ok {
+		return user.User{}, errdef.ErrNotFound("user not found").WithProcess(user.ProcessName)
+	}
+	return found, nil
+}
+
+func (r users) Save(u *user.User) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		for _, row := range t.users {
+			if row.ID == u.ID {
+				continue
+			}
+			if strings.EqualFold(row.Username, u.Username) {
+				return errdef.ErrAlreadyExistsf("user %s already exists", u.Username).WithMeta("field", "username")
+			}
+			if u.ID != 0 {
+				continue
+			}
+			for _, c := range row.Contacts {
+				for _, nc := range u.Contacts {
+					if c.Channel == nc.Channel && c.Contact == nc.Contact {
+						return errdef.ErrAlreadyExistsf("contact %s %s already exists", nc.Channel, nc.Contact).WithMeta("field", "contacts")
+					}
+				}
+			}
+		}
+		now := time.Now().UTC()
+		if u.ID == 0 {
+			t.lastID["users"]++
+			u.ID = t.lastID["users"]
+			u.CreatedAt = now
+			contacts := make(contact.Contacts, len(u.Contacts))
+			for i, c := range u.Contacts {
+				t.lastID["user_contact"]++
+				c.ID = t.lastID["user_contact"]
+				c.UserID = u.ID
+				c.CreatedAt, c.UpdatedAt = now, now
+				contacts[i] = c
+			}
+			u.Contacts = contacts
+		} else {
+			old, ok := t.users[u.ID]
+			if !ok {
+				return errdef.ErrNotFoundf("user %d not found", u.ID).WithProcess(user.ProcessName)
+			}
+			u.Contacts = old.Contacts
+		}
+		u.UpdatedAt = now
+		t.users[u.ID] = *u
+		return nil
+	})
+}
+
+func (r users) SignedIn(id uint, at time.Time) *errdef.Error {
+	return r.s.write(func(t *tables) *errdef.Error {
+		row, ok := t.users[id]
+		if !ok {
+			return errdef.ErrNotFoundf("user %d not found", id).WithProcess(user.ProcessName)
+		}
+		row.LastSignedAt = &at
+		t.users[id] = row
+		return nil
+	})
+}
+
+func (r users) CountRole(role user.Role) (int, *errdef.Error) {
+	var n int
+	r.s.read(func(t *tables) {
+		for _, row := range t.users {
+			if row.Role == role {
+				n++
+			}
+		}
+	})
+	return n, nil
+}
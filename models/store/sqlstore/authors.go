@@ -0,0 +1,124 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type authors struct {
+	db *gorm.DB
+}
+
+func (r authors) Find(f store.AuthorFilter, p paging.Params) ([]author.Author, *int64, *errdef.Error) {
+	query := r.db.Model(&author.Author{})
+	if f.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+likeEscape(f.Name)+"%")
+	}
+	var results []author.Author
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find authors")
+	}
+	return results, total, nil
+}
+
+func (r authors) Get(id uint) (author.Author, *errdef.Error) {
+	return r.first(r.db, id)
+}
+
+func (r authors) Lock(id uint) (author.Author, *errdef.Error) {
+	return r.first(forUpdate(r.db), id)
+}
+
+func (r authors) first(db *gorm.DB, id uint) (author.Author, *errdef.Error) {
+	var a author.Author
+	err := db.First(&a, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return a, errdef.ErrNotFoundf("author %d not found", id).WithProcess(author.ProcessName)
+	}
+	if err != nil {
+		return a, errdef.Wrap(err, errdef.CodeInternal, "failed to load author")
+	}
+	return a, nil
+}
+
+func (r authors) Save(a *author.Author) *errdef.Error {
+	if err := r.db.Save(a).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save author")
+	}
+	return nil
+}
+
+func (r authors) Delete(id uint) *errdef.Error {
+	res := r.db.Delete(&author.Author{}, id)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to delete author")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("author %d not found", id).WithProcess(author.ProcessName)
+	}
+	return nil
+}
+
+func (r authors) Credits(bookIDs ...uint) (map[uint][]author.Credit, *errdef.Error) {
+	results := make(map[uint][]author.Credit, len(bookIDs))
+	if len(bookIDs) == 0 {
+		return results, nil
+	}
+	var rows []struct {
+		BookID   uint
+		AuthorID uint
+		Role     author.Role
+		Position int
+		Name     string
+	}
+	err := r.db.Table("book_authors").
+		Select("book_authors.*, authors.name").
+		Joins("JOIN authors ON authors.id = book_authors.author_id").
+		Where("book_authors.book_id IN (?)", bookIDs).
+		Order("book_authors.book_id, book_authors.position").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load credits")
+	}
+	for _, row := range rows {
+		results[row.BookID] = append(results[row.BookID], author.Credit{
+			BookID:   row.BookID,
+			AuthorID: row.AuthorID,
+			Role:     row.Role,
+			Position: row.Position,
+			Name:     row.Name,
+		})
+	}
+	return results, nil
+}
+
+func (r authors) SetCredits(bookID uint, credits []author.Credit) *errdef.Error {
+	if err := r.db.Where("book_id = ?", bookID).Delete(&author.Credit{}).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to delete credits")
+	}
+	for _, c := range credits {
+		err := r.db.Exec("INSERT INTO book_authors (book_id, author_id, role, position) VALUES (?, ?, ?, ?)",
+			bookID, c.AuthorID, c.Role, c.Position).Error
+		if err != nil {
+			return errdef.Wrap(err, errdef.CodeInternal, "failed to save credits")
+		}
+	}
+	return nil
+}
+
+func (r authors) BookIDs(authorID uint) ([]uint, *errdef.Error) {
+	var ids []uint
+	err := r.db.Model(&author.Credit{}).
+		Where("author_id = ?", authorID).
+		Order("book_id").
+		Pluck("DISTINCT book_id", &ids).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find books of author")
+	}
+	return ids, nil
+}
@@ -0,0 +1,238 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type books struct {
+	db *gorm.DB
+}
+
+func (r books) Find(f store.BookFilter, p paging.Params) ([]book.Book, *int64, *errdef.Error) {
+	query := r.filter(f)
+	var results []book.Book
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find books")
+	}
+	return results, total, nil
+}
+
+// headlineOptions make ts_headline return the whole field.
+const headlineOptions = "HighlightAll=true, StartSel=" + book.HighlightStart + ", StopSel=" + book.HighlightStop
+
+func (r books) Search(q book.Query, p paging.Params) ([]book.Match, *int64, *errdef.Error) {
+	tsq := q.TSQuery()
+	// library_search is the text search configuration created by the
+	// book search migration, the simple one extended by unaccent.
+	query := r.db.Model(&book.Book{}).Where("search @@ to_tsquery('library_search', ?)", tsq)
+
+	var total *int64
+	if p.WithTotal {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to count books")
+		}
+		total = &count
+	}
+
+	var rows []struct {
+		book.Book
+		Rank        float64
+		TitleMatch  string
+		AuthorMatch string
+	}
+	err := query.
+		Select(`books.*,
+			ts_rank(search, to_tsquery('library_search', ?)) AS rank,
+			ts_headline('library_search', title, to_tsquery('library_search', ?), ?) AS title_match,
+			ts_headline('library_search', author, to_tsquery('library_search', ?), ?) AS author_match`,
+			tsq, tsq, headlineOptions, tsq, headlineOptions).
+		Order("rank DESC, id").
+		Limit(p.Limit + 1).
+		Offset(p.Offset).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to search books")
+	}
+	matches := make([]book.Match, len(rows))
+	for i, row := range rows {
+		matches[i] = book.Match{Book: row.Book, Rank: row.Rank, Title: row.TitleMatch, Author: row.AuthorMatch}
+	}
+	return matches, total, nil
+}
+
+func (r books) FindByPersonID(personID uint) ([]book.Book, *errdef.Error) {
+	var results []book.Book
+	if err := r.db.Where("person_id = ?", personID).Order("id").Find(&results).Error; err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find books")
+	}
+	return results, nil
+}
+
+func (r books) Each(f store.BookFilter, fn func(b book.Book) *errdef.Error) *errdef.Error {
+	var row book.Book
+	return each(r.filter(f), &row, func() *errdef.Error {
+		return fn(row)
+	})
+}
+
+// filter returns query of the books matching the filter.
+func (r books) filter(f store.BookFilter) *gorm.DB {
+	query := r.db.Model(&book.Book{})
+	if f.Author != "" {
+		query = query.Where("author ILIKE ?", "%"+likeEscape(f.Author)+"%")
+	}
+	if f.Title != "" {
+		query = query.Where("title ILIKE ?", likeEscape(f.Title)+"%")
+	}
+	if f.PersonID != nil {
+		query = query.Where("person_id = ?", *f.PersonID)
+	}
+	if f.CallNumber != 0 {
+		query = query.Where("call_number = ?", f.CallNumber)
+	}
+	if f.AuthorID != 0 {
+		query = query.Where("id IN (SELECT book_id FROM book_authors WHERE author_id = ?)", f.AuthorID)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("id IN (SELECT book_id FROM copies WHERE location_id = ? AND deleted_at IS NULL)", f.BranchID)
+	}
+	return query
+}
+
+func (r books) Get(id uint) (book.Book, *errdef.Error) {
+	return r.first(r.db, id)
+}
+
+func (r books) GetByISBN(isbn string) (book.Book, *errdef.Error) {
+	var b book.Book
+	err := r.db.Where("isbn = ?", isbn).First(&b).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, errdef.ErrNotFoundf("book with isbn %s not found", isbn).WithProcess(book.ProcessName)
+	}
+	if err != nil {
+		return b, errdef.Wrap(err, errdef.CodeInternal, "failed to load book")
+	}
+	return b, nil
+}
+
+func (r books) Lock(id uint) (book.Book, *errdef.Error) {
+	return r.first(forUpdate(r.db), id)
+}
+
+func (r books) first(db *gorm.DB, id uint) (book.Book, *errdef.Error) {
+	var b book.Book
+	err := db.First(&b, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, errdef.ErrNotFoundf("book %d not found", id).WithProcess(book.ProcessName)
+	}
+	if err != nil {
+		return b, errdef.Wrap(err, errdef.CodeInternal, "failed to load book")
+	}
+	return b, nil
+}
+
+func (r books) Save(b *book.Book) *errdef.Error {
+	var count int
+	err := r.db.Unscoped().Model(&book.Book{}).
+		Where("call_number = ? AND id <> ?", b.CallNumber, b.ID).
+		Count(&count).Error
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to check call number")
+	}
+	if count > 0 {
+		return errdef.ErrAlreadyExistsf("book with call number %d already exists", b.CallNumber).WithMeta("field", "call_number")
+	}
+	if b.ISBN != "" {
+		err = r.db.Unscoped().Model(&book.Book{}).
+			Where("isbn = ? AND id <> ?", b.ISBN, b.ID).
+			Count(&count).Error
+		if err != nil {
+			return errdef.Wrap(err, errdef.CodeInternal, "failed to check isbn")
+		}
+		if count > 0 {
+			return errdef.ErrAlreadyExistsf("book with isbn %s already exists", b.ISBN).WithMeta("field", "isbn")
+		}
+	}
+	err = r.db.Save(b).Error
+	if isUniqueViolation(err, "uix_books_isbn") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "book with isbn %s already exists", b.ISBN).WithMeta("field", "isbn")
+	}
+	if isUniqueViolation(err, "") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "book with call number %d already exists", b.CallNumber).WithMeta("field", "call_number")
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save book")
+	}
+	return nil
+}
+
+func (r books) Delete(id uint) *errdef.Error {
+	res := r.db.Delete(&book.Book{}, id)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to delete book")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("book %d not found", id).WithProcess(book.ProcessName)
+	}
+	return nil
+}
+
+func (r books) Trash(p paging.Params) ([]book.Book, *int64, *errdef.Error) {
+	var results []book.Book
+	total, err := trashPage(r.db, &book.Book{}, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find deleted books")
+	}
+	return results, total, nil
+}
+
+func (r books) GetDeleted(id uint) (book.Book, *errdef.Error) {
+	var b book.Book
+	err := deleted(r.db, &book.Book{}).First(&b, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+	}
+	if err != nil {
+		return b, errdef.Wrap(err, errdef.CodeInternal, "failed to load deleted book")
+	}
+	return b, nil
+}
+
+func (r books) Restore(id uint) *errdef.Error {
+	n, err := restore(r.db, &book.Book{}, id)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to restore book")
+	}
+	if n == 0 {
+		return errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+	}
+	return nil
+}
+
+func (r books) Purge(id uint) *errdef.Error {
+	n, err := purge(r.db, &book.Book{}, id)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to purge book")
+	}
+	if n == 0 {
+		return errdef.ErrNotFoundf("book %d not found in trash", id).WithProcess(book.ProcessName)
+	}
+	return nil
+}
+
+func (r books) PurgeDeletedBefore(t time.Time) (int64, *errdef.Error) {
+	n, err := purgeBefore(r.db, &book.Book{}, t)
+	if err != nil {
+		return 0, errdef.Wrap(err, errdef.CodeInternal, "failed to purge deleted books")
+	}
+	return n, nil
+}
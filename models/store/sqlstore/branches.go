@@ -0,0 +1,80 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type branches struct {
+	db *gorm.DB
+}
+
+func (r branches) Find(f store.BranchFilter, p paging.Params) ([]branch.Branch, *int64, *errdef.Error) {
+	query := r.db.Model(&branch.Branch{})
+	if f.Name != "" {
+		like := "%" + likeEscape(f.Name) + "%"
+		query = query.Where("name ILIKE ? OR code ILIKE ?", like, like)
+	}
+	var results []branch.Branch
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find branches")
+	}
+	return results, total, nil
+}
+
+func (r branches) Get(id uint) (branch.Branch, *errdef.Error) {
+	return r.first(r.db, id)
+}
+
+func (r branches) Lock(id uint) (branch.Branch, *errdef.Error) {
+	return r.first(forUpdate(r.db), id)
+}
+
+func (r branches) first(db *gorm.DB, id uint) (branch.Branch, *errdef.Error) {
+	var b branch.Branch
+	err := db.First(&b, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return b, errdef.ErrNotFoundf("branch %d not found", id).WithProcess(branch.ProcessName)
+	}
+	if err != nil {
+		return b, errdef.Wrap(err, errdef.CodeInternal, "failed to load branch")
+	}
+	return b, nil
+}
+
+func (r branches) Save(b *branch.Branch) *errdef.Error {
+	var count int
+	err := r.db.Unscoped().Model(&branch.Branch{}).
+		Where("code = ? AND id <> ?", b.Code, b.ID).
+		Count(&count).Error
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to check branch code")
+	}
+	if count > 0 {
+		return errdef.ErrAlreadyExistsf("branch with code %s already exists", b.Code).WithMeta("field", "code")
+	}
+	err = r.db.Save(b).Error
+	if isUniqueViolation(err, "") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "branch with code %s already exists", b.Code).WithMeta("field", "code")
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save branch")
+	}
+	return nil
+}
+
+func (r branches) Delete(id uint) *errdef.Error {
+	res := r.db.Delete(&branch.Branch{}, id)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to delete branch")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("branch %d not found", id).WithProcess(branch.ProcessName)
+	}
+	return nil
+}
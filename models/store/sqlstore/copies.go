@@ -0,0 +1,108 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type copies struct {
+	db *gorm.DB
+}
+
+func (r copies) Find(f store.CopyFilter, p paging.Params) ([]item.Copy, *int64, *errdef.Error) {
+	query := r.db.Model(&item.Copy{})
+	if f.BookID != 0 {
+		query = query.Where("book_id = ?", f.BookID)
+	}
+	if f.Status != nil {
+		query = query.Where("status = ?", *f.Status)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("location_id = ?", f.BranchID)
+	}
+	if f.HomeBranchID != 0 {
+		query = query.Where("home_branch_id = ?", f.HomeBranchID)
+	}
+	var results []item.Copy
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find copies")
+	}
+	return results, total, nil
+}
+
+func (r copies) OfBook(bookID uint) ([]item.Copy, *errdef.Error) {
+	var results []item.Copy
+	if err := r.db.Where("book_id = ?", bookID).Order("id").Find(&results).Error; err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find copies")
+	}
+	return results, nil
+}
+
+func (r copies) Get(id uint) (item.Copy, *errdef.Error) {
+	return r.first(r.db, id)
+}
+
+func (r copies) GetByBarcode(barcode string) (item.Copy, *errdef.Error) {
+	var c item.Copy
+	err := r.db.Where("barcode = ?", barcode).First(&c).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return c, errdef.ErrNotFoundf("copy with barcode %s not found", barcode).WithProcess(item.ProcessName)
+	}
+	if err != nil {
+		return c, errdef.Wrap(err, errdef.CodeInternal, "failed to load copy")
+	}
+	return c, nil
+}
+
+func (r copies) Lock(id uint) (item.Copy, *errdef.Error) {
+	return r.first(forUpdate(r.db), id)
+}
+
+func (r copies) first(db *gorm.DB, id uint) (item.Copy, *errdef.Error) {
+	var c item.Copy
+	err := db.First(&c, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return c, errdef.ErrNotFoundf("copy %d not found", id).WithProcess(item.ProcessName)
+	}
+	if err != nil {
+		return c, errdef.Wrap(err, errdef.CodeInternal, "failed to load copy")
+	}
+	return c, nil
+}
+
+func (r copies) Save(c *item.Copy) *errdef.Error {
+	var count int
+	err := r.db.Unscoped().Model(&item.Copy{}).
+		Where("barcode = ? AND id <> ?", c.Barcode, c.ID).
+		Count(&count).Error
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to check barcode")
+	}
+	if count > 0 {
+		return errdef.ErrAlreadyExistsf("copy with barcode %s already exists", c.Barcode).WithMeta("field", "barcode")
+	}
+	err = r.db.Save(c).Error
+	if isUniqueViolation(err, "") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "copy with barcode %s already exists", c.Barcode).WithMeta("field", "barcode")
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save copy")
+	}
+	return nil
+}
+
+func (r copies) Delete(id uint) *errdef.Error {
+	res := r.db.Delete(&item.Copy{}, id)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to delete copy")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("copy %d not found", id).WithProcess(item.ProcessName)
+	}
+	return nil
+}
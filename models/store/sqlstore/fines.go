@@ -0,0 +1,46 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type fines struct {
+	db *gorm.DB
+}
+
+func (r fines) Find(f store.FineFilter, p paging.Params) (fine.Ledger, *int64, *errdef.Error) {
+	query := r.db.Model(&fine.Entry{})
+	if f.PersonID != 0 {
+		query = query.Where("person_id = ?", f.PersonID)
+	}
+	if f.Kind != nil {
+		query = query.Where("kind = ?", *f.Kind)
+	}
+	var results fine.Ledger
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find fines")
+	}
+	return results, total, nil
+}
+
+func (r fines) Ledger(personID uint) (fine.Ledger, *errdef.Error) {
+	var ledger fine.Ledger
+	err := r.db.Where("person_id = ?", personID).Order("id").Find(&ledger).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load fines")
+	}
+	return ledger, nil
+}
+
+func (r fines) Save(e *fine.Entry) *errdef.Error {
+	if err := r.db.Save(e).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save ledger entry")
+	}
+	return nil
+}
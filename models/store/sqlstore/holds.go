@@ -0,0 +1,79 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type holds struct {
+	db *gorm.DB
+}
+
+func (r holds) Find(f store.HoldFilter, p paging.Params) (hold.Holds, *int64, *errdef.Error) {
+	query := r.db.Model(&hold.Hold{})
+	if f.PersonID != 0 {
+		query = query.Where("person_id = ?", f.PersonID)
+	}
+	if f.BookID != 0 {
+		query = query.Where("book_id = ?", f.BookID)
+	}
+	if f.Status != nil {
+		query = query.Where("status = ?", *f.Status)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("pickup_branch_id = ?", f.BranchID)
+	}
+	var results hold.Holds
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find holds")
+	}
+	return results, total, nil
+}
+
+func (r holds) Get(id uint) (hold.Hold, *errdef.Error) {
+	var h hold.Hold
+	err := r.db.First(&h, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return h, errdef.ErrNotFoundf("hold %d not found", id).WithProcess(hold.ProcessName)
+	}
+	if err != nil {
+		return h, errdef.Wrap(err, errdef.CodeInternal, "failed to load hold")
+	}
+	return h, nil
+}
+
+func (r holds) Open(bookID uint) (hold.Holds, *errdef.Error) {
+	var results hold.Holds
+	err := r.db.Where("book_id = ? AND status IN (?)", bookID, []hold.Status{hold.Waiting, hold.Ready}).
+		Order("placed_at, id").
+		Find(&results).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load holds")
+	}
+	return results, nil
+}
+
+func (r holds) ExpiredBookIDs(now time.Time) ([]uint, *errdef.Error) {
+	var bookIDs []uint
+	err := r.db.Model(&hold.Hold{}).
+		Where("status = ? AND expires_at < ?", hold.Ready, now).
+		Pluck("DISTINCT book_id", &bookIDs).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find expired holds")
+	}
+	return bookIDs, nil
+}
+
+func (r holds) Save(h *hold.Hold) *errdef.Error {
+	if err := r.db.Save(h).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save hold")
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type loans struct {
+	db *gorm.DB
+}
+
+func (r loans) Find(f store.LoanFilter, p paging.Params) (loan.Loans, *int64, *errdef.Error) {
+	query := r.filter(f)
+	var results loan.Loans
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find loans")
+	}
+	return results, total, nil
+}
+
+func (r loans) Each(f store.LoanFilter, fn func(l loan.Loan) *errdef.Error) *errdef.Error {
+	var row loan.Loan
+	return each(r.filter(f), &row, func() *errdef.Error {
+		return fn(row)
+	})
+}
+
+// filter returns query of the loans matching the filter.
+func (r loans) filter(f store.LoanFilter) *gorm.DB {
+	query := r.db.Model(&loan.Loan{})
+	if f.PersonID != 0 {
+		query = query.Where("person_id = ?", f.PersonID)
+	}
+	if f.BookID != 0 {
+		query = query.Where("book_id = ?", f.BookID)
+	}
+	if f.CopyID != 0 {
+		query = query.Where("copy_id = ?", f.CopyID)
+	}
+	if f.Active != nil {
+		if *f.Active {
+			query = query.Where("returned_at IS NULL")
+		} else {
+			query = query.Where("returned_at IS NOT NULL")
+		}
+	}
+	return query
+}
+
+func (r loans) Get(id uint) (loan.Loan, *errdef.Error) {
+	var l loan.Loan
+	err := r.db.First(&l, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return l, errdef.ErrNotFoundf("loan %d not found", id).WithProcess(loan.ProcessName)
+	}
+	if err != nil {
+		return l, errdef.Wrap(err, errdef.CodeInternal, "failed to load loan")
+	}
+	return l, nil
+}
+
+func (r loans) Active(copyID uint) (*loan.Loan, *errdef.Error) {
+	var l loan.Loan
+	err := r.db.Where("copy_id = ? AND returned_at IS NULL", copyID).First(&l).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to check copy loans")
+	}
+	return &l, nil
+}
+
+func (r loans) Save(l *loan.Loan) *errdef.Error {
+	if err := r.db.Save(l).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save loan")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type people struct {
+	db *gorm.DB
+}
+
+func (r people) Find(f store.PersonFilter, p paging.Params) ([]person.Person, *int64, *errdef.Error) {
+	query := r.filter(f)
+	var results []person.Person
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find people")
+	}
+	return results, total, nil
+}
+
+func (r people) Each(f store.PersonFilter, fn func(p person.Person) *errdef.Error) *errdef.Error {
+	var row person.Person
+	return each(r.filter(f), &row, func() *errdef.Error {
+		return fn(row)
+	})
+}
+
+// filter returns query of the people matching the filter.
+func (r people) filter(f store.PersonFilter) *gorm.DB {
+	query := r.db.Model(&person.Person{})
+	if f.Name != "" {
+		query = query.Where("name ILIKE ?", "%"+likeEscape(f.Name)+"%")
+	}
+	if f.Email != "" {
+		query = query.Where("LOWER(email) = ?", strings.ToLower(f.Email))
+	}
+	return query
+}
+
+func (r people) Get(id uint) (person.Person, *errdef.Error) {
+	return r.first(r.db, id)
+}
+
+func (r people) Lock(id uint) (person.Person, *errdef.Error) {
+	return r.first(forUpdate(r.db), id)
+}
+
+func (r people) first(db *gorm.DB, id uint) (person.Person, *errdef.Error) {
+	var p person.Person
+	err := db.First(&p, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return p, errdef.ErrNotFoundf("person %d not found", id).WithProcess(person.ProcessName)
+	}
+	if err != nil {
+		return p, errdef.Wrap(err, errdef.CodeInternal, "failed to load person")
+	}
+	return p, nil
+}
+
+func (r people) Save(p *person.Person) *errdef.Error {
+	var count int
+	err := r.db.Unscoped().Model(&person.Person{}).
+		Where("email = ? AND id <> ?", p.Email, p.ID).
+		Count(&count).Error
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to check email")
+	}
+	if count > 0 {
+		return errdef.ErrAlreadyExistsf("person with email %s already exists", p.Email).WithMeta("field", "email")
+	}
+	err = r.db.Save(p).Error
+	if isUniqueViolation(err, "") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "person with email %s already exists", p.Email).WithMeta("field", "email")
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save person")
+	}
+	return nil
+}
+
+func (r people) Delete(id uint) *errdef.Error {
+	res := r.db.Delete(&person.Person{}, id)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to delete person")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("person %d not found", id).WithProcess(person.ProcessName)
+	}
+	return nil
+}
+
+func (r people) Trash(p paging.Params) ([]person.Person, *int64, *errdef.Error) {
+	var results []person.Person
+	total, err := trashPage(r.db, &person.Person{}, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find deleted people")
+	}
+	return results, total, nil
+}
+
+func (r people) GetDeleted(id uint) (person.Person, *errdef.Error) {
+	var p person.Person
+	err := deleted(r.db, &person.Person{}).First(&p, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return p, errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+	}
+	if err != nil {
+		return p, errdef.Wrap(err, errdef.CodeInternal, "failed to load deleted person")
+	}
+	return p, nil
+}
+
+func (r people) Restore(id uint) *errdef.Error {
+	n, err := restore(r.db, &person.Person{}, id)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to restore person")
+	}
+	if n == 0 {
+		return errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+	}
+	return nil
+}
+
+func (r people) Purge(id uint) *errdef.Error {
+	n, err := purge(r.db, &person.Person{}, id)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to purge person")
+	}
+	if n == 0 {
+		return errdef.ErrNotFoundf("person %d not found in trash", id).WithProcess(person.ProcessName)
+	}
+	return nil
+}
+
+func (r people) PurgeDeletedBefore(t time.Time) (int64, *errdef.Error) {
+	n, err := purgeBefore(r.db, &person.Person{}, t)
+	if err != nil {
+		return 0, errdef.Wrap(err, errdef.CodeInternal, "failed to purge deleted people")
+	}
+	return n, nil
+}
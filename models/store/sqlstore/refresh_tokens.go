@@ -0,0 +1,67 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type refreshTokens struct {
+	db *gorm.DB
+}
+
+func (r refreshTokens) Create(rt *user.RefreshToken) *errdef.Error {
+	err := r.db.Create(rt).Error
+	if isUniqueViolation(err, "refresh_tokens_hash_key") {
+		return errdef.Wrap(err, errdef.CodeAlreadyExists, "refresh token already exists").WithProcess(user.ProcessName)
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to create refresh token")
+	}
+	return nil
+}
+
+func (r refreshTokens) ByHash(hash string) (user.RefreshToken, *errdef.Error) {
+	var rt user.RefreshToken
+	err := r.db.Where("hash = ?", hash).First(&rt).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return rt, errdef.ErrNotFound("refresh token not found").WithProcess(user.ProcessName)
+	}
+	if err != nil {
+		return rt, errdef.Wrap(err, errdef.CodeInternal, "failed to load refresh token")
+	}
+	return rt, nil
+}
+
+func (r refreshTokens) Rotate(id uint, at time.Time) *errdef.Error {
+	res := r.db.Model(&user.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		UpdateColumn("rotated_at", at)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to rotate refresh token")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrFailedPrecondition("refresh token was already used").WithProcess(user.ProcessName)
+	}
+	return nil
+}
+
+func (r refreshTokens) RevokeFamily(family string, at time.Time) *errdef.Error {
+	return r.revoke(r.db.Where("family = ?", family), at)
+}
+
+func (r refreshTokens) RevokeUser(userID uint, at time.Time) *errdef.Error {
+	return r.revoke(r.db.Where("user_id = ?", userID), at)
+}
+
+// revoke revokes the tokens matched by the query which are not revoked yet.
+func (r refreshTokens) revoke(query *gorm.DB, at time.Time) *errdef.Error {
+	err := query.Model(&user.RefreshToken{}).Where("revoked_at IS NULL").UpdateColumn("revoked_at", at).Error
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to revoke refresh tokens")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type reports struct {
+	db *gorm.DB
+}
+
+// periodFormats are to_char formats of the period groups, the periods
+// are truncated in UTC as report.Group.Period does.
+var periodFormats = map[report.Group]string{
+	report.Month: "YYYY-MM",
+	report.Week:  "YYYY-MM-DD",
+	report.Day:   "YYYY-MM-DD",
+}
+
+func (r reports) Loans(f store.ReportFilter, g report.Group, now time.Time) ([]report.LoanCount, *errdef.Error) {
+	query := r.filter(f)
+	var key string
+	switch g {
+	case report.Branch:
+		query = query.Joins("LEFT JOIN branches ON branches.id = copies.home_branch_id")
+		key = "COALESCE(branches.code, '')"
+	case report.Author:
+		query = query.
+			Joins("LEFT JOIN book_authors ON book_authors.book_id = loans.book_id").
+			Joins("LEFT JOIN authors ON authors.id = book_authors.author_id")
+		key = "COALESCE(authors.name, '')"
+	default:
+		key = "to_char(date_trunc('" + g.String() + "', loans.checked_out_at AT TIME ZONE 'UTC'), '" + periodFormats[g] + "')"
+	}
+	// an author credited in several roles counts once
+	var results []report.LoanCount
+	err := query.
+		Select(key+` AS "group", COUNT(DISTINCT loans.id) AS loans,
+			COUNT(DISTINCT loans.id) FILTER (WHERE loans.returned_at > loans.due_at
+				OR (loans.returned_at IS NULL AND loans.due_at < ?)) AS overdue`, now).
+		Group("1").
+		Order("1").
+		Scan(&results).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to count loans")
+	}
+	for i := range results {
+		results[i].Rate()
+	}
+	if results == nil {
+		results = []report.LoanCount{}
+	}
+	return results, nil
+}
+
+func (r reports) MostBorrowed(f store.ReportFilter, limit int) ([]report.BookCount, *errdef.Error) {
+	var results []report.BookCount
+	err := r.filter(f).
+		Joins("JOIN books ON books.id = loans.book_id").
+		Select("books.id AS book_id, books.title, COUNT(*) AS loans").
+		Group("books.id").
+		Order("loans DESC, books.id").
+		Limit(limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to count loans of books")
+	}
+	return results, nil
+}
+
+func (r reports) ActivePatrons(f store.ReportFilter, limit int) ([]report.PersonCount, *errdef.Error) {
+	var results []report.PersonCount
+	err := r.filter(f).
+		Joins("JOIN people ON people.id = loans.person_id").
+		Select("people.id AS person_id, people.name, COUNT(*) AS loans").
+		Group("people.id").
+		Order("loans DESC, people.id").
+		Limit(limit).
+		Scan(&results).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to count loans of people")
+	}
+	return results, nil
+}
+
+func (r reports) NeverBorrowed(f store.ReportFilter, limit int) ([]report.BookCount, *errdef.Error) {
+	borrowed := r.filter(f).Select("loans.book_id")
+	query := r.db.Table("books").
+		Select("books.id AS book_id, books.title").
+		Where("books.deleted_at IS NULL").
+		Where("books.id NOT IN (?)", borrowed.SubQuery())
+	if !f.To.IsZero() {
+		query = query.Where("books.created_at < ?", f.To)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("books.id IN (SELECT book_id FROM copies WHERE home_branch_id = ? AND deleted_at IS NULL)", f.BranchID)
+	}
+	var results []report.BookCount
+	err := query.Order("books.id").Limit(limit).Scan(&results).Error
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find books never borrowed")
+	}
+	return results, nil
+}
+
+// filter returns query of the loans matching the filter, joined with
+// their copies. Deleted copies are joined too, their loans still count.
+// Copies of purged books are gone, so the join is a left one.
+func (r reports) filter(f store.ReportFilter) *gorm.DB {
+	query := r.db.Table("loans").
+		Joins("LEFT JOIN copies ON copies.id = loans.copy_id").
+		Where("loans.deleted_at IS NULL")
+	if !f.From.IsZero() {
+		query = query.Where("loans.checked_out_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		query = query.Where("loans.checked_out_at < ?", f.To)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("copies.home_branch_id = ?", f.BranchID)
+	}
+	return query
+}
@@ -0,0 +1,177 @@
+// Package sqlstore implements store.Store on top of the database.
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Store is store.Store backed by the database.
+type Store struct {
+	db *gorm.DB
+	// depth is the number of transactions the store is bound to.
+	depth int
+}
+
+var _ store.Store = &Store{}
+
+// New creates store using the connection.
+func New(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+// People returns the repository of people.
+func (s *Store) People() store.People {
+	return people{db: s.db}
+}
+
+// Books returns the repository of books.
+func (s *Store) Books() store.Books {
+	return books{db: s.db}
+}
+
+// Authors returns the repository of authors.
+func (s *Store) Authors() store.Authors {
+	return authors{db: s.db}
+}
+
+// Branches returns the repository of branches.
+func (s *Store) Branches() store.Branches {
+	return branches{db: s.db}
+}
+
+// Copies returns the repository of copies.
+func (s *Store) Copies() store.Copies {
+	return copies{db: s.db}
+}
+
+// Transfers returns the repository of transfers.
+func (s *Store) Transfers() store.Transfers {
+	return transfers{db: s.db}
+}
+
+// Loans returns the repository of loans.
+func (s *Store) Loans() store.Loans {
+	return loans{db: s.db}
+}
+
+// Holds returns the repository of holds.
+func (s *Store) Holds() store.Holds {
+	return holds{db: s.db}
+}
+
+// Fines returns the repository of the fines ledger.
+func (s *Store) Fines() store.Fines {
+	return fines{db: s.db}
+}
+
+// Reports returns the circulation reports.
+func (s *Store) Reports() store.Reports {
+	return reports{db: s.db}
+}
+
+// Users returns the repository of user accounts.
+func (s *Store) Users() store.Users {
+	return users{db: s.db}
+}
+
+// RefreshTokens returns the repository of refresh tokens.
+func (s *Store) RefreshTokens() store.RefreshTokens {
+	return refreshTokens{db: s.db}
+}
+
+// Transaction runs fn inside of database transaction. Nested
+// transactions run inside of a savepoint, so their failure doesn't
+// abort the outer transaction.
+func (s *Store) Transaction(fn func(tx store.Store) error) error {
+	if s.depth == 0 {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			return fn(&Store{db: tx, depth: 1})
+		})
+	}
+	savepoint := fmt.Sprintf("sp%d", s.depth)
+	if err := s.db.Exec("SAVEPOINT " + savepoint).Error; err != nil {
+		return err
+	}
+	if err := fn(&Store{db: s.db, depth: s.depth + 1}); err != nil {
+		if rbErr := s.db.Exec("ROLLBACK TO SAVEPOINT " + savepoint).Error; rbErr != nil {
+			return rbErr
+		}
+		return err
+	}
+	return s.db.Exec("RELEASE SAVEPOINT " + savepoint).Error
+}
+
+// forUpdate makes the query lock selected rows until the transaction ends.
+func forUpdate(db *gorm.DB) *gorm.DB {
+	return db.Set("gorm:query_option", "FOR UPDATE")
+}
+
+// findPage loads one page of the filtered query into out. It fetches
+// one row more than the limit, so paging.NewPage can tell if there is a next page.
+// Total count is only returned when the client asked for it.
+func findPage(query *gorm.DB, params paging.Params, out interface{}) (*int64, error) {
+	var total *int64
+	if params.WithTotal {
+		var count int64
+		if err := query.Count(&count).Error; err != nil {
+			return nil, err
+		}
+		total = &count
+	}
+	if params.Cursor != nil {
+		cond, args := params.Sort.After(params.Cursor.Values)
+		query = query.Where(cond, args...)
+	}
+	err := query.
+		Order(params.Sort.OrderBy()).
+		Offset(params.Offset).
+		Limit(params.Limit + 1).
+		Find(out).Error
+	return total, err
+}
+
+// each streams rows of the query in ID order. Every row is scanned
+// into dest before fn is called.
+func each(query *gorm.DB, dest interface{}, fn func() *errdef.Error) *errdef.Error {
+	rows, err := query.Order("id").Rows()
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to query rows")
+	}
+	defer rows.Close()
+	v := reflect.ValueOf(dest).Elem()
+	for rows.Next() {
+		v.Set(reflect.Zero(v.Type()))
+		if err := query.ScanRows(rows, dest); err != nil {
+			return errdef.Wrap(err, errdef.CodeInternal, "failed to scan row")
+		}
+		if errSet := fn(); errSet != nil {
+			return errSet
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to read rows")
+	}
+	return nil
+}
+
+// likeEscape escapes LIKE wildcards in user input.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// isUniqueViolation tells you if the database refused the write
+// because of the unique index, any unique index if it is empty.
+func isUniqueViolation(err error, index string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && (index == "" || pqErr.Constraint == index)
+}
@@ -0,0 +1,71 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/migrations"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/migrate"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// errRollback rolls back the transaction of a test.
+var errRollback = errors.New("rollback")
+
+// newTestStore connects to the database of LIBRARY_TEST_DSN and
+// migrates it. Tests are skipped when the variable is not set.
+func newTestStore(t *testing.T) *Store {
+	dsn := os.Getenv("LIBRARY_TEST_DSN")
+	if dsn == "" {
+		t.Skip("LIBRARY_TEST_DSN is not set")
+	}
+	db, err := gorm.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	mm, errSet := migrate.Load(migrations.FS)
+	require.Nil(t, errSet)
+	_, errSet = migrate.New(db.DB(), mm).Up(context.Background(), 0)
+	require.Nil(t, errSet)
+	return New(db)
+}
+
+func TestPeopleTrashCursor(t *testing.T) {
+	s := newTestStore(t)
+	err := s.Transaction(func(tx store.Store) error {
+		for _, p := range []person.Person{{Name: "Jack", Email: "trash.jack@gmail.com"}, {Name: "Jill", Email: "trash.jill@gmail.com"}} {
+			require.Nil(t, tx.People().Save(&p))
+			require.Nil(t, tx.People().Delete(p.ID))
+		}
+
+		params, errSet := paging.Parse(url.Values{"limit": {"1"}}, person.TrashSortable, "-deleted_at")
+		require.Nil(t, errSet)
+		people, _, errSet := tx.People().Trash(params)
+		require.Nil(t, errSet)
+		page, n := paging.NewPage(params, len(people), func(i int) []interface{} {
+			return people[i].SortValues(params.Sort)
+		})
+		require.Equal(t, 1, n)
+		require.NotEmpty(t, page.NextCursor)
+		first := people[0]
+
+		params, errSet = paging.Parse(url.Values{"limit": {"1"}, "cursor": {page.NextCursor}}, person.TrashSortable, "-deleted_at")
+		require.Nil(t, errSet)
+		people, _, errSet = tx.People().Trash(params)
+		require.Nil(t, errSet)
+		require.NotEmpty(t, people, "second page is empty")
+		assert.NotEqual(t, first.ID, people[0].ID)
+		assert.False(t, people[0].DeletedAt.After(*first.DeletedAt))
+		return errRollback
+	})
+	assert.Equal(t, errRollback, err)
+}
@@ -0,0 +1,77 @@
+package sqlstore
+
+import (
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+type transfers struct {
+	db *gorm.DB
+}
+
+// openStatuses are statuses of transfers which have not ended yet.
+var openStatuses = []branch.Status{branch.Requested, branch.InTransit}
+
+func (r transfers) Find(f store.TransferFilter, p paging.Params) ([]branch.Transfer, *int64, *errdef.Error) {
+	query := r.db.Model(&branch.Transfer{})
+	if f.CopyID != 0 {
+		query = query.Where("copy_id = ?", f.CopyID)
+	}
+	if f.BranchID != 0 {
+		query = query.Where("from_branch_id = ? OR to_branch_id = ?", f.BranchID, f.BranchID)
+	}
+	if f.HoldID != 0 {
+		query = query.Where("hold_id = ?", f.HoldID)
+	}
+	if f.Status != nil {
+		query = query.Where("status = ?", *f.Status)
+	}
+	if f.Open != nil {
+		if *f.Open {
+			query = query.Where("status IN (?)", openStatuses)
+		} else {
+			query = query.Where("status NOT IN (?)", openStatuses)
+		}
+	}
+	var results []branch.Transfer
+	total, err := findPage(query, p, &results)
+	if err != nil {
+		return nil, nil, errdef.Wrap(err, errdef.CodeInternal, "failed to find transfers")
+	}
+	return results, total, nil
+}
+
+func (r transfers) Get(id uint) (branch.Transfer, *errdef.Error) {
+	var t branch.Transfer
+	err := r.db.First(&t, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return t, errdef.ErrNotFoundf("transfer %d not found", id).WithProcess(branch.TransferProcessName)
+	}
+	if err != nil {
+		return t, errdef.Wrap(err, errdef.CodeInternal, "failed to load transfer")
+	}
+	return t, nil
+}
+
+func (r transfers) Open(copyID uint) (*branch.Transfer, *errdef.Error) {
+	var t branch.Transfer
+	err := r.db.Where("copy_id = ? AND status IN (?)", copyID, openStatuses).First(&t).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to check copy transfers")
+	}
+	return &t, nil
+}
+
+func (r transfers) Save(t *branch.Transfer) *errdef.Error {
+	if err := r.db.Save(t).Error; err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save transfer")
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package sqlstore
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Soft deleted rows are in the trash. These helpers work with the trash
+// of the table of the model, which is a pointer to empty model.
+
+// deleted returns query of the rows in the trash.
+func deleted(db *gorm.DB, model interface{}) *gorm.DB {
+	return db.Unscoped().Model(model).Where("deleted_at IS NOT NULL")
+}
+
+// trashPage loads page of the rows in the trash into out.
+func trashPage(db *gorm.DB, model interface{}, p paging.Params, out interface{}) (*int64, error) {
+	return findPage(deleted(db, model), p, out)
+}
+
+// restore moves the row out of the trash and returns
+// number of restored rows.
+func restore(db *gorm.DB, model interface{}, id uint) (int64, error) {
+	res := deleted(db, model).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"deleted_at": gorm.Expr("NULL"),
+		"updated_at": time.Now().UTC(),
+	})
+	return res.RowsAffected, res.Error
+}
+
+// purge removes the row from the trash and returns
+// number of removed rows.
+func purge(db *gorm.DB, model interface{}, id uint) (int64, error) {
+	res := db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(model)
+	return res.RowsAffected, res.Error
+}
+
+// purgeBefore removes rows deleted before the time from the trash
+// and returns number of removed rows.
+func purgeBefore(db *gorm.DB, model interface{}, t time.Time) (int64, error) {
+	res := db.Unscoped().Where("deleted_at < ?", t).Delete(model)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,100 @@
+package sqlstore
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/models/user/contact"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type users struct {
+	db *gorm.DB
+}
+
+func (r users) Get(id uint) (user.User, *errdef.Error) {
+	var u user.User
+	err := r.db.First(&u, id).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return u, errdef.ErrNotFoundf("user %d not found", id).WithProcess(user.ProcessName)
+	}
+	if err != nil {
+		return u, errdef.Wrap(err, errdef.CodeInternal, "failed to load user")
+	}
+	return r.withContacts(u)
+}
+
+func (r users) ByLogin(login string) (user.User, *errdef.Error) {
+	login = strings.ToLower(strings.TrimSpace(login))
+	var u user.User
+	err := r.db.
+		Where("lower(username) = ? OR id IN (SELECT user_id FROM user_contact WHERE channel = ? AND lower(contact) = ? AND verified)", login, contact.Email, login).
+		First(&u).Error
+	if gorm.IsRecordNotFoundError(err) {
+		return u, errdef.ErrNotFound("user not found").WithProcess(user.ProcessName)
+	}
+	if err != nil {
+		return u, errdef.Wrap(err, errdef.CodeInternal, "failed to load user")
+	}
+	return r.withContacts(u)
+}
+
+// withContacts loads contacts of the user.
+func (r users) withContacts(u user.User) (user.User, *errdef.Error) {
+	u.Contacts = contact.Contacts{}
+	if err := r.db.Where("user_id = ?", u.ID).Order("id").Find(&u.Contacts).Error; err != nil {
+		return u, errdef.Wrap(err, errdef.CodeInternal, "failed to load contacts")
+	}
+	return u, nil
+}
+
+func (r users) Save(u *user.User) *errdef.Error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if u.ID != 0 {
+			return tx.Save(u).Error
+		}
+		if err := tx.Create(u).Error; err != nil {
+			return err
+		}
+		for i := range u.Contacts {
+			u.Contacts[i].ID = 0
+			u.Contacts[i].UserID = u.ID
+			if err := tx.Create(&u.Contacts[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if isUniqueViolation(err, "users_username_key") {
+		return errdef.Wrapf(err, errdef.CodeAlreadyExists, "user %s already exists", u.Username).WithMeta("field", "username")
+	}
+	if isUniqueViolation(err, "") {
+		return errdef.Wrap(err, errdef.CodeAlreadyExists, "contact already exists").WithMeta("field", "contacts")
+	}
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to save user")
+	}
+	return nil
+}
+
+func (r users) SignedIn(id uint, at time.Time) *errdef.Error {
+	res := r.db.Model(&user.User{}).Where("id = ?", id).UpdateColumn("last_signed_at", at)
+	if res.Error != nil {
+		return errdef.Wrap(res.Error, errdef.CodeInternal, "failed to update user")
+	}
+	if res.RowsAffected == 0 {
+		return errdef.ErrNotFoundf("user %d not found", id).WithProcess(user.ProcessName)
+	}
+	return nil
+}
+
+func (r users) CountRole(role user.Role) (int, *errdef.Error) {
+	var rows []user.User
+	if err := forUpdate(r.db).Select("id").Where("role = ?", role).Find(&rows).Error; err != nil {
+		return 0, errdef.Wrap(err, errdef.CodeInternal, "failed to count users")
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,375 @@
+// Package store defines the repositories the library API reads and writes
+// its data through. The sqlstore package implements them on top of the
+// database, memstore keeps everything in memory, so the API can be tested
+// without a running database.
+package store
+
+import (
+	"time"
+
+	"github.com/investapp/backend/models/author"
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/item"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Store gives access to all repositories.
+type Store interface {
+	People() People
+	Books() Books
+	Authors() Authors
+	Branches() Branches
+	Copies() Copies
+	Transfers() Transfers
+	Loans() Loans
+	Holds() Holds
+	Fines() Fines
+	Reports() Reports
+	Users() Users
+	RefreshTokens() RefreshTokens
+	// Transaction runs fn with a store bound to a transaction. The
+	// transaction is committed if fn returns nil and rolled back otherwise,
+	// the error of fn is returned as it is. A nested transaction rolls
+	// back only its own changes, the outer one can go on.
+	Transaction(fn func(tx Store) error) error
+}
+
+// PersonFilter narrows down the list of people. Zero values don't filter.
+type PersonFilter struct {
+	// Name matches people whose name contains it, case insensitive.
+	Name string
+	// Email matches people with the email, case insensitive.
+	Email string
+}
+
+// People is the repository of people.
+type People interface {
+	// Find returns page of people, see paging.Slice for the result.
+	Find(f PersonFilter, p paging.Params) ([]person.Person, *int64, *errdef.Error)
+	// Each calls fn for every person matching the filter in ID order,
+	// rows are streamed one by one. It stops at the first error of fn.
+	Each(f PersonFilter, fn func(p person.Person) *errdef.Error) *errdef.Error
+	// Get returns the person or errdef NotFound.
+	Get(id uint) (person.Person, *errdef.Error)
+	// Lock works like Get, the person can't be changed by other
+	// transactions until the current one ends.
+	Lock(id uint) (person.Person, *errdef.Error)
+	// Save creates the person if it has no ID yet or updates it.
+	// It returns errdef AlreadyExists if the email is taken.
+	Save(p *person.Person) *errdef.Error
+	// Delete deletes the person or returns errdef NotFound.
+	// The person is moved to the trash.
+	Delete(id uint) *errdef.Error
+	// Trash returns page of deleted people.
+	Trash(p paging.Params) ([]person.Person, *int64, *errdef.Error)
+	// GetDeleted returns the person from the trash or errdef NotFound.
+	GetDeleted(id uint) (person.Person, *errdef.Error)
+	// Restore moves the person out of the trash or returns errdef NotFound.
+	Restore(id uint) *errdef.Error
+	// Purge removes the person from the trash for good
+	// or returns errdef NotFound.
+	Purge(id uint) *errdef.Error
+	// PurgeDeletedBefore removes people deleted before the time
+	// for good and returns how many were removed.
+	PurgeDeletedBefore(t time.Time) (int64, *errdef.Error)
+}
+
+// BookFilter narrows down the list of books. Zero values don't filter.
+type BookFilter struct {
+	// Author matches books whose author contains it, case insensitive.
+	Author string
+	// Title matches books whose title starts with it, case insensitive.
+	Title string
+	// PersonID matches books of the person.
+	PersonID *uint
+	// CallNumber matches the book with the call number.
+	CallNumber int
+	// AuthorID matches books crediting the author in any role.
+	AuthorID uint
+	// BranchID matches books with a copy located at the branch.
+	BranchID uint
+}
+
+// Books is the repository of books.
+type Books interface {
+	// Find returns page of books, see paging.Slice for the result.
+	Find(f BookFilter, p paging.Params) ([]book.Book, *int64, *errdef.Error)
+	// Search returns page of books matching the query, the best match
+	// first. Only limit, offset and total of the paging are used.
+	Search(q book.Query, p paging.Params) ([]book.Match, *int64, *errdef.Error)
+	// Each calls fn for every book matching the filter in ID order,
+	// rows are streamed one by one. It stops at the first error of fn.
+	Each(f BookFilter, fn func(b book.Book) *errdef.Error) *errdef.Error
+	// FindByPersonID returns all books of the person.
+	FindByPersonID(personID uint) ([]book.Book, *errdef.Error)
+	// Get returns the book or errdef NotFound.
+	Get(id uint) (book.Book, *errdef.Error)
+	// GetByISBN returns the book with the ISBN-13 or errdef NotFound.
+	GetByISBN(isbn string) (book.Book, *errdef.Error)
+	// Lock works like Get, the book can't be changed by other
+	// transactions until the current one ends.
+	Lock(id uint) (book.Book, *errdef.Error)
+	// Save creates the book if it has no ID yet or updates it.
+	// It returns errdef AlreadyExists if the call number or ISBN is taken.
+	Save(b *book.Book) *errdef.Error
+	// Delete deletes the book or returns errdef NotFound.
+	// The book is moved to the trash.
+	Delete(id uint) *errdef.Error
+	// Trash returns page of deleted books.
+	Trash(p paging.Params) ([]book.Book, *int64, *errdef.Error)
+	// GetDeleted returns the book from the trash or errdef NotFound.
+	GetDeleted(id uint) (book.Book, *errdef.Error)
+	// Restore moves the book out of the trash or returns errdef NotFound.
+	Restore(id uint) *errdef.Error
+	// Purge removes the book from the trash for good
+	// or returns errdef NotFound.
+	Purge(id uint) *errdef.Error
+	// PurgeDeletedBefore removes books deleted before the time
+	// for good and returns how many were removed.
+	PurgeDeletedBefore(t time.Time) (int64, *errdef.Error)
+}
+
+// AuthorFilter narrows down the list of authors. Zero values don't filter.
+type AuthorFilter struct {
+	// Name matches authors whose name contains it, case insensitive.
+	Name string
+}
+
+// Authors is the repository of authors and their credits on books.
+type Authors interface {
+	// Find returns page of authors, see paging.Slice for the result.
+	Find(f AuthorFilter, p paging.Params) ([]author.Author, *int64, *errdef.Error)
+	// Get returns the author or errdef NotFound.
+	Get(id uint) (author.Author, *errdef.Error)
+	// Lock works like Get, the author can't be changed by other
+	// transactions until the current one ends.
+	Lock(id uint) (author.Author, *errdef.Error)
+	// Save creates the author if it has no ID yet or updates it.
+	Save(a *author.Author) *errdef.Error
+	// Delete deletes the author or returns errdef NotFound.
+	Delete(id uint) *errdef.Error
+	// Credits returns credits of the books by book ID, every list is
+	// ordered by position and has names of the authors set.
+	Credits(bookIDs ...uint) (map[uint][]author.Credit, *errdef.Error)
+	// SetCredits replaces all credits of the book.
+	SetCredits(bookID uint, credits []author.Credit) *errdef.Error
+	// BookIDs returns IDs of the books the author is credited on,
+	// deleted books included, in ID order.
+	BookIDs(authorID uint) ([]uint, *errdef.Error)
+}
+
+// BranchFilter narrows down the list of branches. Zero values don't filter.
+type BranchFilter struct {
+	// Name matches branches whose name or code contains it, case insensitive.
+	Name string
+}
+
+// Branches is the repository of library branches.
+type Branches interface {
+	// Find returns page of branches, see paging.Slice for the result.
+	Find(f BranchFilter, p paging.Params) ([]branch.Branch, *int64, *errdef.Error)
+	// Get returns the branch or errdef NotFound.
+	Get(id uint) (branch.Branch, *errdef.Error)
+	// Lock works like Get, the branch can't be changed by other
+	// transactions until the current one ends.
+	Lock(id uint) (branch.Branch, *errdef.Error)
+	// Save creates the branch if it has no ID yet or updates it.
+	// It returns errdef AlreadyExists if the code is taken.
+	Save(b *branch.Branch) *errdef.Error
+	// Delete deletes the branch or returns errdef NotFound.
+	Delete(id uint) *errdef.Error
+}
+
+// CopyFilter narrows down the list of copies. Zero values don't filter.
+type CopyFilter struct {
+	BookID uint
+	Status *item.Status
+	// BranchID matches copies located at the branch or heading there.
+	BranchID uint
+	// HomeBranchID matches copies belonging to the branch.
+	HomeBranchID uint
+}
+
+// Copies is the repository of copies of books.
+type Copies interface {
+	// Find returns page of copies, see paging.Slice for the result.
+	Find(f CopyFilter, p paging.Params) ([]item.Copy, *int64, *errdef.Error)
+	// OfBook returns all copies of the book in ID order.
+	OfBook(bookID uint) ([]item.Copy, *errdef.Error)
+	// Get returns the copy or errdef NotFound.
+	Get(id uint) (item.Copy, *errdef.Error)
+	// GetByBarcode returns the copy with the barcode or errdef NotFound.
+	GetByBarcode(barcode string) (item.Copy, *errdef.Error)
+	// Lock works like Get, the copy can't be changed by other
+	// transactions until the current one ends.
+	Lock(id uint) (item.Copy, *errdef.Error)
+	// Save creates the copy if it has no ID yet or updates it.
+	// It returns errdef AlreadyExists if the barcode is taken.
+	Save(c *item.Copy) *errdef.Error
+	// Delete deletes the copy or returns errdef NotFound.
+	Delete(id uint) *errdef.Error
+}
+
+// TransferFilter narrows down the list of transfers. Zero values don't filter.
+type TransferFilter struct {
+	CopyID uint
+	// BranchID matches transfers from or to the branch.
+	BranchID uint
+	HoldID   uint
+	Status   *branch.Status
+	// Open matches requested and in transit transfers if true
+	// and the closed ones if false.
+	Open *bool
+}
+
+// Transfers is the repository of transfers of copies between branches.
+type Transfers interface {
+	// Find returns page of transfers, see paging.Slice for the result.
+	Find(f TransferFilter, p paging.Params) ([]branch.Transfer, *int64, *errdef.Error)
+	// Get returns the transfer or errdef NotFound.
+	Get(id uint) (branch.Transfer, *errdef.Error)
+	// Open returns the requested or in transit transfer of the copy,
+	// nil if the copy is not being transferred.
+	Open(copyID uint) (*branch.Transfer, *errdef.Error)
+	// Save creates the transfer if it has no ID yet or updates it.
+	Save(t *branch.Transfer) *errdef.Error
+}
+
+// LoanFilter narrows down the list of loans. Zero values don't filter.
+type LoanFilter struct {
+	PersonID uint
+	BookID   uint
+	CopyID   uint
+	// Active matches loans which were not returned yet if true
+	// and the returned ones if false.
+	Active *bool
+}
+
+// Loans is the repository of loans.
+type Loans interface {
+	// Find returns page of loans, see paging.Slice for the result.
+	Find(f LoanFilter, p paging.Params) (loan.Loans, *int64, *errdef.Error)
+	// Each calls fn for every loan matching the filter in ID order,
+	// rows are streamed one by one. It stops at the first error of fn.
+	Each(f LoanFilter, fn func(l loan.Loan) *errdef.Error) *errdef.Error
+	// Get returns the loan or errdef NotFound.
+	Get(id uint) (loan.Loan, *errdef.Error)
+	// Active returns the loan of the copy which was not returned yet,
+	// nil if the copy is not on loan.
+	Active(copyID uint) (*loan.Loan, *errdef.Error)
+	// Save creates the loan if it has no ID yet or updates it.
+	Save(l *loan.Loan) *errdef.Error
+}
+
+// HoldFilter narrows down the list of holds. Zero values don't filter.
+type HoldFilter struct {
+	PersonID uint
+	BookID   uint
+	Status   *hold.Status
+	// BranchID matches holds picked up at the branch.
+	BranchID uint
+}
+
+// Holds is the repository of holds.
+type Holds interface {
+	// Find returns page of holds, see paging.Slice for the result.
+	Find(f HoldFilter, p paging.Params) (hold.Holds, *int64, *errdef.Error)
+	// Get returns the hold or errdef NotFound.
+	Get(id uint) (hold.Hold, *errdef.Error)
+	// Open returns waiting and ready holds of the book in queue order.
+	Open(bookID uint) (hold.Holds, *errdef.Error)
+	// ExpiredBookIDs returns books with ready holds expired before now.
+	ExpiredBookIDs(now time.Time) ([]uint, *errdef.Error)
+	// Save creates the hold if it has no ID yet or updates it.
+	Save(h *hold.Hold) *errdef.Error
+}
+
+// FineFilter narrows down the list of ledger entries. Zero values don't filter.
+type FineFilter struct {
+	PersonID uint
+	Kind     *fine.Kind
+}
+
+// Fines is the repository of the fines ledger.
+type Fines interface {
+	// Find returns page of entries, see paging.Slice for the result.
+	Find(f FineFilter, p paging.Params) (fine.Ledger, *int64, *errdef.Error)
+	// Ledger returns all entries of the person in the order they were added.
+	Ledger(personID uint) (fine.Ledger, *errdef.Error)
+	// Save creates the entry if it has no ID yet or updates it.
+	Save(e *fine.Entry) *errdef.Error
+}
+
+// ReportFilter narrows down the loans counted in reports. Zero values
+// don't filter.
+type ReportFilter struct {
+	// From and To match loans checked out at or after From and before To.
+	From time.Time
+	To   time.Time
+	// BranchID matches loans of copies whose home branch it is.
+	BranchID uint
+}
+
+// Reports aggregates the circulation. Deleted loans are not counted,
+// deleted books and people are, they were borrowing at the time.
+type Reports interface {
+	// Loans counts loans by the group, ordered by the group. Loans
+	// returned after the due date or still out at now are overdue.
+	Loans(f ReportFilter, g report.Group, now time.Time) ([]report.LoanCount, *errdef.Error)
+	// MostBorrowed returns up to limit books with the most loans,
+	// books with the same number of loans in ID order.
+	MostBorrowed(f ReportFilter, limit int) ([]report.BookCount, *errdef.Error)
+	// ActivePatrons returns up to limit people with the most loans,
+	// people with the same number of loans in ID order.
+	ActivePatrons(f ReportFilter, limit int) ([]report.PersonCount, *errdef.Error)
+	// NeverBorrowed returns up to limit books in ID order which were
+	// not borrowed. Deleted books and books added at or after f.To are
+	// left out, with f.BranchID only books with a copy there are
+	// returned and only loans of those copies count.
+	NeverBorrowed(f ReportFilter, limit int) ([]report.BookCount, *errdef.Error)
+}
+
+// Users is the repository of user accounts. Users are returned with
+// their contacts.
+type Users interface {
+	// Get returns the user or errdef NotFound.
+	Get(id uint) (user.User, *errdef.Error)
+	// ByLogin returns the user whose username is the login, ignoring
+	// case, or who has the verified email contact, or errdef NotFound.
+	ByLogin(login string) (user.User, *errdef.Error)
+	// Save creates the user with its contacts if it has no ID yet or
+	// updates the user, contacts of existing users are not changed.
+	// Username and contacts have to be unique.
+	Save(u *user.User) *errdef.Error
+	// SignedIn sets the time the user signed in last.
+	SignedIn(id uint, at time.Time) *errdef.Error
+	// CountRole returns the number of users with the role. Their role
+	// can't be changed by other transactions until the current one ends.
+	CountRole(role user.Role) (int, *errdef.Error)
+}
+
+// RefreshTokens is the repository of refresh tokens, see
+// user.RefreshToken.
+type RefreshTokens interface {
+	// Create stores the token.
+	Create(t *user.RefreshToken) *errdef.Error
+	// ByHash returns the token with the hash or errdef NotFound.
+	ByHash(hash string) (user.RefreshToken, *errdef.Error)
+	// Rotate marks the token rotated at the time. It returns errdef
+	// FailedPrecondition if the token is rotated or revoked already, so
+	// a token is rotated once even if it is used twice at the same time.
+	Rotate(id uint, at time.Time) *errdef.Error
+	// RevokeFamily revokes the tokens of the family which are not
+	// revoked yet.
+	RevokeFamily(family string, at time.Time) *errdef.Error
+	// RevokeUser revokes all tokens of the user which are not revoked yet.
+	RevokeUser(userID uint, at time.Time) *errdef.Error
+}
@@ -47,17 +47,22 @@ var (
 
 // Scan implements sql.Scanner interface.
 func (c *Channel) Scan(value interface{}) error {
-	bin, ok := value.([]byte)
-	if !ok {
-		return errdef.ErrInternalf("contact_channel_scan", "invalid type")
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid contact channel type").WithProcess(ProcessName)
 	}
-	switch string(bin) {
+	switch str {
 	case "PHONE":
 		*c = Phone
 	case "EMAIL":
 		*c = Email
 	default:
-		return errdef.ErrInternalf("contact_channel", "unknown channel value: "+string(bin))
+		return errdef.ErrInternal("unknown contact channel value: " + str).WithProcess(ProcessName)
 	}
 	return nil
 }
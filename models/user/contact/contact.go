@@ -28,6 +28,12 @@ type Contact struct {
 	ConfirmationRequests uint      `json:"confirmation_requests" sql:",notnull"`
 }
 
+// TableName sets the table of contacts for gorm, go-pg models
+// set it in contactdb.
+func (Contact) TableName() string {
+	return "user_contact"
+}
+
 // Sanitize will sanitize contact
 func (c *Contact) Sanitize() {
 	c.Contact = strings.Trim(c.Contact, " ")
@@ -45,7 +51,7 @@ func (c *Contact) Sanitize() {
 
 // Validate will validate contact.
 func (c *Contact) Validate() *errdef.Error {
-	errSet := errdef.New("",ProcessName, errdef.CodeInvalidArgument)
+	errSet := errdef.New("", ProcessName, errdef.CodeInvalidArgument)
 	if len(c.Contact) == 0 || len(c.Contact) > 250 {
 		return errSet
 	}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"time"
+
+	"github.com/investapp/backend/pkg/crypto"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// refreshTokenBytes is the number of random bytes of refresh tokens.
+const refreshTokenBytes = 32
+
+// RefreshToken is a database model of an opaque token the user gets new
+// access tokens with. Only the hash of the token is stored. Tokens issued
+// from one sign in share Family: using a token rotates it, the used
+// token is marked rotated and a new one of the family is issued. A rotated
+// token used again was stolen by someone, so the whole family is revoked.
+type RefreshToken struct {
+	ID        uint `gorm:"primary_key"`
+	CreatedAt time.Time
+	UserID    uint   `gorm:"index"`
+	Family    string `gorm:"index"`
+	Hash      string `gorm:"unique_index"`
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+}
+
+// TableName returns name of the refresh tokens table.
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// NewRefreshToken creates refresh token of the user valid for ttl and
+// returns it together with the token to be given to the user. A new
+// family is started if family is empty.
+func NewRefreshToken(userID uint, family string, ttl time.Duration, now time.Time) (RefreshToken, string, *errdef.Error) {
+	token, err := crypto.RandomToken(refreshTokenBytes)
+	if err != nil {
+		return RefreshToken{}, "", errdef.Wrap(err, errdef.CodeInternal, "failed to create refresh token")
+	}
+	if family == "" {
+		if family, err = crypto.RandomToken(refreshTokenBytes / 2); err != nil {
+			return RefreshToken{}, "", errdef.Wrap(err, errdef.CodeInternal, "failed to create refresh token")
+		}
+	}
+	return RefreshToken{
+		UserID:    userID,
+		Family:    family,
+		Hash:      crypto.HashToken(token),
+		ExpiresAt: now.Add(ttl),
+	}, token, nil
+}
+
+// Active tells you if the token can be used to refresh.
+func (t RefreshToken) Active(now time.Time) bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
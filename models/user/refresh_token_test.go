@@ -0,0 +1,32 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/crypto"
+)
+
+func TestNewRefreshToken(t *testing.T) {
+	now := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	rt, token, errSet := NewRefreshToken(7, "", time.Hour, now)
+	require.Nil(t, errSet)
+	assert.Equal(t, uint(7), rt.UserID)
+	assert.NotEmpty(t, rt.Family)
+	assert.Equal(t, crypto.HashToken(token), rt.Hash)
+	assert.True(t, rt.Active(now))
+	assert.False(t, rt.Active(now.Add(time.Hour)))
+
+	next, nextToken, errSet := NewRefreshToken(7, rt.Family, time.Hour, now)
+	require.Nil(t, errSet)
+	assert.Equal(t, rt.Family, next.Family)
+	assert.NotEqual(t, token, nextToken)
+
+	next.RotatedAt = &now
+	assert.False(t, next.Active(now))
+	next.RotatedAt, next.RevokedAt = nil, &now
+	assert.False(t, next.Active(now))
+}
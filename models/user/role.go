@@ -0,0 +1,149 @@
+package user
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Role of the user decides what the user is allowed to do.
+type Role int
+
+const (
+	// Member is a patron of the library, it is the role of new users.
+	Member Role = iota
+	// Staff runs the library, lends books and keeps the catalog.
+	Staff
+	// Admin can do everything, including changing roles of users.
+	Admin
+)
+
+// Permission allows the user to do a kind of operation. Reading the
+// catalog is allowed to everyone.
+type Permission string
+
+const (
+	// BooksWrite allows to change books, their authors and copies.
+	BooksWrite Permission = "books:write"
+	// PeopleWrite allows to change people.
+	PeopleWrite Permission = "people:write"
+	// BranchesWrite allows to change branches.
+	BranchesWrite Permission = "branches:write"
+	// CirculationWrite allows to lend and return copies, to manage holds
+	// and to transfer copies between branches.
+	CirculationWrite Permission = "circulation:write"
+	// FinesWrite allows to record payments and waive fines.
+	FinesWrite Permission = "fines:write"
+	// DataImport allows to import people and books.
+	DataImport Permission = "data:import"
+	// TrashPurge allows to purge deleted people and books for good.
+	TrashPurge Permission = "trash:purge"
+	// RolesWrite allows to change roles of users.
+	RolesWrite Permission = "users:roles"
+	// RecordsRead allows to read what is not in the catalog: people,
+	// their loans, holds and fines, exports, reports and deleted rows.
+	RecordsRead Permission = "records:read"
+)
+
+// permissions of the roles.
+var permissions = map[Role][]Permission{
+	Member: {},
+	Staff:  {BooksWrite, PeopleWrite, CirculationWrite, FinesWrite, DataImport, RecordsRead},
+	Admin:  {BooksWrite, PeopleWrite, BranchesWrite, CirculationWrite, FinesWrite, DataImport, TrashPurge, RolesWrite, RecordsRead},
+}
+
+// Permissions returns the permissions of the role.
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), permissions[r]...)
+}
+
+// Can tells you if the role has the permission.
+func (r Role) Can(p Permission) bool {
+	for _, rp := range permissions[r] {
+		if rp == p {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements fmt.Stringer.
+func (r Role) String() string {
+	switch r {
+	case Member:
+		return "member"
+	case Staff:
+		return "staff"
+	case Admin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRole parses role from its string form.
+func ParseRole(s string) (Role, *errdef.Error) {
+	for _, role := range []Role{Member, Staff, Admin} {
+		if strings.EqualFold(role.String(), s) {
+			return role, nil
+		}
+	}
+	return Member, errdef.ErrInvalidArgument("invalid role value: " + s).WithProcess(ProcessName)
+}
+
+// compile time check for the driver.Valuer interface.
+var _ driver.Valuer = Member
+
+// Value implements driver.Valuer interface.
+func (r Role) Value() (driver.Value, error) {
+	return driver.Value(strings.ToUpper(r.String())), nil
+}
+
+// compile time check for the sql.Scanner interface.
+var (
+	tmpr             = Member
+	_    sql.Scanner = &tmpr
+)
+
+// Scan implements sql.Scanner interface.
+func (r *Role) Scan(value interface{}) error {
+	var str string
+	switch v := value.(type) {
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errdef.ErrInternal("invalid role type").WithProcess(ProcessName)
+	}
+	role, errSet := ParseRole(str)
+	if errSet != nil {
+		return errdef.ErrInternal("unknown role value: " + str).WithProcess(ProcessName)
+	}
+	*r = role
+	return nil
+}
+
+// compile time check for the encoding.TextMarshaler interface.
+var _ encoding.TextMarshaler = Member
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// compile time check for the encoding.TextUnmarshaler interface.
+var _ encoding.TextUnmarshaler = &tmpr
+
+// UnmarshalText implement encoding.TextUnmarshaler interface.
+func (r *Role) UnmarshalText(text []byte) error {
+	role, errSet := ParseRole(string(text))
+	if errSet != nil {
+		return errSet
+	}
+	*r = role
+	return nil
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole(t *testing.T) {
+	data, err := json.Marshal(Staff)
+	require.NoError(t, err)
+	assert.Equal(t, `"staff"`, string(data))
+
+	var r Role
+	require.NoError(t, json.Unmarshal([]byte(`"Admin"`), &r))
+	assert.Equal(t, Admin, r)
+	assert.Error(t, json.Unmarshal([]byte(`"root"`), &r))
+
+	v, err := Member.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "MEMBER", v)
+	require.NoError(t, r.Scan([]byte("STAFF")))
+	assert.Equal(t, Staff, r)
+	assert.Error(t, r.Scan(1))
+}
+
+func TestRolePermissions(t *testing.T) {
+	assert.Empty(t, Member.Permissions())
+	assert.False(t, Member.Can(BooksWrite))
+	assert.False(t, Member.Can(RecordsRead))
+	assert.True(t, Staff.Can(RecordsRead))
+	assert.True(t, Staff.Can(BooksWrite))
+	assert.False(t, Staff.Can(RolesWrite))
+	assert.False(t, Staff.Can(TrashPurge))
+	assert.True(t, Admin.Can(RolesWrite))
+	for _, p := range Staff.Permissions() {
+		assert.True(t, Admin.Can(p), p)
+	}
+
+	// the returned slice is a copy
+	perms := Admin.Permissions()
+	perms[0] = "anything"
+	assert.False(t, Admin.Can("anything"))
+}
@@ -5,8 +5,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
 	"github.com/stretchr/testify/require"
-	
 
 	"github.com/investapp/backend/models/user/contact"
 	"github.com/investapp/backend/pkg/crypto"
@@ -30,14 +30,15 @@ type User struct {
 	Firstname       string           `json:"firstname,omitempty" sql:",notnull"`
 	Lastname        string           `json:"lastname,omitempty" sql:",notnull"`
 	Username        string           `json:"username,omitempty" sql:",notnull"`
-	Hash            *string          `json:"-" sql:"password,notnull"`
+	Hash            *string          `json:"-" sql:"password,notnull" gorm:"column:password"`
+	Role            Role             `json:"role" sql:",notnull" gorm:"type:varchar(20)"`
 	CreatorID       *uint            `json:"creator_id,omitempty"`
 	Contacts        contact.Contacts `json:"contacts,omitempty" sql:"-"`
 	PicturePath     *string          `json:"picture_path,omitempty"`
 	CryptoAddressID *uint            `json:"crypto_address_id" sql:",notnull"`
 	// 2FA definitions
-	TwoFactorAuthID       *uint     `json:"2fa_id" sql:"2fa_id"`
-	TwoFactorAuthVerifyID null.UUID `json:"-" sql:"2fa_verify_id"`
+	TwoFactorAuthID       *uint     `json:"2fa_id" sql:"2fa_id" gorm:"column:2fa_id"`
+	TwoFactorAuthVerifyID null.UUID `json:"-" sql:"2fa_verify_id" gorm:"column:2fa_verify_id"`
 }
 
 // HasPwd will tell you if user set password
@@ -64,14 +65,27 @@ func (u *User) GetEmailContact() *contact.Contact {
 	return nil
 }
 
+// HasEmail tells if the email is one of the verified emails of the user.
+func (u User) HasEmail(email string) bool {
+	if email == "" {
+		return false
+	}
+	for _, c := range u.Contacts {
+		if c.Channel == contact.Email && c.Verified && strings.EqualFold(c.Contact, email) {
+			return true
+		}
+	}
+	return false
+}
+
 // SetPwd will set pwd and hash
 func (u *User) SetPwd(pwd string) *errdef.Error {
 	if len(pwd) < 8 {
-		return errdef.ErrInvalidArgumentf("password", "too weak")
+		return errdef.ErrInvalidArgument("password is too weak").WithProcess(ProcessName).WithMeta("field", "password")
 	}
 	hash, err := crypto.Crypt([]byte(pwd))
 	if err != nil {
-		return errdef.ErrInvalidArgumentf(err.Error(), "failed to encrypt pwd")
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to encrypt password").WithProcess(ProcessName)
 	}
 	hashStr := string(hash)
 	u.Hash = &hashStr
@@ -95,25 +109,23 @@ func Now() time.Time {
 	return time.Now().UTC().Truncate(time.Second)
 }
 
-
-
 // Validate validates struct content.
 func (u User) Validate() *errdef.Error {
-	errSet := errdef.ErrInvalidArgumentf(ProcessName, "validate")
+	errSet := errdef.ErrInvalidArgument("user is not valid").WithProcess(ProcessName)
 	switch {
 	case len(u.Firstname) > 200:
 		msg := fmt.Sprintf("out of range 1-200 characters: '%s'", u.Firstname)
-		errSet.Detail= msg
+		errSet.Detail = msg
 		return errSet
 	case len(u.Lastname) > 200:
 		msg := fmt.Sprintf("out of range 1-200 characters: '%s'", u.Lastname)
 		errSet.Detail = msg
 		return errSet
 	case !valid.Username(u.Username):
-		errSet.Detail=  "invalid username"
+		errSet.Detail = "invalid username"
 		return errSet
 	case u.CreatorID != nil && u.ID == *u.CreatorID:
-		errSet.Detail= "creator_id - is self referencing"
+		errSet.Detail = "creator_id - is self referencing"
 		return errSet
 	}
 	return nil
@@ -174,13 +186,13 @@ var minDate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
 func DOB(s string) (*time.Time, *errdef.Error) {
 	d, err := time.Parse("02/01/2006", s)
 	if err != nil {
-		return nil, errdef.ErrInternalf(err.Error(), "date_of_birth")
+		return nil, errdef.Wrap(err, errdef.CodeInvalidArgument, "date of birth is not valid").WithMeta("field", "date_of_birth")
 	}
 	if d.IsZero() {
-		return nil, errdef.ErrInternalf("date_of_birth", "zero value provided")
+		return nil, errdef.ErrInvalidArgument("date of birth is zero").WithMeta("field", "date_of_birth")
 	}
 	if d.Before(minDate) {
-		return nil, errdef.ErrInternalf("date_of_birth", "value is not valid")
+		return nil, errdef.ErrInvalidArgument("date of birth is not valid").WithMeta("field", "date_of_birth")
 	}
 	return &d, nil
 }
@@ -203,7 +215,6 @@ func TstGenRandom(t testing.TB) User {
 	return u
 }
 
-
 // TstGenRandomFast will generate random user
 func TstGenRandomFast() User {
 	u := User{}
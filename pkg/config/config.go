@@ -0,0 +1,246 @@
+// Package config loads typed configuration of the server. Every setting
+// has a dotted key, e.g. "db.host", and can be set by a command line flag
+// (--db.host), in the YAML config file (db: {host: ...}), by a prefixed
+// env variable (LIBRARY_DB_HOST) or in a .env file, in that order of precedence.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "config"
+
+// EnvPrefix is the prefix of env variables read into the configuration.
+const EnvPrefix = "LIBRARY_"
+
+// Config is the configuration of the server.
+type Config struct {
+	HTTP  HTTP  `yaml:"http"`
+	DB    DB    `yaml:"db"`
+	Trash Trash `yaml:"trash"`
+	Auth  Auth  `yaml:"auth"`
+	Loans Loans `yaml:"loans"`
+	Holds Holds `yaml:"holds"`
+	Fines Fines `yaml:"fines"`
+}
+
+// HTTP configures the API listener.
+type HTTP struct {
+	Addr    string `yaml:"addr" usage:"address the API listens on"`
+	TLSCert string `yaml:"tls_cert" usage:"path to TLS certificate, TLS is off if empty"`
+	TLSKey  string `yaml:"tls_key" usage:"path to TLS private key"`
+	// ShutdownTimeout limits how long in-flight requests are drained
+	// after the server is asked to stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" usage:"how long in-flight requests are drained on shutdown"`
+}
+
+// DB configures the database connection and its pool.
+type DB struct {
+	Host            string        `yaml:"host" usage:"database host"`
+	Port            int           `yaml:"port" usage:"database port"`
+	User            string        `yaml:"user" usage:"database user"`
+	Password        string        `yaml:"password" usage:"database password" secret:"true"`
+	Name            string        `yaml:"name" usage:"database name"`
+	SSLMode         string        `yaml:"sslmode" usage:"disable, require, verify-ca or verify-full"`
+	MaxOpenConns    int           `yaml:"max_open_conns" usage:"maximum number of open connections, 0 is unlimited"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" usage:"maximum number of idle connections"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" usage:"maximum time a connection is reused, 0 is forever"`
+}
+
+// Trash configures deleted people and books.
+type Trash struct {
+	PurgeAfterDays int `yaml:"purge_after_days" usage:"days after which deleted people and books are purged, 0 keeps them forever"`
+}
+
+// Loans configures lending of books.
+type Loans struct {
+	Period      time.Duration `yaml:"period" usage:"how long a book is lent for"`
+	MaxRenewals int           `yaml:"max_renewals" usage:"how many times a loan can be renewed"`
+}
+
+// Holds configures the hold queue.
+type Holds struct {
+	PickupWindow time.Duration `yaml:"pickup_window" usage:"how long a ready hold keeps the book for pickup"`
+}
+
+// Fines configures overdue fines.
+type Fines struct {
+	DailyRate      decimal.Decimal `yaml:"daily_rate" usage:"fine for every started day after the due date"`
+	GracePeriod    time.Duration   `yaml:"grace_period" usage:"how late a book can be returned without a fine"`
+	MaxPerItem     decimal.Decimal `yaml:"max_per_item" usage:"maximum fine of one loan, 0 is no maximum"`
+	BlockThreshold decimal.Decimal `yaml:"block_threshold" usage:"balance over which checkouts are refused"`
+}
+
+// Auth configures signing in.
+type Auth struct {
+	Algorithm       string        `yaml:"algorithm" usage:"algorithm signing access tokens, HS256, RS256 or EdDSA"`
+	Secret          string        `yaml:"secret" usage:"key signing HS256 access tokens, at least 32 characters, sign in and writes are off if empty" secret:"true"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" usage:"how long access tokens are valid"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" usage:"how long refresh tokens are valid, each refresh extends it"`
+	KeyFile         string        `yaml:"key_file" usage:"PEM file of RS256 or EdDSA private keys, the first one signs, keys are generated at start if empty"`
+	KeyRotation     time.Duration `yaml:"key_rotation" usage:"how often RS256 and EdDSA signing keys are replaced, or reloaded from key_file"`
+	KeyOverlap      time.Duration `yaml:"key_overlap" usage:"how long replaced signing keys still verify tokens, at least access_token_ttl"`
+}
+
+// Asymmetric tells you if tokens are signed with a private key, which
+// is loaded from the key file or generated at start, instead of the secret.
+func (a Auth) Asymmetric() bool {
+	return a.Algorithm != "HS256"
+}
+
+// Default returns configuration used for settings which are not set.
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:            ":8080",
+			ShutdownTimeout: 15 * time.Second,
+		},
+		DB: DB{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    20,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+		},
+		Auth: Auth{
+			Algorithm:       "HS256",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+			KeyRotation:     24 * time.Hour,
+			KeyOverlap:      time.Hour,
+		},
+		Loans: Loans{
+			Period:      21 * 24 * time.Hour,
+			MaxRenewals: 2,
+		},
+		Holds: Holds{
+			PickupWindow: 7 * 24 * time.Hour,
+		},
+		Fines: Fines{
+			DailyRate:      decimal.New(25, -2),
+			GracePeriod:    24 * time.Hour,
+			MaxPerItem:     decimal.New(10, 0),
+			BlockThreshold: decimal.New(5, 0),
+		},
+	}
+}
+
+// TLS tells you if the API is served over TLS.
+func (h HTTP) TLS() bool {
+	return h.TLSCert != ""
+}
+
+// DSN returns the connection string of the database.
+func (db DB) DSN() string {
+	params := []struct{ key, value string }{
+		{"host", db.Host},
+		{"port", fmt.Sprint(db.Port)},
+		{"user", db.User},
+		{"password", db.Password},
+		{"dbname", db.Name},
+		{"sslmode", db.SSLMode},
+	}
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.value == "" {
+			continue
+		}
+		value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(p.value)
+		parts = append(parts, fmt.Sprintf("%s='%s'", p.key, value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Validate validates struct content.
+func (c Config) Validate() *errdef.Error {
+	invalid := func(key, format string, args ...interface{}) *errdef.Error {
+		return errdef.ErrInvalidArgumentf("%s: "+format, append([]interface{}{key}, args...)...).
+			WithProcess(ProcessName).
+			WithMeta("key", key)
+	}
+	switch {
+	case c.HTTP.Addr == "":
+		return invalid("http.addr", "is required")
+	case (c.HTTP.TLSCert == "") != (c.HTTP.TLSKey == ""):
+		return invalid("http.tls_cert", "TLS needs both certificate and key")
+	case c.HTTP.ShutdownTimeout <= 0:
+		return invalid("http.shutdown_timeout", "must be positive")
+	case c.DB.Host == "":
+		return invalid("db.host", "is required")
+	case c.DB.Port < 1 || c.DB.Port > 65535:
+		return invalid("db.port", "%d is out of range 1-65535", c.DB.Port)
+	case c.DB.User == "":
+		return invalid("db.user", "is required")
+	case c.DB.Name == "":
+		return invalid("db.name", "is required")
+	case c.DB.MaxOpenConns < 0:
+		return invalid("db.max_open_conns", "must not be negative")
+	case c.DB.MaxIdleConns < 0:
+		return invalid("db.max_idle_conns", "must not be negative")
+	case c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns:
+		return invalid("db.max_idle_conns", "must not be greater than db.max_open_conns")
+	case c.DB.ConnMaxLifetime < 0:
+		return invalid("db.conn_max_lifetime", "must not be negative")
+	case c.Trash.PurgeAfterDays < 0:
+		return invalid("trash.purge_after_days", "must not be negative")
+	case c.Auth.Secret != "" && len(c.Auth.Secret) < 32:
+		return invalid("auth.secret", "must have at least 32 characters")
+	case c.Auth.AccessTokenTTL <= 0:
+		return invalid("auth.access_token_ttl", "must be positive")
+	case c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL:
+		return invalid("auth.refresh_token_ttl", "must be longer than auth.access_token_ttl")
+	case c.Auth.KeyFile != "" && !c.Auth.Asymmetric():
+		return invalid("auth.key_file", "needs RS256 or EdDSA algorithm")
+	case c.Auth.Asymmetric() && c.Auth.KeyRotation <= 0:
+		return invalid("auth.key_rotation", "must be positive")
+	case c.Auth.Asymmetric() && c.Auth.KeyOverlap < c.Auth.AccessTokenTTL:
+		return invalid("auth.key_overlap", "must not be shorter than auth.access_token_ttl")
+	case c.Loans.Period <= 0:
+		return invalid("loans.period", "must be positive")
+	case c.Loans.MaxRenewals < 0:
+		return invalid("loans.max_renewals", "must not be negative")
+	case c.Holds.PickupWindow <= 0:
+		return invalid("holds.pickup_window", "must be positive")
+	case c.Fines.DailyRate.IsNegative():
+		return invalid("fines.daily_rate", "must not be negative")
+	case c.Fines.GracePeriod < 0:
+		return invalid("fines.grace_period", "must not be negative")
+	case c.Fines.MaxPerItem.IsNegative():
+		return invalid("fines.max_per_item", "must not be negative")
+	case c.Fines.BlockThreshold.IsNegative():
+		return invalid("fines.block_threshold", "must not be negative")
+	}
+	switch c.Auth.Algorithm {
+	case "HS256", "RS256", "EdDSA":
+	default:
+		return invalid("auth.algorithm", "unknown algorithm '%s'", c.Auth.Algorithm)
+	}
+	switch c.DB.SSLMode {
+	case "disable", "require", "verify-ca", "verify-full":
+	default:
+		return invalid("db.sslmode", "unknown mode '%s'", c.DB.SSLMode)
+	}
+	files := []struct{ key, path string }{
+		{"http.tls_cert", c.HTTP.TLSCert},
+		{"http.tls_key", c.HTTP.TLSKey},
+		{"auth.key_file", c.Auth.KeyFile},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			return invalid(f.key, "%s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	envFile := writeFile(t, ".env", `
+# local settings
+LIBRARY_DB_USER=dotenv
+export LIBRARY_DB_NAME="library"
+LIBRARY_DB_HOST=dotenv
+LIBRARY_DB_PORT=1111
+USER=ignored
+`)
+	file := writeFile(t, "config.yaml", `
+db:
+  host: file
+  max_open_conns: 50
+  conn_max_lifetime: 1h
+fines:
+  daily_rate: 0.5
+`)
+	cfg, err := Load(Sources{
+		Flags:   map[string]string{"db.max_open_conns": "60", "loans.max_renewals": "0"},
+		File:    file,
+		Env:     []string{"LIBRARY_DB_HOST=env", "LIBRARY_DB_PORT=2222", "USER=shell"},
+		EnvFile: envFile,
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "dotenv", cfg.DB.User)
+	assert.Equal(t, "library", cfg.DB.Name)
+	assert.Equal(t, 2222, cfg.DB.Port)
+	assert.Equal(t, "file", cfg.DB.Host)
+	assert.Equal(t, 60, cfg.DB.MaxOpenConns)
+	assert.Equal(t, time.Hour, cfg.DB.ConnMaxLifetime)
+	assert.Equal(t, ":8080", cfg.HTTP.Addr)
+	assert.Equal(t, "0.5", cfg.Fines.DailyRate.String())
+	assert.Equal(t, "10", cfg.Fines.MaxPerItem.String())
+	assert.Equal(t, 0, cfg.Loans.MaxRenewals)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	env := []string{"LIBRARY_DB_USER=u", "LIBRARY_DB_NAME=n"}
+	_, err := Load(Sources{Env: env})
+	require.Nil(t, err)
+
+	testCases := []Sources{
+		{Env: []string{"LIBRARY_DB_USER=u"}},
+		{Env: append(env, "LIBRARY_DB_PORT=http")},
+		{Env: env, Flags: map[string]string{"db.max_idle_conns": "30", "db.max_open_conns": "10"}},
+		{Env: env, Flags: map[string]string{"http.tls_cert": "cert.pem"}},
+		{Env: env, Flags: map[string]string{"db.sslmode": "sometimes"}},
+		{Env: env, Flags: map[string]string{"http.shutdown_timeout": "0s"}},
+		{Env: env, Flags: map[string]string{"trash.purge_after_days": "-1"}},
+		{Env: env, Flags: map[string]string{"auth.secret": "short"}},
+		{Env: env, Flags: map[string]string{"auth.access_token_ttl": "0s"}},
+		{Env: env, Flags: map[string]string{"auth.refresh_token_ttl": "10m"}},
+		{Env: env, Flags: map[string]string{"auth.algorithm": "ES256"}},
+		{Env: env, Flags: map[string]string{"auth.algorithm": "EdDSA", "auth.key_overlap": "1m"}},
+		{Env: env, Flags: map[string]string{"auth.algorithm": "RS256", "auth.key_rotation": "0s"}},
+		{Env: env, Flags: map[string]string{"auth.key_file": writeFile(t, "keys.pem", "")}},
+		{Env: env, Flags: map[string]string{"auth.algorithm": "EdDSA", "auth.key_file": "missing.pem"}},
+		{Env: env, Flags: map[string]string{"loans.period": "0s"}},
+		{Env: env, Flags: map[string]string{"loans.max_renewals": "-1"}},
+		{Env: env, Flags: map[string]string{"holds.pickup_window": "0s"}},
+		{Env: env, Flags: map[string]string{"fines.daily_rate": "ten"}},
+		{Env: env, Flags: map[string]string{"fines.block_threshold": "-5"}},
+		{Env: env, File: writeFile(t, "config.yaml", "db:\n  hots: x\n")},
+		{Env: env, File: writeFile(t, "config.yaml", "db: [")},
+		{Env: env, File: "missing.yaml"},
+	}
+	for _, src := range testCases {
+		_, err := Load(src)
+		require.NotNil(t, err, src)
+		assert.True(t, errdef.IsInvalidArgument(err), err.Error())
+	}
+}
+
+func TestValues(t *testing.T) {
+	cfg := Default()
+	values := cfg.Values()
+	assert.Equal(t, "", values["db.password"])
+	assert.Equal(t, "30m0s", values["db.conn_max_lifetime"])
+
+	cfg.DB.Password = "secret"
+	assert.Equal(t, Redacted, cfg.Values()["db.password"])
+	assert.Len(t, values, len(Keys()))
+}
+
+func TestKeys(t *testing.T) {
+	for _, k := range Keys() {
+		if k.Name == "db.max_open_conns" {
+			assert.Equal(t, "LIBRARY_DB_MAX_OPEN_CONNS", k.Env())
+			assert.Equal(t, "20", k.Default)
+			return
+		}
+	}
+	t.Fatal("db.max_open_conns not found")
+}
+
+func TestDSN(t *testing.T) {
+	db := DB{Host: "localhost", Port: 5432, User: "lib", Password: "it's", Name: "library", SSLMode: "disable"}
+	assert.Equal(t, `host='localhost' port='5432' user='lib' password='it\'s' dbname='library' sslmode='disable'`, db.DSN())
+}
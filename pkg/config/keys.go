@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Redacted replaces values of secret settings when the configuration is printed.
+const Redacted = "******"
+
+// Key describes one setting.
+type Key struct {
+	// Name is the dotted name of the setting, e.g. "db.host".
+	Name   string
+	Usage  string
+	Secret bool
+	// Default is the default value formatted as string.
+	Default string
+}
+
+// Env returns name of the env variable of the setting.
+func (k Key) Env() string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(k.Name, ".", "_"))
+}
+
+// Keys returns all settings in the order they are declared.
+func Keys() []Key {
+	var keys []Key
+	def := Default()
+	walk(reflect.ValueOf(&def).Elem(), "", func(name string, f reflect.StructField, v reflect.Value) {
+		keys = append(keys, Key{
+			Name:    name,
+			Usage:   f.Tag.Get("usage"),
+			Secret:  f.Tag.Get("secret") == "true",
+			Default: format(v),
+		})
+	})
+	return keys
+}
+
+// Values returns all settings formatted as strings by their keys.
+// Values of secret settings are replaced by Redacted unless empty.
+func (c Config) Values() map[string]string {
+	values := map[string]string{}
+	walk(reflect.ValueOf(&c).Elem(), "", func(name string, f reflect.StructField, v reflect.Value) {
+		s := format(v)
+		if f.Tag.Get("secret") == "true" && s != "" {
+			s = Redacted
+		}
+		values[name] = s
+	})
+	return values
+}
+
+// Set parses the value and sets the setting of the key.
+func (c *Config) Set(key, value string) *errdef.Error {
+	var (
+		found  bool
+		errSet *errdef.Error
+	)
+	walk(reflect.ValueOf(c).Elem(), "", func(name string, f reflect.StructField, v reflect.Value) {
+		if name != key {
+			return
+		}
+		found = true
+		if err := parse(v, value); err != nil {
+			errSet = errdef.ErrInvalidArgumentf("%s: %s", key, err).WithProcess(ProcessName).WithMeta("key", key)
+		}
+	})
+	if !found {
+		return errdef.ErrInvalidArgumentf("unknown setting %s", key).WithProcess(ProcessName).WithMeta("key", key)
+	}
+	return errSet
+}
+
+// walk calls fn for every leaf field of the struct with its dotted name.
+func walk(v reflect.Value, prefix string, fn func(name string, f reflect.StructField, v reflect.Value)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := prefix + f.Tag.Get("yaml")
+		if f.Type.Kind() == reflect.Struct && f.Type != decimalType {
+			walk(v.Field(i), name+".", fn)
+			continue
+		}
+		fn(name, f, v.Field(i))
+	}
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	decimalType  = reflect.TypeOf(decimal.Decimal{})
+)
+
+func format(v reflect.Value) string {
+	switch v.Type() {
+	case durationType:
+		return time.Duration(v.Int()).String()
+	case decimalType:
+		return v.Interface().(decimal.Decimal).String()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func parse(v reflect.Value, s string) error {
+	s = strings.TrimSpace(s)
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Type() == decimalType:
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a decimal number", s)
+		}
+		v.Set(reflect.ValueOf(d))
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", s)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a boolean", s)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.String:
+		v.SetString(s)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Sources lists where the configuration is read from.
+type Sources struct {
+	// Flags are values of command line flags set by the user by their keys.
+	Flags map[string]string
+	// File is path to the YAML config file, none is read if empty.
+	File string
+	// Env is the environment in the form of os.Environ.
+	Env []string
+	// EnvFile is path to the .env file, it is skipped if it does not exist.
+	EnvFile string
+}
+
+// Load reads the configuration from the sources on top of the defaults
+// and validates it. Flags take precedence over the config file, the file
+// over env variables and env variables over the .env file.
+func Load(src Sources) (Config, *errdef.Error) {
+	cfg := Default()
+
+	layers := []func() (map[string]string, *errdef.Error){
+		func() (map[string]string, *errdef.Error) { return readEnvFile(src.EnvFile) },
+		func() (map[string]string, *errdef.Error) { return fromEnv(src.Env), nil },
+		func() (map[string]string, *errdef.Error) { return readFile(src.File) },
+		func() (map[string]string, *errdef.Error) { return src.Flags, nil },
+	}
+	for _, layer := range layers {
+		values, errSet := layer()
+		if errSet != nil {
+			return cfg, errSet
+		}
+		for _, k := range Keys() {
+			if v, ok := values[k.Name]; ok {
+				if errSet := cfg.Set(k.Name, v); errSet != nil {
+					return cfg, errSet
+				}
+			}
+		}
+	}
+	if errSet := cfg.Validate(); errSet != nil {
+		return cfg, errSet
+	}
+	return cfg, nil
+}
+
+// fromEnv picks prefixed env variables of known settings.
+func fromEnv(env []string) map[string]string {
+	byEnv := map[string]string{}
+	for _, kv := range env {
+		if i := strings.IndexByte(kv, '='); i > 0 {
+			byEnv[kv[:i]] = kv[i+1:]
+		}
+	}
+	values := map[string]string{}
+	for _, k := range Keys() {
+		if v, ok := byEnv[k.Env()]; ok {
+			values[k.Name] = v
+		}
+	}
+	return values
+}
+
+// readEnvFile parses KEY=VALUE lines of the .env file. Empty lines,
+// comments and the export keyword are allowed, values may be quoted.
+func readEnvFile(path string) (map[string]string, *errdef.Error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errdef.Wrapf(err, errdef.CodeInvalidArgument, "failed to read %s", path).WithProcess(ProcessName)
+	}
+	var env []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.IndexByte(line, '=')
+		if i < 1 {
+			return nil, errdef.ErrInvalidArgumentf("%s:%d: expected KEY=VALUE", path, n).WithProcess(ProcessName)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env = append(env, key+"="+value)
+	}
+	return fromEnv(env), nil
+}
+
+// readFile reads the YAML config file. Unknown settings are refused,
+// so typos don't go unnoticed.
+func readFile(path string) (map[string]string, *errdef.Error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errdef.Wrapf(err, errdef.CodeInvalidArgument, "failed to read %s", path).WithProcess(ProcessName)
+	}
+	var root map[string]interface{}
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, errdef.Wrapf(err, errdef.CodeInvalidArgument, "%s is not valid yaml", path).WithProcess(ProcessName)
+	}
+	values := map[string]string{}
+	flatten(root, "", values)
+
+	known := map[string]bool{}
+	for _, k := range Keys() {
+		known[k.Name] = true
+	}
+	for key := range values {
+		if !known[key] {
+			return nil, errdef.ErrInvalidArgumentf("%s: unknown setting %s", path, key).WithProcess(ProcessName).WithMeta("key", key)
+		}
+	}
+	return values, nil
+}
+
+func flatten(m map[string]interface{}, prefix string, values map[string]string) {
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(nested, prefix+k+".", values)
+			continue
+		}
+		if v == nil {
+			v = ""
+		}
+		values[prefix+k] = fmt.Sprint(v)
+	}
+}
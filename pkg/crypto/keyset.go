@@ -0,0 +1,381 @@
+package crypto
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Algorithms of asymmetric signing keys.
+const (
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// rsaKeyBits is the size of generated RSA keys.
+const rsaKeyBits = 2048
+
+// keyIDBytes is the number of random bytes of key IDs.
+const keyIDBytes = 12
+
+// JWKSMaxAge is how long verifiers may cache the published keys. Keys
+// have to be published at least this long before they sign.
+const JWKSMaxAge = 5 * time.Minute
+
+// TokenSigner creates access tokens and decodes them back to ID of the
+// user they were issued to.
+type TokenSigner interface {
+	CreateToken(id uint, expiration time.Duration) (string, error)
+	DecodeToken(token string) (uint, error)
+}
+
+// Secret signs tokens with HS256, see CreateToken. Every service which
+// verifies the tokens needs the secret.
+type Secret string
+
+// CreateToken implements TokenSigner.
+func (s Secret) CreateToken(id uint, expiration time.Duration) (string, error) {
+	return CreateToken(string(s), id, expiration)
+}
+
+// DecodeToken implements TokenSigner.
+func (s Secret) DecodeToken(token string) (uint, error) {
+	return DecodeToken(string(s), token)
+}
+
+// signingKey is a private key of the key set.
+type signingKey struct {
+	id      string
+	private crypto.Signer
+	// retiredAt is the time the key stopped signing or was removed from
+	// the loaded keys, it is zero for the current key and loaded keys.
+	retiredAt time.Time
+}
+
+// KeySet signs tokens with its current key and verifies them with the
+// key named by their kid header. Rotation retires the current key: it
+// doesn't sign anymore, but tokens signed with it are verified for the
+// overlap window, so they stay valid until they expire as long as the
+// overlap is longer than the tokens live. The key which signs after the
+// rotation is published a rotation ahead, so services caching the
+// published keys know it before the first token signed with it comes.
+// Only public keys are shared with other services, see JWKS.
+type KeySet struct {
+	alg     string
+	overlap time.Duration
+	now     func() time.Time
+
+	mu sync.RWMutex
+	// keys has the current key first.
+	keys []signingKey
+	// next is the key which becomes current on rotation. For loaded
+	// keys it is the new first key until the next load.
+	next *signingKey
+}
+
+var _ TokenSigner = &KeySet{}
+
+// NewKeySet creates key set of the algorithm with generated current and
+// next keys.
+func NewKeySet(alg string, overlap time.Duration) (*KeySet, error) {
+	if alg != RS256 && alg != EdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+	}
+	ks := &KeySet{alg: alg, overlap: overlap, now: time.Now}
+	if err := ks.Rotate(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// LoadKeySet creates key set of the algorithm with the private keys of
+// the PEM data, see Load.
+func LoadKeySet(alg string, overlap time.Duration, data []byte) (*KeySet, error) {
+	if alg != RS256 && alg != EdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", alg)
+	}
+	ks := &KeySet{alg: alg, overlap: overlap, now: time.Now}
+	if err := ks.Load(data); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Load merges the PKCS #8 or PKCS #1 private keys of the PEM data into
+// the set. The first key signs, the others only verify tokens, e.g. the
+// previous key until the tokens it signed expire. Key IDs are derived
+// from the public keys, so all services loading the same keys name them
+// the same. A first key which is not published yet is published as the
+// next key and signs from the following load on, until then the current
+// key keeps signing. Keys removed from the data are retired, they verify
+// tokens for the overlap window like rotated keys.
+func (ks *KeySet) Load(data []byte) error {
+	var loaded []signingKey
+	for {
+		var block *pem.Block
+		if block, data = pem.Decode(data); block == nil {
+			break
+		}
+		key, err := parseKey(ks.alg, block)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, key)
+	}
+	if len(loaded) == 0 {
+		return errors.New("no private key found")
+	}
+	now := ks.now()
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if len(ks.keys) == 0 {
+		ks.keys = loaded
+		return nil
+	}
+	current, next := loaded[0], (*signingKey)(nil)
+	if current.id != ks.keys[0].id && !ks.published(current.id, now) {
+		current, next = ks.keys[0], &loaded[0]
+	}
+	keys := []signingKey{current}
+	inData := make(map[string]bool, len(loaded))
+	for _, k := range loaded {
+		inData[k.id] = true
+		if k.id != current.id && k.id != loaded[0].id {
+			keys = append(keys, k)
+		}
+	}
+	for _, k := range ks.keys {
+		if k.id == current.id || inData[k.id] {
+			continue
+		}
+		if k.retiredAt.IsZero() {
+			k.retiredAt = now
+		}
+		if now.Sub(k.retiredAt) < ks.overlap {
+			keys = append(keys, k)
+		}
+	}
+	ks.keys, ks.next = keys, next
+	return nil
+}
+
+// published reports whether the key with the ID is in the published
+// keys, see JWKS. ks.mu must be held.
+func (ks *KeySet) published(id string, now time.Time) bool {
+	if ks.next != nil && ks.next.id == id {
+		return true
+	}
+	for _, k := range ks.keys {
+		if k.id == id && (k.retiredAt.IsZero() || now.Sub(k.retiredAt) < ks.overlap) {
+			return true
+		}
+	}
+	return false
+}
+
+// Algorithm returns the algorithm tokens are signed with.
+func (ks *KeySet) Algorithm() string {
+	return ks.alg
+}
+
+// Rotate makes the next key current, retires the previous one and
+// generates a new next key. Keys retired longer than the overlap ago
+// are removed. Key sets without a next key, e.g. loaded ones, only get
+// the new next key, so no key signs before it is published.
+func (ks *KeySet) Rotate() error {
+	next, err := generateKey(ks.alg)
+	if err != nil {
+		return err
+	}
+	ks.mu.RLock()
+	current, empty := ks.next, len(ks.keys) == 0
+	ks.mu.RUnlock()
+	if current == nil && empty {
+		key, err := generateKey(ks.alg)
+		if err != nil {
+			return err
+		}
+		current = &key
+	}
+	now := ks.now()
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if current == nil {
+		ks.next = &next
+		return nil
+	}
+	keys := []signingKey{*current}
+	for _, k := range ks.keys {
+		if k.retiredAt.IsZero() {
+			k.retiredAt = now
+		}
+		if now.Sub(k.retiredAt) < ks.overlap {
+			keys = append(keys, k)
+		}
+	}
+	ks.keys = keys
+	ks.next = &next
+	return nil
+}
+
+// CreateToken signs token of the user with the current key.
+func (ks *KeySet) CreateToken(id uint, expiration time.Duration) (string, error) {
+	ks.mu.RLock()
+	key := ks.keys[0]
+	ks.mu.RUnlock()
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.alg), tokenClaims(id, expiration))
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// DecodeToken verifies the token with the key of its kid header and
+// returns ID of the user.
+func (ks *KeySet) DecodeToken(token string) (uint, error) {
+	parser := jwt.Parser{ValidMethods: []string{ks.alg}}
+	parsed, err := parser.ParseWithClaims(stripBearer(token), jwt.MapClaims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := ks.key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key.private.Public(), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !parsed.Valid {
+		return 0, errors.New("session is no longer valid")
+	}
+	return claimsUserID(parsed.Claims.(jwt.MapClaims))
+}
+
+// key returns the key with the ID unless its overlap window is over.
+func (ks *KeySet) key(id string) (signingKey, bool) {
+	now := ks.now()
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, k := range ks.keys {
+		if k.id == id && (k.retiredAt.IsZero() || now.Sub(k.retiredAt) < ks.overlap) {
+			return k, true
+		}
+	}
+	return signingKey{}, false
+}
+
+// JWKS is a JSON Web Key Set, RFC 7517.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a public key in the JSON Web Key format. RSA keys have N and
+// E, Ed25519 keys have Curve and X.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys tokens are verified with, the current
+// key first and the next key last.
+func (ks *KeySet) JWKS() JWKS {
+	now := ks.now()
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	keys := ks.keys
+	if ks.next != nil {
+		keys = append(keys[:len(keys):len(keys)], *ks.next)
+	}
+	set := JWKS{Keys: make([]JWK, 0, len(keys))}
+	for _, k := range keys {
+		if !k.retiredAt.IsZero() && now.Sub(k.retiredAt) >= ks.overlap {
+			continue
+		}
+		jwk := JWK{ID: k.id, Use: "sig", Algorithm: ks.alg}
+		switch pub := k.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// parseKey parses private key of the algorithm from the PEM block.
+func parseKey(alg string, block *pem.Block) (signingKey, error) {
+	var (
+		private interface{}
+		err     error
+	)
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+	if err != nil {
+		return signingKey{}, err
+	}
+	var signer crypto.Signer
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if alg == RS256 {
+			signer = key
+		}
+	case ed25519.PrivateKey:
+		if alg == EdDSA {
+			signer = key
+		}
+	}
+	if signer == nil {
+		return signingKey{}, fmt.Errorf("%T is not a key of %s", private, alg)
+	}
+	public, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return signingKey{}, err
+	}
+	sum := sha256.Sum256(public)
+	return signingKey{id: base64.RawURLEncoding.EncodeToString(sum[:keyIDBytes]), private: signer}, nil
+}
+
+// generateKey generates private key of the algorithm with a random ID.
+func generateKey(alg string) (signingKey, error) {
+	id, err := RandomToken(keyIDBytes)
+	if err != nil {
+		return signingKey{}, err
+	}
+	key := signingKey{id: id}
+	switch alg {
+	case RS256:
+		key.private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case EdDSA:
+		_, key.private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported signing algorithm %s", alg)
+	}
+	return key, err
+}
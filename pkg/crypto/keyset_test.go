@@ -0,0 +1,237 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeySet(t *testing.T) {
+	for _, alg := range []string{RS256, EdDSA} {
+		ks, err := NewKeySet(alg, time.Hour)
+		require.NoError(t, err, alg)
+		token, err := ks.CreateToken(7, time.Minute)
+		require.NoError(t, err, alg)
+		parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err, alg)
+		assert.Equal(t, alg, parsed.Header["alg"])
+		assert.Equal(t, ks.JWKS().Keys[0].ID, parsed.Header["kid"])
+
+		id, err := ks.DecodeToken("Bearer " + token)
+		require.NoError(t, err, alg)
+		assert.Equal(t, uint(7), id)
+
+		expired, err := ks.CreateToken(7, -time.Second)
+		require.NoError(t, err, alg)
+		_, err = ks.DecodeToken(expired)
+		assert.Error(t, err, alg)
+
+		// tokens of another key set and HS256 tokens are not accepted
+		other, err := NewKeySet(alg, time.Hour)
+		require.NoError(t, err, alg)
+		_, err = other.DecodeToken(token)
+		assert.Error(t, err, alg)
+		hmac, err := CreateToken("secret", 7, time.Minute)
+		require.NoError(t, err)
+		_, err = ks.DecodeToken(hmac)
+		assert.Error(t, err, alg)
+	}
+	_, err := NewKeySet("HS256", time.Hour)
+	assert.Error(t, err)
+}
+
+func TestKeySetRotate(t *testing.T) {
+	now := time.Now()
+	ks, err := NewKeySet(EdDSA, time.Hour)
+	require.NoError(t, err)
+	ks.now = func() time.Time { return now }
+	old, err := ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+
+	// the next key is published before it signs
+	published := ks.JWKS().Keys
+	require.Len(t, published, 2)
+	assert.Equal(t, ks.next.id, published[1].ID)
+	_, err = ks.DecodeToken(signWithNext(t, ks))
+	assert.Error(t, err)
+
+	require.NoError(t, ks.Rotate())
+	keys := ks.JWKS().Keys
+	require.Len(t, keys, 3)
+	assert.Equal(t, published[1].ID, keys[0].ID)
+	assert.Equal(t, published[0].ID, keys[1].ID)
+	token, err := ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, published[1].ID, parsed.Header["kid"])
+
+	// the retired key verifies tokens during the overlap window
+	_, err = ks.DecodeToken(old)
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = ks.DecodeToken(old)
+	assert.Error(t, err)
+	assert.Len(t, ks.JWKS().Keys, 2)
+	_, err = ks.DecodeToken(token)
+	assert.NoError(t, err)
+
+	require.NoError(t, ks.Rotate())
+	assert.Len(t, ks.keys, 2)
+}
+
+// signWithNext signs token with the next key, which doesn't sign yet.
+func signWithNext(t *testing.T, ks *KeySet) string {
+	t.Helper()
+	key := *ks.next
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(ks.alg), tokenClaims(7, time.Minute))
+	token.Header["kid"] = key.id
+	signed, err := token.SignedString(key.private)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestLoadKeySet(t *testing.T) {
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, previous, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	var data []byte
+	for _, key := range []ed25519.PrivateKey{current, previous} {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})...)
+	}
+
+	// replicas and restarts loading the same keys accept each other's tokens
+	ks, err := LoadKeySet(EdDSA, time.Hour, data)
+	require.NoError(t, err)
+	keys := ks.JWKS().Keys
+	require.Len(t, keys, 2)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(current.Public().(ed25519.PublicKey)), keys[0].X)
+	token, err := ks.CreateToken(7, time.Minute)
+	require.NoError(t, err)
+	other, err := LoadKeySet(EdDSA, time.Hour, data)
+	require.NoError(t, err)
+	assert.Equal(t, keys, other.JWKS().Keys)
+	id, err := other.DecodeToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), id)
+
+	for _, tc := range []struct {
+		alg  string
+		data []byte
+	}{
+		{RS256, data},
+		{EdDSA, nil},
+		{EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: []byte("key")})},
+		{EdDSA, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")})},
+		{"HS256", data},
+	} {
+		_, err = LoadKeySet(tc.alg, time.Hour, tc.data)
+		assert.Error(t, err, tc.alg)
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	var keys [3][]byte
+	for i := range keys {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		keys[i] = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+	kid := func(token string) interface{} {
+		parsed, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		return parsed.Header["kid"]
+	}
+	now := time.Now()
+	ks, err := LoadKeySet(EdDSA, time.Hour, keys[0])
+	require.NoError(t, err)
+	ks.now = func() time.Time { return now }
+	old, err := ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	first := kid(old)
+
+	// the new key replacing the old one is published before it signs
+	require.NoError(t, ks.Load(keys[1]))
+	published := ks.JWKS().Keys
+	require.Len(t, published, 2)
+	assert.Equal(t, first, published[0].ID)
+	token, err := ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, first, kid(token))
+
+	// it signs from the next load, the old key verifies during the overlap
+	require.NoError(t, ks.Load(keys[1]))
+	token, err = ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, published[1].ID, kid(token))
+	_, err = ks.DecodeToken(old)
+	assert.NoError(t, err)
+	now = now.Add(time.Hour)
+	require.NoError(t, ks.Load(keys[1]))
+	_, err = ks.DecodeToken(old)
+	assert.Error(t, err)
+	assert.Len(t, ks.JWKS().Keys, 1)
+
+	// a key which verifies already signs right away
+	require.NoError(t, ks.Load(append(keys[1], keys[2]...)))
+	third := ks.JWKS().Keys[1].ID
+	require.NoError(t, ks.Load(append(keys[2], keys[1]...)))
+	token, err = ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, third, kid(token))
+	assert.Len(t, ks.keys, 2)
+	assert.Nil(t, ks.next)
+
+	// rotation of loaded keys only publishes the next key
+	require.NoError(t, ks.Rotate())
+	token, err = ks.CreateToken(7, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, third, kid(token))
+	assert.Len(t, ks.JWKS().Keys, 3)
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	for _, alg := range []string{RS256, EdDSA} {
+		ks, err := NewKeySet(alg, time.Hour)
+		require.NoError(t, err)
+		token, err := ks.CreateToken(7, time.Minute)
+		require.NoError(t, err)
+
+		// the token is verified with the published key only
+		jwk := ks.JWKS().Keys[0]
+		assert.Equal(t, "sig", jwk.Use)
+		assert.Equal(t, alg, jwk.Algorithm)
+		var public interface{}
+		switch jwk.KeyType {
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+			require.NoError(t, err)
+			e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+			require.NoError(t, err)
+			public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "OKP":
+			assert.Equal(t, "Ed25519", jwk.Curve)
+			x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+			require.NoError(t, err)
+			public = ed25519.PublicKey(x)
+		}
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) { return public, nil })
+		require.NoError(t, err, alg)
+		assert.True(t, parsed.Valid)
+	}
+}
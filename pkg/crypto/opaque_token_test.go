@@ -0,0 +1,20 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomToken(t *testing.T) {
+	token1, err := RandomToken(32)
+	assert.Empty(t, err)
+	token2, err := RandomToken(32)
+	assert.Empty(t, err)
+	assert.Len(t, token1, 43)
+	assert.NotEqual(t, token1, token2)
+
+	assert.Equal(t, HashToken(token1), HashToken(token1))
+	assert.NotEqual(t, HashToken(token1), HashToken(token2))
+	assert.Len(t, HashToken(token1), 64)
+}
@@ -2,6 +2,7 @@ package crypto
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -21,15 +22,7 @@ func stripBearer(tok string) string {
 // CreateToken ...
 func CreateToken(password string, id uint, expiration time.Duration) (string, error) {
 	token := jwt.New(jwt.GetSigningMethod("HS256"))
-	// Set some claims
-	exp := time.Now().Add(expiration).Unix()
-	m := jwt.MapClaims{
-		"Id": strconv.FormatUint(uint64(id), 10),
-	}
-	if expiration != 0 {
-		m["exp"] = exp
-	}
-	token.Claims = m
+	token.Claims = tokenClaims(id, expiration)
 	// Sign and get the complete encoded token as a string
 	tokenString, err := token.SignedString([]byte(password))
 	if err != nil {
@@ -38,9 +31,26 @@ func CreateToken(password string, id uint, expiration time.Duration) (string, er
 	return tokenString, nil
 }
 
+// tokenClaims returns claims of token of the user, the token doesn't
+// expire if expiration is 0.
+func tokenClaims(id uint, expiration time.Duration) jwt.MapClaims {
+	// Set some claims
+	m := jwt.MapClaims{
+		"Id": strconv.FormatUint(uint64(id), 10),
+	}
+	if expiration != 0 {
+		m["exp"] = time.Now().Add(expiration).Unix()
+	}
+	return m
+}
+
 // DecodeToken ...
 func DecodeToken(password string, token string) (uint, error) {
 	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		// the key is a secret, so only HMAC signatures are accepted
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
 		b := ([]byte(password))
 		return b, nil
 	}
@@ -52,8 +62,16 @@ func DecodeToken(password string, token string) (uint, error) {
 	if !tokenParsed.Valid {
 		return 0, errors.New("session is no longer valid")
 	}
-	claims := tokenParsed.Claims.(jwt.MapClaims)
-	id, err := strconv.ParseUint(claims["Id"].(string), 10, 64)
+	return claimsUserID(tokenParsed.Claims.(jwt.MapClaims))
+}
+
+// claimsUserID returns ID of the user the token was issued to.
+func claimsUserID(claims jwt.MapClaims) (uint, error) {
+	idClaim, ok := claims["Id"].(string)
+	if !ok {
+		return 0, errors.New("token has no user id")
+	}
+	id, err := strconv.ParseUint(idClaim, 10, 64)
 	if err != nil {
 		return 0, err
 	}
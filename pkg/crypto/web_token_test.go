@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,3 +29,16 @@ func TestWebTokenExpiration(t *testing.T) {
 	_, err = DecodeToken("test", "bearer "+token)
 	assert.Equal(t, "Token is expired", err.Error())
 }
+
+func TestWebTokenMalformed(t *testing.T) {
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"Id": "1"}).
+		SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.Empty(t, err)
+	_, err = DecodeToken("test", none)
+	assert.Error(t, err)
+
+	noID, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"Id": 1}).SignedString([]byte("test"))
+	assert.Empty(t, err)
+	_, err = DecodeToken("test", noID)
+	assert.Equal(t, "token has no user id", err.Error())
+}
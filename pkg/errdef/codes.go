@@ -2,6 +2,7 @@ package errdef
 
 import (
 	"errors"
+	"net/http"
 
 	"gocloud.dev/gcerrors"
 	"google.golang.org/grpc/codes"
@@ -196,6 +197,37 @@ func (c ErrorCode) ToGRPCCode() codes.Code {
 	}
 }
 
+// HTTPStatus gets the related http status code.
+func (c ErrorCode) HTTPStatus() int {
+	switch c {
+	case CodeOK:
+		return http.StatusOK
+	case CodeCanceled:
+		// non standard status used when client closes the request
+		return 499
+	case CodeInvalidArgument, CodeFailedPrecondition, CodeOutOfRange:
+		return http.StatusBadRequest
+	case CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeAlreadyExists, CodeAborted:
+		return http.StatusConflict
+	case CodePermissionDenied:
+		return http.StatusForbidden
+	case CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case CodeUnimplemented:
+		return http.StatusNotImplemented
+	case CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func fromGCErrors(code gcerrors.ErrorCode) ErrorCode {
 	switch code {
 	case gcerrors.OK:
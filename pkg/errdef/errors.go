@@ -7,8 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"os/exec"
+
+	uuid "github.com/satori/go.uuid"
 	"google.golang.org/grpc/status"
 )
 
@@ -53,14 +53,9 @@ func (e *Error) Error() string {
 	return string(data)
 }
 
-func generateUUID() string{
-	newUUID, err := exec.Command("uuidgen").Output()
-    if err != nil {
-        log.Fatal(err)
-    }
-	return string(newUUID)
-} 
-
+func generateUUID() string {
+	return uuid.NewV4().String()
+}
 
 // FromString parses string error into an Error structure.
 func FromString(err string) (*Error, bool) {
@@ -83,3 +83,24 @@ func TestErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPStatus(t *testing.T) {
+	testData := map[ErrorCode]int{
+		CodeOK:                 200,
+		CodeInvalidArgument:    400,
+		CodeFailedPrecondition: 400,
+		CodeUnauthenticated:    401,
+		CodePermissionDenied:   403,
+		CodeNotFound:           404,
+		CodeAlreadyExists:      409,
+		CodeResourceExhausted:  429,
+		CodeInternal:           500,
+		CodeUnknown:            500,
+		CodeUnavailable:        503,
+	}
+	for code, status := range testData {
+		if got := code.HTTPStatus(); got != status {
+			t.Fatalf("Expected %d for %s got %d", status, code, got)
+		}
+	}
+}
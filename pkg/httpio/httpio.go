@@ -0,0 +1,87 @@
+// Package httpio writes JSON responses and reads JSON requests.
+// Errors are written as RFC 7807 problem details with the status
+// matching their errdef code.
+package httpio
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+const (
+	// ContentTypeJSON is the media type of JSON responses.
+	ContentTypeJSON = "application/json"
+	// ContentTypeProblem is the media type of problem details.
+	ContentTypeProblem = "application/problem+json"
+)
+
+// Problem is the problem details object of RFC 7807 extended
+// with the members of errdef.Error.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	ID       string            `json:"id,omitempty"`
+	Code     string            `json:"code"`
+	Process  string            `json:"process,omitempty"`
+	Meta     map[string]string `json:"meta,omitempty"`
+}
+
+// NewProblem creates problem details of the error. Errors which are not
+// *errdef.Error don't expose their message, it could leak internals.
+func NewProblem(err error) Problem {
+	var e *errdef.Error
+	if !errors.As(err, &e) {
+		code := errdef.Code(err)
+		e = errdef.Wrap(err, code, http.StatusText(code.HTTPStatus()))
+	}
+	status := e.Code.HTTPStatus()
+	return Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  e.Detail,
+		ID:      e.ID,
+		Code:    e.Code.String(),
+		Process: e.Process,
+		Meta:    e.Meta,
+	}
+}
+
+// WriteJSON writes v encoded as JSON with given status.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", ContentTypeJSON)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("failed to write response:", err)
+	}
+}
+
+// WriteErr writes the error as problem details. Server errors are logged
+// with the full error, so they can be found by the ID returned to client.
+func WriteErr(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(err)
+	p.Instance = r.URL.Path
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("request %s %s failed [%s]: %s", r.Method, r.URL.Path, p.ID, err)
+	}
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(p.Status)
+	if err := json.NewEncoder(w).Encode(p); err != nil {
+		log.Println("failed to write response:", err)
+	}
+}
+
+// ReadJSON decodes request body into v.
+func ReadJSON(r *http.Request, v interface{}) *errdef.Error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errdef.Wrap(err, errdef.CodeInvalidArgument, "request body is not valid json")
+	}
+	return nil
+}
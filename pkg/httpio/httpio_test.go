@@ -0,0 +1,97 @@
+package httpio
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func TestWriteErr(t *testing.T) {
+	testCases := []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{
+			err:    errdef.ErrNotFound("person 1 not found").WithProcess("person").WithMeta("field", "id"),
+			status: http.StatusNotFound,
+			code:   "NotFound",
+			detail: "person 1 not found",
+		},
+		{
+			err:    errdef.ErrAlreadyExists("exists"),
+			status: http.StatusConflict,
+			code:   "AlreadyExists",
+			detail: "exists",
+		},
+		{
+			err:    errdef.ErrInvalidArgument("bad"),
+			status: http.StatusBadRequest,
+			code:   "InvalidArgument",
+			detail: "bad",
+		},
+		{
+			err:    errdef.ErrUnauthenticated("who are you"),
+			status: http.StatusUnauthorized,
+			code:   "Unauthenticated",
+			detail: "who are you",
+		},
+		{
+			err:    errors.New("pq: password authentication failed"),
+			status: http.StatusInternalServerError,
+			code:   "Unknown",
+			detail: "Internal Server Error",
+		},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/person/1", nil)
+		WriteErr(w, r, tc.err)
+
+		assert.Equal(t, tc.status, w.Code)
+		assert.Equal(t, ContentTypeProblem, w.Header().Get("Content-Type"))
+		var p Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		assert.Equal(t, tc.status, p.Status)
+		assert.Equal(t, tc.code, p.Code)
+		assert.Equal(t, tc.detail, p.Detail)
+		assert.Equal(t, http.StatusText(tc.status), p.Title)
+		assert.Equal(t, "/person/1", p.Instance)
+		assert.NotEmpty(t, p.ID)
+	}
+}
+
+func TestProblemMeta(t *testing.T) {
+	p := NewProblem(errdef.ErrNotFound("missing").WithProcess("person").WithMeta("field", "id"))
+	assert.Equal(t, "person", p.Process)
+	assert.Equal(t, map[string]string{"field": "id"}, p.Meta)
+}
+
+func TestWriteJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteJSON(w, http.StatusCreated, map[string]int{"a": 1})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, ContentTypeJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"a":1}`, w.Body.String())
+}
+
+func TestReadJSON(t *testing.T) {
+	var v struct{ Name string }
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"Name":"Jack"}`))
+	require.Nil(t, ReadJSON(r, &v))
+	assert.Equal(t, "Jack", v.Name)
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`))
+	err := ReadJSON(r, &v)
+	require.NotNil(t, err)
+	assert.True(t, errdef.IsInvalidArgument(err))
+}
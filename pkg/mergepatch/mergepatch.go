@@ -0,0 +1,49 @@
+// Package mergepatch implements JSON merge patch as defined in RFC 7386.
+package mergepatch
+
+import (
+	"encoding/json"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "merge_patch"
+
+// Apply applies the patch to the JSON document and returns the result.
+func Apply(doc, patch []byte) ([]byte, *errdef.Error) {
+	var target, p interface{}
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, errdef.Wrap(err, errdef.CodeInvalidArgument, "document is not valid json").WithProcess(ProcessName)
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInvalidArgument, "patch is not valid json").WithProcess(ProcessName)
+	}
+	result, err := json.Marshal(merge(target, p))
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to encode patched document").WithProcess(ProcessName)
+	}
+	return result, nil
+}
+
+// merge is the MergePatch function of the RFC.
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
@@ -0,0 +1,181 @@
+// Package migrate applies versioned SQL migrations and records them
+// in the schema_migrations table. Every migration is a pair of files
+// named <version>_<name>.up.sql and <version>_<name>.down.sql.
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "migrate"
+
+// Table is the name of the table applied migrations are recorded in.
+const Table = "schema_migrations"
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrations is list of migrations ordered by version.
+type Migrations []Migration
+
+// Status tells you if the migration was applied and when.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Applied tells you if the migration was applied.
+func (s Status) Applied() bool {
+	return s.AppliedAt != nil
+}
+
+// Load reads migrations from the root of fsys. Files not matching the
+// naming scheme are ignored. Every version must have both up and down file.
+func Load(fsys fs.FS) (Migrations, *errdef.Error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to read migrations").WithProcess(ProcessName)
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errdef.Wrapf(err, errdef.CodeInternal, "failed to read migration %s", entry.Name()).WithProcess(ProcessName)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, errdef.ErrInvalidArgumentf("migration %d has two names: %s and %s", version, m.Name, match[2]).WithProcess(ProcessName)
+		}
+		if match[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	mm := make(Migrations, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, errdef.ErrInvalidArgumentf("migration %d_%s must have up and down file", m.Version, m.Name).WithProcess(ProcessName)
+		}
+		mm = append(mm, *m)
+	}
+	sort.Slice(mm, func(i, j int) bool {
+		return mm[i].Version < mm[j].Version
+	})
+	return mm, nil
+}
+
+// Pending returns migrations which were not applied yet in the order
+// they should be applied.
+func (mm Migrations) Pending(applied map[int64]time.Time) Migrations {
+	var results Migrations
+	for _, m := range mm {
+		if _, ok := applied[m.Version]; !ok {
+			results = append(results, m)
+		}
+	}
+	return results
+}
+
+// Revertible returns up to n last applied migrations in the order they
+// should be reverted. Applied versions missing in mm are refused, as
+// they can't be reverted by this binary.
+func (mm Migrations) Revertible(applied map[int64]time.Time, n int) (Migrations, *errdef.Error) {
+	known := map[int64]Migration{}
+	for _, m := range mm {
+		known[m.Version] = m
+	}
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+	var results Migrations
+	for _, v := range versions {
+		if len(results) == n {
+			break
+		}
+		m, ok := known[v]
+		if !ok {
+			return nil, errdef.ErrFailedPreconditionf("applied migration %d is unknown", v).WithProcess(ProcessName)
+		}
+		results = append(results, m)
+	}
+	return results, nil
+}
+
+// Status combines migrations with the applied versions.
+func (mm Migrations) Status(applied map[int64]time.Time) []Status {
+	results := make([]Status, 0, len(mm))
+	for _, m := range mm {
+		s := Status{Migration: m}
+		if at, ok := applied[m.Version]; ok {
+			at := at
+			s.AppliedAt = &at
+		}
+		results = append(results, s)
+	}
+	return results
+}
+
+// Next returns version of the migration created after mm.
+func (mm Migrations) Next() int64 {
+	if len(mm) == 0 {
+		return 1
+	}
+	return mm[len(mm)-1].Version + 1
+}
+
+// FileNames returns names of the up and down files of the migration.
+func (m Migration) FileNames() (up, down string) {
+	base := fmt.Sprintf("%04d_%s", m.Version, m.Name)
+	return base + ".up.sql", base + ".down.sql"
+}
+
+// String returns the migration as version_name.
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// validName checks the name can be used in file names.
+var validName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// NewMigration creates empty migration following mm.
+func (mm Migrations) NewMigration(name string) (Migration, *errdef.Error) {
+	if !validName.MatchString(name) {
+		return Migration{}, errdef.ErrInvalidArgumentf("migration name %q must contain only a-z, 0-9 and _", name).WithProcess(ProcessName)
+	}
+	m := Migration{Version: mm.Next(), Name: name}
+	up, down := m.FileNames()
+	m.Up = fmt.Sprintf("-- %s\n", path.Base(up))
+	m.Down = fmt.Sprintf("-- %s\n", path.Base(down))
+	return m, nil
+}
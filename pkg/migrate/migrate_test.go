@@ -0,0 +1,103 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/migrations"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_add_email.up.sql":     {Data: []byte("ALTER TABLE a ADD email text;")},
+		"0002_add_email.down.sql":   {Data: []byte("ALTER TABLE a DROP email;")},
+		"0001_create_a.up.sql":      {Data: []byte("CREATE TABLE a (id int);")},
+		"0001_create_a.down.sql":    {Data: []byte("DROP TABLE a;")},
+		"0003_create_b.up.sql":      {Data: []byte("CREATE TABLE b (id int);")},
+		"0003_create_b.down.sql":    {Data: []byte("DROP TABLE b;")},
+		"README.md":                 {Data: []byte("ignored")},
+		"migrations.go":             {Data: []byte("package migrations")},
+		"0004_not_finished.up.sql~": {Data: []byte("ignored")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	mm, err := Load(testFS())
+	require.Nil(t, err)
+	require.Len(t, mm, 3)
+	assert.Equal(t, int64(1), mm[0].Version)
+	assert.Equal(t, "create_a", mm[0].Name)
+	assert.Equal(t, "DROP TABLE a;", mm[0].Down)
+	assert.Equal(t, "0003_create_b", mm[2].String())
+
+	fsys := testFS()
+	delete(fsys, "0003_create_b.down.sql")
+	_, err = Load(fsys)
+	assert.True(t, errdef.IsInvalidArgument(err))
+
+	fsys = testFS()
+	fsys["0003_create_c.down.sql"] = fsys["0003_create_b.down.sql"]
+	_, err = Load(fsys)
+	assert.NotNil(t, err)
+}
+
+func TestEmbedded(t *testing.T) {
+	mm, err := Load(migrations.FS)
+	require.Nil(t, err)
+	require.NotEmpty(t, mm)
+	for i, m := range mm {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions must be sequential")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	mm, err := Load(testFS())
+	require.Nil(t, err)
+	now := time.Now()
+	applied := map[int64]time.Time{1: now, 2: now}
+
+	pending := mm.Pending(applied)
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(3), pending[0].Version)
+
+	revert, err := mm.Revertible(applied, 5)
+	require.Nil(t, err)
+	require.Len(t, revert, 2)
+	assert.Equal(t, int64(2), revert[0].Version)
+	assert.Equal(t, int64(1), revert[1].Version)
+
+	revert, err = mm.Revertible(applied, 1)
+	require.Nil(t, err)
+	require.Len(t, revert, 1)
+
+	_, err = mm.Revertible(map[int64]time.Time{9: now}, 1)
+	assert.True(t, errdef.IsFailedPrecondition(err))
+
+	status := mm.Status(applied)
+	require.Len(t, status, 3)
+	assert.True(t, status[0].Applied())
+	assert.False(t, status[2].Applied())
+}
+
+func TestNewMigration(t *testing.T) {
+	mm, err := Load(testFS())
+	require.Nil(t, err)
+	m, err := mm.NewMigration("add_isbn")
+	require.Nil(t, err)
+	assert.Equal(t, int64(4), m.Version)
+	up, down := m.FileNames()
+	assert.Equal(t, "0004_add_isbn.up.sql", up)
+	assert.Equal(t, "0004_add_isbn.down.sql", down)
+
+	_, err = mm.NewMigration("Add ISBN")
+	assert.True(t, errdef.IsInvalidArgument(err))
+
+	m, err = Migrations{}.NewMigration("init")
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), m.Version)
+}
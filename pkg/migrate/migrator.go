@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// lockID is the key of the advisory lock held while migrating,
+// so two instances never migrate the same database at once.
+const lockID = 72707369
+
+// Migrator applies migrations to a PostgreSQL database.
+type Migrator struct {
+	db         *sql.DB
+	migrations Migrations
+}
+
+// New creates migrator of the database.
+func New(db *sql.DB, mm Migrations) *Migrator {
+	return &Migrator{db: db, migrations: mm}
+}
+
+// Migrations returns all known migrations.
+func (m *Migrator) Migrations() Migrations {
+	return m.migrations
+}
+
+// Status returns all known migrations with the time they were applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, *errdef.Error) {
+	applied, errSet := m.applied(ctx, m.db)
+	if errSet != nil {
+		return nil, errSet
+	}
+	return m.migrations.Status(applied), nil
+}
+
+// Pending returns migrations which were not applied yet.
+func (m *Migrator) Pending(ctx context.Context) (Migrations, *errdef.Error) {
+	applied, errSet := m.applied(ctx, m.db)
+	if errSet != nil {
+		return nil, errSet
+	}
+	return m.migrations.Pending(applied), nil
+}
+
+// Up applies up to n pending migrations, all of them if n is not positive.
+// Each migration runs in its own transaction together with its record
+// in the schema_migrations table. It returns the applied migrations.
+func (m *Migrator) Up(ctx context.Context, n int) (Migrations, *errdef.Error) {
+	var done Migrations
+	errSet := m.locked(ctx, func(conn *sql.Conn) *errdef.Error {
+		applied, errSet := m.applied(ctx, conn)
+		if errSet != nil {
+			return errSet
+		}
+		pending := m.migrations.Pending(applied)
+		if n > 0 && len(pending) > n {
+			pending = pending[:n]
+		}
+		for _, mig := range pending {
+			err := m.run(ctx, conn, mig.Up, `INSERT INTO `+Table+` (version, name) VALUES ($1, $2)`, mig.Version, mig.Name)
+			if err != nil {
+				return errdef.Wrapf(err, errdef.CodeInternal, "failed to apply migration %s", mig).WithProcess(ProcessName)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, errSet
+}
+
+// Down reverts n last applied migrations in reverse order and returns them.
+func (m *Migrator) Down(ctx context.Context, n int) (Migrations, *errdef.Error) {
+	if n < 1 {
+		return nil, errdef.ErrInvalidArgument("number of migrations to revert must be positive").WithProcess(ProcessName)
+	}
+	var done Migrations
+	errSet := m.locked(ctx, func(conn *sql.Conn) *errdef.Error {
+		applied, errSet := m.applied(ctx, conn)
+		if errSet != nil {
+			return errSet
+		}
+		revert, errSet := m.migrations.Revertible(applied, n)
+		if errSet != nil {
+			return errSet
+		}
+		for _, mig := range revert {
+			err := m.run(ctx, conn, mig.Down, `DELETE FROM `+Table+` WHERE version = $1`, mig.Version)
+			if err != nil {
+				return errdef.Wrapf(err, errdef.CodeInternal, "failed to revert migration %s", mig).WithProcess(ProcessName)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, errSet
+}
+
+// run executes the migration script and the bookkeeping statement in one transaction.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// locked runs fn on a connection holding the migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) *errdef.Error) *errdef.Error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return errdef.Wrap(err, errdef.CodeUnavailable, "failed to connect to database").WithProcess(ProcessName)
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return errdef.Wrap(err, errdef.CodeInternal, "failed to acquire migration lock").WithProcess(ProcessName)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+	return fn(conn)
+}
+
+// querier is implemented by both *sql.DB and *sql.Conn.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied returns versions recorded in the migrations table,
+// the table is created if it does not exist yet.
+func (m *Migrator) applied(ctx context.Context, q querier) (map[int64]time.Time, *errdef.Error) {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+Table+` (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamp with time zone NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to create migrations table").WithProcess(ProcessName)
+	}
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM `+Table)
+	if err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load applied migrations").WithProcess(ProcessName)
+	}
+	defer rows.Close()
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var (
+			version int64
+			at      time.Time
+		)
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load applied migrations").WithProcess(ProcessName)
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errdef.Wrap(err, errdef.CodeInternal, "failed to load applied migrations").WithProcess(ProcessName)
+	}
+	return applied, nil
+}
@@ -0,0 +1,38 @@
+package paging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Cursor points behind the last row of a page. It carries the sort
+// it was created for and the sort values of that row.
+type Cursor struct {
+	Sort   string        `json:"s"`
+	Values []interface{} `json:"v"`
+}
+
+// Encode encodes cursor into opaque url safe string.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes cursor created by Cursor.Encode.
+// Numbers are kept as json.Number so they keep their precision.
+func DecodeCursor(s string) (Cursor, *errdef.Error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errdef.Wrap(err, errdef.CodeInvalidArgument, "cursor is not valid").WithProcess(ProcessName)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil {
+		return c, errdef.Wrap(err, errdef.CodeInvalidArgument, "cursor is not valid").WithProcess(ProcessName)
+	}
+	return c, nil
+}
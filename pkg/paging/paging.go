@@ -0,0 +1,124 @@
+// Package paging parses list query parameters (limit, offset, cursor and sort)
+// and builds the paging metadata returned with list responses.
+package paging
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "paging"
+
+const (
+	// DefaultLimit is used when the request does not specify a limit.
+	DefaultLimit = 50
+	// MaxLimit is the highest limit a client can ask for.
+	MaxLimit = 500
+)
+
+// Params holds parsed paging parameters of a list request.
+type Params struct {
+	Limit     int
+	Offset    int
+	Cursor    *Cursor
+	Sort      Sort
+	WithTotal bool
+}
+
+// Parse reads paging parameters from query values.
+// Recognised keys are limit, offset, cursor, sort and total.
+// The sortable maps public field names to columns, defaultSort
+// is used if the request has no sort parameter.
+func Parse(q url.Values, sortable map[string]string, defaultSort string) (Params, *errdef.Error) {
+	p := Params{Limit: DefaultLimit}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return p, errdef.ErrInvalidArgumentf("limit must be between 1 and %d", MaxLimit).WithProcess(ProcessName)
+		}
+		p.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return p, errdef.ErrInvalidArgument("offset must be a positive number").WithProcess(ProcessName)
+		}
+		p.Offset = offset
+	}
+	if v := q.Get("total"); v != "" {
+		withTotal, err := strconv.ParseBool(v)
+		if err != nil {
+			return p, errdef.ErrInvalidArgument("total must be a boolean").WithProcess(ProcessName)
+		}
+		p.WithTotal = withTotal
+	}
+
+	sortStr := q.Get("sort")
+	if sortStr == "" {
+		sortStr = defaultSort
+	}
+	sort, errSet := ParseSort(sortStr, sortable)
+	if errSet != nil {
+		return p, errSet
+	}
+	p.Sort = sort
+
+	if v := q.Get("cursor"); v != "" {
+		if p.Offset != 0 {
+			return p, errdef.ErrInvalidArgument("cursor and offset can not be combined").WithProcess(ProcessName)
+		}
+		cursor, errSet := DecodeCursor(v)
+		if errSet != nil {
+			return p, errSet
+		}
+		if cursor.Sort != p.Sort.String() || len(cursor.Values) != len(p.Sort) {
+			return p, errdef.ErrInvalidArgument("cursor does not match the sort").WithProcess(ProcessName)
+		}
+		p.Cursor = &cursor
+	}
+	return p, nil
+}
+
+// Page is paging metadata returned with a list.
+type Page struct {
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	NextOffset int    `json:"next_offset,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// List is the envelope of a paged list response.
+type List struct {
+	Data   interface{} `json:"data"`
+	Paging Page        `json:"paging"`
+}
+
+// NewPage creates paging metadata for the result of a query that
+// fetched up to p.Limit+1 rows. The n is number of fetched rows,
+// last returns sort values of the last row of the page.
+// It returns the number of rows that belong to the page.
+func NewPage(p Params, n int, last func(i int) []interface{}) (Page, int) {
+	page := Page{Limit: p.Limit, Offset: p.Offset}
+	if n <= p.Limit {
+		return page, n
+	}
+	cursor := Cursor{Sort: p.Sort.String(), Values: last(p.Limit - 1)}
+	page.NextCursor = cursor.Encode()
+	return page, p.Limit
+}
+
+// NewOffsetPage works like NewPage for lists which can't be paged by
+// cursor, e.g. ordered by relevance. The next page is linked by offset.
+func NewOffsetPage(p Params, n int) (Page, int) {
+	page := Page{Limit: p.Limit, Offset: p.Offset}
+	if n <= p.Limit {
+		return page, n
+	}
+	page.NextOffset = p.Offset + p.Limit
+	return page, p.Limit
+}
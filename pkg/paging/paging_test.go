@@ -0,0 +1,147 @@
+package paging
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sortable = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"email": "email",
+}
+
+func TestParseSort(t *testing.T) {
+	s, err := ParseSort("name,-email", sortable)
+	require.Nil(t, err)
+	assert.Equal(t, "name,-email,id", s.String())
+	assert.Equal(t, "name ASC, email DESC, id ASC", s.OrderBy())
+
+	s, err = ParseSort("-id", sortable)
+	require.Nil(t, err)
+	assert.Equal(t, "-id", s.String())
+
+	_, err = ParseSort("password", sortable)
+	assert.NotNil(t, err)
+
+	_, err = ParseSort("name,-name", sortable)
+	assert.NotNil(t, err)
+}
+
+func TestSortAfter(t *testing.T) {
+	s, err := ParseSort("name,-email", sortable)
+	require.Nil(t, err)
+	cond, args := s.After([]interface{}{"a", "b", 3})
+	assert.Equal(t, "(name > ?) OR (name = ? AND email < ?) OR (name = ? AND email = ? AND id > ?)", cond)
+	assert.Equal(t, []interface{}{"a", "a", "b", "a", "b", 3}, args)
+}
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Sort: "name,id", Values: []interface{}{"jack", 12}}
+	decoded, err := DecodeCursor(c.Encode())
+	require.Nil(t, err)
+	assert.Equal(t, c.Sort, decoded.Sort)
+	assert.Equal(t, []interface{}{"jack", json.Number("12")}, decoded.Values)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.NotNil(t, err)
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(url.Values{}, sortable, "id")
+	require.Nil(t, err)
+	assert.Equal(t, DefaultLimit, p.Limit)
+	assert.Equal(t, "id", p.Sort.String())
+	assert.Nil(t, p.Cursor)
+
+	q := url.Values{"limit": {"10"}, "offset": {"20"}, "sort": {"-name"}, "total": {"true"}}
+	p, err = Parse(q, sortable, "id")
+	require.Nil(t, err)
+	assert.Equal(t, 10, p.Limit)
+	assert.Equal(t, 20, p.Offset)
+	assert.True(t, p.WithTotal)
+	assert.Equal(t, "-name,id", p.Sort.String())
+
+	cursor := Cursor{Sort: "-name,id", Values: []interface{}{"jack", 1}}
+	q = url.Values{"sort": {"-name"}, "cursor": {cursor.Encode()}}
+	p, err = Parse(q, sortable, "id")
+	require.Nil(t, err)
+	require.NotNil(t, p.Cursor)
+
+	testCases := []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"100000"}},
+		{"offset": {"-1"}},
+		{"total": {"maybe"}},
+		{"sort": {"name"}, "cursor": {cursor.Encode()}},
+		{"sort": {"-name"}, "offset": {"1"}, "cursor": {cursor.Encode()}},
+	}
+	for _, q := range testCases {
+		_, err := Parse(q, sortable, "id")
+		assert.NotNil(t, err, q.Encode())
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	p := Params{Limit: 2, Sort: Sort{{Name: "id", Column: "id"}}}
+	rows := []int{1, 2, 3}
+	last := func(i int) []interface{} { return []interface{}{rows[i]} }
+
+	page, n := NewPage(p, len(rows), last)
+	assert.Equal(t, 2, n)
+	require.NotEmpty(t, page.NextCursor)
+	c, err := DecodeCursor(page.NextCursor)
+	require.Nil(t, err)
+	assert.Equal(t, []interface{}{json.Number("2")}, c.Values)
+
+	page, n = NewPage(p, 2, last)
+	assert.Equal(t, 2, n)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestNewOffsetPage(t *testing.T) {
+	p := Params{Limit: 2, Offset: 4}
+
+	page, n := NewOffsetPage(p, 3)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 6, page.NextOffset)
+	assert.Empty(t, page.NextCursor)
+
+	page, n = NewOffsetPage(p, 1)
+	assert.Equal(t, 1, n)
+	assert.Zero(t, page.NextOffset)
+}
+
+func TestSlice(t *testing.T) {
+	type row struct {
+		id   uint
+		name string
+	}
+	rows := []row{{1, "b"}, {2, "a"}, {3, "b"}, {4, "c"}}
+	values := func(i int) []interface{} { return []interface{}{rows[i].name, rows[i].id} }
+	s, errSet := ParseSort("-name", sortable)
+	require.Nil(t, errSet)
+
+	p := Params{Limit: 2, Sort: s, WithTotal: true}
+	idx, total := Slice(p, len(rows), values)
+	assert.Equal(t, []int{3, 0, 2}, idx)
+	require.NotNil(t, total)
+	assert.Equal(t, int64(4), *total)
+
+	page, n := NewPage(p, len(idx), func(i int) []interface{} { return values(idx[i]) })
+	assert.Equal(t, 2, n)
+	c, errSet := DecodeCursor(page.NextCursor)
+	require.Nil(t, errSet)
+	p = Params{Limit: 2, Sort: s, Cursor: &c}
+	idx, total = Slice(p, len(rows), values)
+	assert.Equal(t, []int{2, 1}, idx)
+	assert.Nil(t, total)
+
+	p = Params{Limit: 2, Offset: 10, Sort: s}
+	idx, _ = Slice(p, len(rows), values)
+	assert.Empty(t, idx)
+}
@@ -0,0 +1,125 @@
+package paging
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Slice pages rows held in memory the same way a query ordered by
+// Sort.OrderBy and filtered by Sort.After would. The n is number of rows,
+// values returns sort values of a row. It returns indexes of up to
+// p.Limit+1 rows in page order, so the result can be passed to NewPage,
+// and the count of all rows if the client asked for it.
+func Slice(p Params, n int, values func(i int) []interface{}) ([]int, *int64) {
+	var total *int64
+	if p.WithTotal {
+		count := int64(n)
+		total = &count
+	}
+	idx := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		if p.Cursor != nil && p.Sort.Compare(values(i), p.Cursor.Values) <= 0 {
+			continue
+		}
+		idx = append(idx, i)
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return p.Sort.Compare(values(idx[a]), values(idx[b])) < 0
+	})
+	if p.Offset >= len(idx) {
+		return idx[:0], total
+	}
+	idx = idx[p.Offset:]
+	if len(idx) > p.Limit+1 {
+		idx = idx[:p.Limit+1]
+	}
+	return idx, total
+}
+
+// Compare compares two rows by their sort values respecting the direction
+// of each field. Values decoded from cursor are compared with the typed
+// values of a row, so numbers and times are compared by their value.
+func (s Sort) Compare(a, b []interface{}) int {
+	for i, f := range s {
+		c := compareValues(a[i], b[i])
+		if f.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := toTime(a); ok {
+		if y, ok := toTime(b); ok {
+			switch {
+			case x.Before(y):
+				return -1
+			case x.After(y):
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toTime(v interface{}) (time.Time, bool) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, true
+	case *time.Time:
+		if x == nil {
+			return time.Time{}, false
+		}
+		return *x, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, x)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case int:
+		return float64(x), true
+	case int64:
+		return float64(x), true
+	case uint:
+		return float64(x), true
+	case uint64:
+		return float64(x), true
+	case float64:
+		return x, true
+	case json.Number:
+		f, err := x.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
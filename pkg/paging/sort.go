@@ -0,0 +1,107 @@
+package paging
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// TieBreaker is the field appended to every sort so the order is total.
+const TieBreaker = "id"
+
+// SortField is single field of a sort with its direction.
+type SortField struct {
+	Name   string
+	Column string
+	Desc   bool
+}
+
+// Sort is ordered list of fields.
+type Sort []SortField
+
+// ParseSort parses comma separated list of field names, each optionally
+// prefixed with '-' for descending order, e.g. "author,-created_at".
+// Only fields present in sortable (public name to column) are accepted.
+// The "id" field is always appended unless already present.
+func ParseSort(s string, sortable map[string]string) (Sort, *errdef.Error) {
+	var sort Sort
+	seen := map[string]bool{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := SortField{}
+		if strings.HasPrefix(part, "-") {
+			f.Desc = true
+			part = part[1:]
+		}
+		column, ok := sortable[part]
+		if !ok {
+			return nil, errdef.ErrInvalidArgumentf("can not sort by '%s'", part).WithProcess(ProcessName)
+		}
+		if seen[part] {
+			return nil, errdef.ErrInvalidArgumentf("sort field '%s' is repeated", part).WithProcess(ProcessName)
+		}
+		seen[part] = true
+		f.Name = part
+		f.Column = column
+		sort = append(sort, f)
+	}
+	if !seen[TieBreaker] {
+		sort = append(sort, SortField{Name: TieBreaker, Column: TieBreaker})
+	}
+	return sort, nil
+}
+
+// String returns the sort in the same format ParseSort accepts.
+func (s Sort) String() string {
+	parts := make([]string, 0, len(s))
+	for _, f := range s {
+		if f.Desc {
+			parts = append(parts, "-"+f.Name)
+			continue
+		}
+		parts = append(parts, f.Name)
+	}
+	return strings.Join(parts, ",")
+}
+
+// OrderBy returns SQL order clause.
+func (s Sort) OrderBy() string {
+	parts := make([]string, 0, len(s))
+	for _, f := range s {
+		if f.Desc {
+			parts = append(parts, f.Column+" DESC")
+			continue
+		}
+		parts = append(parts, f.Column+" ASC")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// After returns SQL condition selecting rows that follow the row
+// with given sort values. Mixed directions are supported, so the condition
+// is expanded as (a > ?) OR (a = ? AND b < ?) OR ...
+func (s Sort) After(values []interface{}) (string, []interface{}) {
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, f := range s {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, fmt.Sprintf("%s = ?", s[j].Column))
+			args = append(args, values[j])
+		}
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		ands = append(ands, fmt.Sprintf("%s %s ?", f.Column, op))
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return strings.Join(ors, " OR "), args
+}
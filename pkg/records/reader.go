@@ -0,0 +1,197 @@
+package records
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// Row tells where the decoded row was in the input and which
+// fields it sets. Fields are names of the struct fields.
+type Row struct {
+	Line   int
+	Fields []string
+}
+
+// Reader decodes rows of CSV or NDJSON input into structs.
+type Reader struct {
+	format Format
+	csv    *csv.Reader
+	header []string
+	lines  *bufio.Reader
+	line   int
+}
+
+// NewReader creates reader of the input. Rows are decoded into values
+// of the type of v, which must be a pointer to struct. CSV header is
+// read right away and it is an error if it has unknown columns.
+func NewReader(r io.Reader, format Format, v interface{}) (*Reader, *errdef.Error) {
+	rd := &Reader{format: format}
+	switch format {
+	case CSV:
+		rd.csv = csv.NewReader(r)
+		rd.csv.TrimLeadingSpace = true
+		header, err := rd.csv.Read()
+		if err == io.EOF {
+			return nil, errdef.ErrInvalidArgument("csv has no header").WithProcess(ProcessName)
+		}
+		if err != nil {
+			return nil, errdef.Wrap(err, errdef.CodeInvalidArgument, "failed to read csv header").WithProcess(ProcessName)
+		}
+		t := reflect.TypeOf(v).Elem()
+		for i, column := range header {
+			f, ok := field(t, strings.TrimSpace(column))
+			if !ok {
+				return nil, errdef.ErrInvalidArgumentf("unknown column '%s'", column).WithProcess(ProcessName).WithMeta("line", "1")
+			}
+			header[i] = f.Name
+		}
+		rd.header = header
+	case NDJSON:
+		rd.lines = bufio.NewReader(r)
+	default:
+		return nil, errdef.ErrInvalidArgumentf("%s can not be imported, use csv or ndjson", format).WithProcess(ProcessName).WithMeta("field", "format")
+	}
+	return rd, nil
+}
+
+// Next decodes the next row into v. It returns io.EOF when there are
+// no more rows. A row which can't be decoded is reported by
+// *errdef.Error with the line in meta, reading can go on after it.
+// Any other error, e.g. malformed CSV quoting, means the input can't
+// be read further.
+func (rd *Reader) Next(v interface{}) (Row, error) {
+	if rd.format == CSV {
+		return rd.nextCSV(v)
+	}
+	return rd.nextNDJSON(v)
+}
+
+func (rd *Reader) nextCSV(v interface{}) (Row, error) {
+	record, err := rd.csv.Read()
+	if pe, ok := err.(*csv.ParseError); ok {
+		row := Row{Line: pe.StartLine}
+		if pe.Err == csv.ErrFieldCount {
+			return row, rowError(pe.Err, row.Line)
+		}
+		return row, err
+	}
+	if err != nil {
+		return Row{}, err
+	}
+	line, _ := rd.csv.FieldPos(0)
+	row := Row{Line: line}
+	val := reflect.ValueOf(v).Elem()
+	val.Set(reflect.Zero(val.Type()))
+	for i, s := range record {
+		name := rd.header[i]
+		if err := setField(val.FieldByName(name), s); err != nil {
+			return row, rowError(err, row.Line).WithMeta("field", name)
+		}
+		row.Fields = append(row.Fields, name)
+	}
+	return row, nil
+}
+
+func (rd *Reader) nextNDJSON(v interface{}) (Row, error) {
+	for {
+		data, err := rd.lines.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return Row{}, err
+		}
+		rd.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		row := Row{Line: rd.line}
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil {
+			return row, rowError(err, row.Line)
+		}
+		val := reflect.ValueOf(v).Elem()
+		val.Set(reflect.Zero(val.Type()))
+		for name, raw := range members {
+			f, ok := field(val.Type(), name)
+			if !ok {
+				return row, rowError(errdef.ErrInvalidArgumentf("unknown member '%s'", name), row.Line)
+			}
+			if err := json.Unmarshal(raw, val.FieldByIndex(f.Index).Addr().Interface()); err != nil {
+				return row, rowError(err, row.Line).WithMeta("field", f.Name)
+			}
+			row.Fields = append(row.Fields, f.Name)
+		}
+		return row, nil
+	}
+}
+
+// rowError reports row which can't be decoded.
+func rowError(err error, line int) *errdef.Error {
+	detail := err.Error()
+	if e, ok := err.(*errdef.Error); ok {
+		detail = e.Detail
+	}
+	return errdef.Wrap(err, errdef.CodeInvalidArgument, detail).
+		WithProcess(ProcessName).
+		WithMeta("line", strconv.Itoa(line))
+}
+
+var textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setField sets the field from CSV value. Empty value is zero value.
+func setField(f reflect.Value, s string) error {
+	if s == "" {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+	if f.Kind() == reflect.Ptr {
+		p := reflect.New(f.Type().Elem())
+		if err := setField(p.Elem(), s); err != nil {
+			return err
+		}
+		f.Set(p)
+		return nil
+	}
+	if f.Addr().Type().Implements(textUnmarshaler) {
+		return f.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return errdef.ErrInvalidArgumentf("%s can not be read from csv", f.Type())
+	}
+	return nil
+}
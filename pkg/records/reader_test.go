@@ -0,0 +1,122 @@
+package records
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+type item struct {
+	ID         uint
+	Title      string
+	CallNumber int
+	Lent       bool
+	Price      *float64
+}
+
+// readAll reads all rows, decode errors are collected by line.
+func readAll(t *testing.T, rd *Reader) ([]item, []Row, map[int]*errdef.Error) {
+	t.Helper()
+	var (
+		items []item
+		rows  []Row
+	)
+	failed := map[int]*errdef.Error{}
+	for {
+		var it item
+		row, err := rd.Next(&it)
+		if err == io.EOF {
+			return items, rows, failed
+		}
+		if err != nil {
+			errSet, ok := err.(*errdef.Error)
+			require.True(t, ok, err)
+			failed[row.Line] = errSet
+			continue
+		}
+		items = append(items, it)
+		rows = append(rows, row)
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	input := "title,call_number,Lent,price\n" +
+		"Go,1,true,9.5\n" +
+		"Rust,x,false,\n" +
+		"\"Multi\nline\",3,,\n" +
+		"Short,4\n" +
+		"Zig,5,false,1\n"
+	rd, err := NewReader(strings.NewReader(input), CSV, &item{})
+	require.Nil(t, err)
+
+	items, rows, failed := readAll(t, rd)
+	require.Len(t, items, 3)
+	assert.Equal(t, "Go", items[0].Title)
+	assert.Equal(t, 1, items[0].CallNumber)
+	assert.True(t, items[0].Lent)
+	require.NotNil(t, items[0].Price)
+	assert.Equal(t, 9.5, *items[0].Price)
+	assert.Equal(t, []string{"Title", "CallNumber", "Lent", "Price"}, rows[0].Fields)
+
+	assert.Equal(t, "Multi\nline", items[1].Title)
+	assert.Nil(t, items[1].Price)
+	assert.Equal(t, 4, rows[1].Line)
+	assert.Equal(t, 7, rows[2].Line)
+
+	require.Len(t, failed, 2)
+	assert.Equal(t, "CallNumber", failed[3].Meta["field"])
+	assert.Equal(t, "3", failed[3].Meta["line"])
+	assert.Contains(t, failed, 6)
+
+	_, err = NewReader(strings.NewReader("title,isbn\n"), CSV, &item{})
+	require.NotNil(t, err)
+	assert.True(t, errdef.IsInvalidArgument(err))
+}
+
+func TestReadNDJSON(t *testing.T) {
+	input := `{"Title":"Go","call_number":1}` + "\n" +
+		"\n" +
+		`{"Title":"Rust","CallNumber":"x"}` + "\n" +
+		`{"Title":"Zig","Author":"Andrew"}` + "\n" +
+		`not json` + "\n" +
+		`{"ID":7,"Lent":true}`
+	rd, err := NewReader(strings.NewReader(input), NDJSON, &item{})
+	require.Nil(t, err)
+
+	items, rows, failed := readAll(t, rd)
+	require.Len(t, items, 2)
+	assert.Equal(t, item{Title: "Go", CallNumber: 1}, items[0])
+	assert.Equal(t, 1, rows[0].Line)
+	assert.ElementsMatch(t, []string{"Title", "CallNumber"}, rows[0].Fields)
+	assert.Equal(t, item{ID: 7, Lent: true}, items[1])
+	assert.Equal(t, 6, rows[1].Line)
+
+	require.Len(t, failed, 3)
+	for _, line := range []int{3, 4, 5} {
+		assert.Contains(t, failed, line)
+	}
+
+	_, err = NewReader(strings.NewReader("[]"), JSON, &item{})
+	require.NotNil(t, err)
+}
+
+func TestFormatOf(t *testing.T) {
+	f, ok := FormatOf("books.ndjson.gz", "")
+	assert.True(t, ok)
+	assert.Equal(t, NDJSON, f)
+
+	f, ok = FormatOf("", "text/csv; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, CSV, f)
+
+	_, ok = FormatOf("books.txt", "text/plain")
+	assert.False(t, ok)
+
+	_, err := ParseFormat("xml")
+	assert.True(t, errdef.IsInvalidArgument(err))
+}
@@ -0,0 +1,100 @@
+// Package records reads and writes rows of models as CSV, NDJSON or JSON.
+// CSV columns and JSON members are matched to struct fields by name
+// ignoring case and underscores, so "call_number" is CallNumber.
+package records
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// ProcessName is the constant used to store the errdef key value.
+const ProcessName = "records"
+
+// Format is encoding of the rows.
+type Format int
+
+const (
+	// CSV is comma separated values with header row.
+	CSV Format = iota
+	// NDJSON is one JSON object per line.
+	NDJSON
+	// JSON is JSON array of objects.
+	JSON
+)
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	switch f {
+	case CSV:
+		return "csv"
+	case NDJSON:
+		return "ndjson"
+	case JSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// ContentType returns media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case CSV:
+		return "text/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// ParseFormat parses format from its string form.
+func ParseFormat(s string) (Format, *errdef.Error) {
+	for _, f := range []Format{CSV, NDJSON, JSON} {
+		if strings.EqualFold(f.String(), s) {
+			return f, nil
+		}
+	}
+	return CSV, errdef.ErrInvalidArgument("invalid format value: "+s).WithProcess(ProcessName).WithMeta("field", "format")
+}
+
+// FormatOf guesses format from the file extension or the media type.
+// It returns false if neither is known.
+func FormatOf(path, contentType string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(path, ".gz"))) {
+	case ".csv":
+		return CSV, true
+	case ".ndjson", ".jsonl":
+		return NDJSON, true
+	case ".json":
+		return JSON, true
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	switch strings.ToLower(mediaType) {
+	case "text/csv":
+		return CSV, true
+	case "application/x-ndjson", "application/jsonl":
+		return NDJSON, true
+	case "application/json":
+		return JSON, true
+	}
+	return CSV, false
+}
+
+// fieldKey normalises column or field name for matching.
+func fieldKey(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+// field finds field of the struct type by column name. Fields of
+// embedded structs are found too, e.g. ID of gorm.Model.
+func field(t reflect.Type, name string) (reflect.StructField, bool) {
+	key := fieldKey(name)
+	return t.FieldByNameFunc(func(n string) bool {
+		return fieldKey(n) == key
+	})
+}
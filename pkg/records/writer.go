@@ -0,0 +1,201 @@
+package records
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Writer encodes rows one by one, so they can be streamed. Close must
+// be called after the last row to finish the output.
+type Writer struct {
+	format  Format
+	w       io.Writer
+	csv     *csv.Writer
+	columns []column
+	rows    int
+}
+
+// column is a CSV column of struct field.
+type column struct {
+	name  string
+	index []int
+}
+
+// NewWriter creates writer of rows of the type of v, which must be
+// a struct or a pointer to struct. CSV has a column for every field
+// which holds a single value, fields of embedded structs included.
+// Columns are named in snake case, so the output can be imported back.
+func NewWriter(w io.Writer, format Format, v interface{}) *Writer {
+	wr := &Writer{format: format, w: w}
+	if format == CSV {
+		wr.csv = csv.NewWriter(w)
+		t := reflect.TypeOf(v)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		wr.columns = columns(t, nil)
+	}
+	return wr
+}
+
+// Write encodes the row.
+func (wr *Writer) Write(v interface{}) error {
+	wr.rows++
+	switch wr.format {
+	case CSV:
+		if wr.rows == 1 {
+			if err := wr.writeHeader(); err != nil {
+				return err
+			}
+		}
+		val := reflect.Indirect(reflect.ValueOf(v))
+		record := make([]string, len(wr.columns))
+		for i, c := range wr.columns {
+			s, err := formatField(val.FieldByIndex(c.index))
+			if err != nil {
+				return err
+			}
+			record[i] = s
+		}
+		return wr.csv.Write(record)
+	case JSON:
+		sep := ",\n"
+		if wr.rows == 1 {
+			sep = "[\n"
+		}
+		if _, err := io.WriteString(wr.w, sep); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = wr.w.Write(data)
+		return err
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = wr.w.Write(append(data, '\n'))
+		return err
+	}
+}
+
+// Close finishes the output, it doesn't close the underlying writer.
+// CSV without rows has only the header.
+func (wr *Writer) Close() error {
+	switch wr.format {
+	case CSV:
+		if wr.rows == 0 {
+			if err := wr.writeHeader(); err != nil {
+				return err
+			}
+		}
+		wr.csv.Flush()
+		return wr.csv.Error()
+	case JSON:
+		end := "\n]\n"
+		if wr.rows == 0 {
+			end = "[]\n"
+		}
+		_, err := io.WriteString(wr.w, end)
+		return err
+	}
+	return nil
+}
+
+func (wr *Writer) writeHeader() error {
+	header := make([]string, len(wr.columns))
+	for i, c := range wr.columns {
+		header[i] = c.name
+	}
+	return wr.csv.Write(header)
+}
+
+var (
+	textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// columns lists fields of the struct type which hold a single value.
+func columns(t reflect.Type, index []int) []column {
+	var cc []column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		idx := append(append([]int{}, index...), i)
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch {
+		case f.Anonymous && ft.Kind() == reflect.Struct:
+			cc = append(cc, columns(ft, idx)...)
+		case ft == timeType || reflect.PtrTo(ft).Implements(textMarshaler):
+			cc = append(cc, column{name: snakeCase(f.Name), index: idx})
+		case ft.Kind() == reflect.Struct, ft.Kind() == reflect.Slice, ft.Kind() == reflect.Map,
+			ft.Kind() == reflect.Interface, ft.Kind() == reflect.Func, ft.Kind() == reflect.Chan:
+			// nested values don't fit in a column
+		default:
+			cc = append(cc, column{name: snakeCase(f.Name), index: idx})
+		}
+	}
+	return cc
+}
+
+// formatField formats the field as CSV value. Nil is empty value.
+func formatField(f reflect.Value) (string, error) {
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return "", nil
+		}
+		f = f.Elem()
+	}
+	if f.Type() == timeType {
+		return f.Interface().(time.Time).UTC().Format(time.RFC3339Nano), nil
+	}
+	if m, ok := f.Interface().(encoding.TextMarshaler); ok {
+		text, err := m.MarshalText()
+		return string(text), err
+	}
+	switch f.Kind() {
+	case reflect.String:
+		return f.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(f.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(f.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(f.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(f.Float(), 'f', -1, f.Type().Bits()), nil
+	}
+	return "", nil
+}
+
+// snakeCase converts field name to snake case, e.g. PersonID to person_id.
+func snakeCase(name string) string {
+	rr := []rune(name)
+	var sb strings.Builder
+	for i, r := range rr {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(rr[i-1])
+			nextLower := i+1 < len(rr) && unicode.IsLower(rr[i+1])
+			if prevLower || (unicode.IsUpper(rr[i-1]) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package records
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type base struct {
+	ID        uint
+	CreatedAt time.Time
+	DeletedAt *time.Time
+}
+
+type row struct {
+	base
+	Title    string
+	PersonID int
+	Price    float64
+	Tags     []string
+	secret   string
+}
+
+func writeAll(t *testing.T, format Format, rows ...row) string {
+	t.Helper()
+	var buf bytes.Buffer
+	wr := NewWriter(&buf, format, &row{})
+	for _, r := range rows {
+		require.NoError(t, wr.Write(r))
+	}
+	require.NoError(t, wr.Close())
+	return buf.String()
+}
+
+func TestWriteCSV(t *testing.T) {
+	created := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+	out := writeAll(t, CSV,
+		row{base: base{ID: 1, CreatedAt: created}, Title: "Go, the language", PersonID: 2, Price: 9.5, secret: "x"},
+		row{base: base{ID: 2, CreatedAt: created, DeletedAt: &created}, Title: "Rust"},
+	)
+	assert.Equal(t, "id,created_at,deleted_at,title,person_id,price\n"+
+		"1,2022-03-01T10:00:00Z,,\"Go, the language\",2,9.5\n"+
+		"2,2022-03-01T10:00:00Z,2022-03-01T10:00:00Z,Rust,0,0\n", out)
+
+	assert.Equal(t, "id,created_at,deleted_at,title,person_id,price\n", writeAll(t, CSV))
+
+	// the output can be read back
+	rd, err := NewReader(strings.NewReader(out), CSV, &row{})
+	require.Nil(t, err)
+	var r row
+	_, rowErr := rd.Next(&r)
+	require.NoError(t, rowErr)
+	assert.Equal(t, "Go, the language", r.Title)
+	assert.True(t, created.Equal(r.CreatedAt))
+}
+
+func TestWriteJSON(t *testing.T) {
+	assert.Equal(t, "[]\n", writeAll(t, JSON))
+	out := writeAll(t, JSON, row{Title: "Go"}, row{Title: "Rust"})
+	assert.True(t, strings.HasPrefix(out, `[`+"\n"+`{"ID":0,`), out)
+	assert.Contains(t, out, "},\n{")
+	assert.True(t, strings.HasSuffix(out, "}\n]\n"), out)
+
+	out = writeAll(t, NDJSON, row{Title: "Go"}, row{Title: "Rust"})
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"Title":"Rust"`)
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, want := range map[string]string{
+		"ID":           "id",
+		"PersonID":     "person_id",
+		"CallNumber":   "call_number",
+		"ISBN":         "isbn",
+		"HTTPServer":   "http_server",
+		"CheckedOutAt": "checked_out_at",
+	} {
+		assert.Equal(t, want, snakeCase(name))
+	}
+}
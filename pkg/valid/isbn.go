@@ -0,0 +1,44 @@
+package valid
+
+// ISBN10 validates ISBN-10 without hyphens. The last character is
+// a checksum digit or X standing for 10.
+func ISBN10(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 10; i++ {
+		var d int
+		switch c := s[i]; {
+		case c >= '0' && c <= '9':
+			d = int(c - '0')
+		case (c == 'X' || c == 'x') && i == 9:
+			d = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * d
+	}
+	return sum%11 == 0
+}
+
+// ISBN13 validates ISBN-13 without hyphens. Digits are weighted
+// alternately by 1 and 3, the last one is the checksum.
+func ISBN13(s string) bool {
+	if len(s) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 13; i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
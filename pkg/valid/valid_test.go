@@ -48,3 +48,27 @@ func TestRelativePath(t *testing.T) {
 		assert.Equal(t, tc.ok, RelativePath(tc.path), tc.label)
 	}
 }
+
+func TestISBN(t *testing.T) {
+	testCases := []struct {
+		isbn   string
+		isbn10 bool
+		isbn13 bool
+	}{
+		{isbn: "0306406152", isbn10: true},
+		{isbn: "080442957X", isbn10: true},
+		{isbn: "080442957x", isbn10: true},
+		{isbn: "0306406153"},
+		{isbn: "X306406152"},
+		{isbn: "030640615"},
+		{isbn: "9780306406157", isbn13: true},
+		{isbn: "9780306406158"},
+		{isbn: "978030640615X"},
+		{isbn: "978-0306406157"},
+		{isbn: ""},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, tc.isbn10, ISBN10(tc.isbn), tc.isbn)
+		assert.Equal(t, tc.isbn13, ISBN13(tc.isbn), tc.isbn)
+	}
+}
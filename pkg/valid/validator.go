@@ -41,19 +41,19 @@ func (ff Flags) Has(flag Flag) bool {
 }
 
 // Validator is an interface used to validate structures.
-// If failed it returns errdef.ErrSet error.
+// If failed it returns *errdef.Error.
 type Validator interface {
-	Validate() errdef.ErrSet
+	Validate() *errdef.Error
 }
 
 type validatorWithFlags interface {
-	Validate(Flags) errdef.ErrSet
+	Validate(Flags) *errdef.Error
 }
 
 // Validate will take input and run validations if input has any.
 // You can also specify flags which can move validation vector, depends on your
 // need of validation
-func Validate(result interface{}, flags ...Flagger) errdef.ErrSet {
+func Validate(result interface{}, flags ...Flagger) *errdef.Error {
 	v1, ok := result.(Validator)
 	if ok {
 		return v1.Validate()
@@ -19,12 +19,12 @@ func TestFlags(t *testing.T) {
 
 type input struct {
 	err      *errdef.Error
-	callback func() errdef.Error
+	callback func() *errdef.Error
 }
 
 var _ Validator = &input{}
 
-func (i *input) Validate() errdef.Error {
+func (i *input) Validate() *errdef.Error {
 	if i.callback != nil {
 		return i.callback()
 	}
@@ -32,13 +32,13 @@ func (i *input) Validate() errdef.Error {
 }
 
 type inputT2 struct {
-	err      errdef.Error
-	callback func(Flags) errdef.Error
+	err      *errdef.Error
+	callback func(Flags) *errdef.Error
 }
 
 var _ validatorWithFlags = &inputT2{}
 
-func (i *inputT2) Validate(f Flags) errdef.Error {
+func (i *inputT2) Validate(f Flags) *errdef.Error {
 	if i.callback != nil {
 		return i.callback(f)
 	}
@@ -48,22 +48,22 @@ func (i *inputT2) Validate(f Flags) errdef.Error {
 func TestValidate(t *testing.T) {
 	i1 := &input{}
 	assert.Nil(t, Validate(i1))
-	err := errdef.ErrInternalf("validation", "very bad bad validation")
+	err := errdef.ErrInternal("very bad bad validation")
 	i2 := &input{err: err}
 	assert.Equal(t, err, Validate(i2))
 	f1 := Flag("hello")
 	i3 := &inputT2{
-		callback: func(ff Flags) errdef.Error {
+		callback: func(ff Flags) *errdef.Error {
 			assert.True(t, ff.Has(f1))
-			return *err
+			return err
 		},
 	}
 	assert.Equal(t, err, Validate(i3, f1))
 	i4 := &inputT2{
-		callback: func(ff Flags) errdef.Error {
+		callback: func(ff Flags) *errdef.Error {
 			assert.False(t, ff.Has(f1))
 			return nil
 		},
 	}
-	assert.Equal(t, nil, Validate(i4))
+	assert.Nil(t, Validate(i4))
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/recommend"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+)
+
+// Recommendations are computed from the whole loan history by a
+// periodic job, requests only read the latest model.
+
+const (
+	recommendationInterval = time.Hour
+	// defaultRecommendations is the number of books returned when the
+	// request does not specify a limit.
+	defaultRecommendations = 10
+	maxRecommendations     = 100
+)
+
+// recommendation is a recommended book in the response.
+type recommendation struct {
+	Book   book.Book
+	Score  float64
+	Reason recommend.Reason
+}
+
+type recommendationList struct {
+	Data []recommendation
+	// BuiltAt is the time the recommendations were computed.
+	BuiltAt time.Time
+}
+
+// recommender holds the latest model, it is replaced by the refresh job.
+type recommender struct {
+	mu    sync.RWMutex
+	model *recommend.Model
+}
+
+func (r *recommender) get() *recommend.Model {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.model
+}
+
+func (r *recommender) set(m *recommend.Model) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.model = m
+}
+
+// getPersonRecommendations returns books the person may like, based on
+// the books borrowed together with the ones the person borrowed.
+func (s *server) getPersonRecommendations(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if _, errSet := s.store.People().Get(personID); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.recommend(w, r, func(m *recommend.Model, limit int) []recommend.Recommendation {
+		return m.ForPerson(personID, limit)
+	})
+}
+
+// getSimilarBooks returns books most often borrowed together with the book.
+func (s *server) getSimilarBooks(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if _, errSet := s.store.Books().Get(bookID); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	s.recommend(w, r, func(m *recommend.Model, limit int) []recommend.Recommendation {
+		return m.Similar(bookID, limit)
+	})
+}
+
+// recommend writes up to limit recommended books. Books deleted since
+// the model was built are left out, so twice as many are asked for.
+func (s *server) recommend(w http.ResponseWriter, r *http.Request, find func(m *recommend.Model, limit int) []recommend.Recommendation) {
+	limit := defaultRecommendations
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRecommendations {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgumentf("limit must be between 1 and %d", maxRecommendations).WithMeta("field", "limit"))
+			return
+		}
+		limit = n
+	}
+
+	list := recommendationList{Data: []recommendation{}}
+	m := s.recs.get()
+	if m == nil {
+		// the first model is not built yet
+		httpio.WriteJSON(w, http.StatusOK, &list)
+		return
+	}
+	list.BuiltAt = m.BuiltAt
+	for _, rec := range find(m, 2*limit) {
+		if len(list.Data) == limit {
+			break
+		}
+		b, errSet := s.store.Books().Get(rec.BookID)
+		if errSet != nil {
+			if errdef.IsNotFound(errSet) {
+				continue
+			}
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		list.Data = append(list.Data, recommendation{Book: b, Score: rec.Score, Reason: rec.Reason})
+	}
+	httpio.WriteJSON(w, http.StatusOK, &list)
+}
+
+// refreshRecommendations builds the model right away and then
+// periodically until ctx is done.
+func refreshRecommendations(ctx context.Context, s store.Store, recs *recommender, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m, errSet := buildRecommendations(s, time.Now().UTC())
+		if errSet != nil {
+			log.Println("failed to build recommendations:", errSet)
+		} else {
+			recs.set(m)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// buildRecommendations builds the model from all loans.
+func buildRecommendations(s store.Store, now time.Time) (*recommend.Model, *errdef.Error) {
+	b := recommend.NewBuilder()
+	errSet := s.Loans().Each(store.LoanFilter{}, func(l loan.Loan) *errdef.Error {
+		b.Add(l.PersonID, l.BookID)
+		return nil
+	})
+	if errSet != nil {
+		return nil, errSet
+	}
+	return b.Build(now), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/recommend"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestRecommendations(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, body := range []string{
+		`{"Name":"Jack","Email":"jack@gmail.com"}`,
+		`{"Name":"Jane","Email":"jane@gmail.com"}`,
+		`{"Name":"Joe","Email":"joe@gmail.com"}`,
+	} {
+		w := do(t, srv, "POST", "/create/person", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+	w := do(t, srv, "POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	for i, title := range []string{"Go", "Rust", "Zig"} {
+		id := strconv.Itoa(i + 1)
+		w = do(t, srv, "POST", "/create/book", `{"Title":"`+title+`","CallNumber":`+id+`}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		w = do(t, srv, "POST", "/create/copy", `{"BookID":`+id+`,"Barcode":"`+title+`","HomeBranchID":1}`)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var recs struct {
+		Data []struct {
+			Book struct {
+				ID uint
+			}
+			Score  float64
+			Reason recommend.Reason
+		}
+		BuiltAt time.Time
+	}
+	w = do(t, srv, "GET", "/person/1/recommendations", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &recs)
+	assert.Empty(t, recs.Data, "nothing is built yet")
+
+	// Jack borrows Go and Rust, Jane borrows Go
+	for _, step := range []struct{ path, body string }{
+		{"/checkout/copy/1", `{"PersonID":1}`},
+		{"/checkout/copy/2", `{"PersonID":1}`},
+		{"/return/copy/1", ""},
+		{"/checkout/copy/1", `{"PersonID":2}`},
+	} {
+		w = do(t, srv, "POST", step.path, step.body)
+		require.Less(t, w.Code, 300, w.Body.String())
+	}
+	m, errSet := buildRecommendations(srv.store, time.Now().UTC())
+	require.Nil(t, errSet)
+	srv.recs.set(m)
+
+	bookIDs := func(path string) []uint {
+		w := do(t, srv, "GET", path, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		decode(t, w, &recs)
+		ids := []uint{}
+		for _, r := range recs.Data {
+			ids = append(ids, r.Book.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint{2}, bookIDs("/person/2/recommendations"))
+	assert.Equal(t, recommend.Similar, recs.Data[0].Reason)
+	assert.False(t, recs.BuiltAt.IsZero())
+	assert.Equal(t, []uint{1, 2}, bookIDs("/person/3/recommendations"), "new patrons get popular books")
+	assert.Equal(t, recommend.Popular, recs.Data[0].Reason)
+	assert.Equal(t, []uint{2}, bookIDs("/book/1/similar?limit=1"))
+	assert.Empty(t, bookIDs("/person/1/recommendations"))
+
+	w = do(t, srv, "GET", "/book/9/similar", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = do(t, srv, "GET", "/person/1/recommendations?limit=0", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+	"github.com/investapp/backend/pkg/records"
+)
+
+// reportQuery are the parameters of a report.
+type reportQuery struct {
+	filter store.ReportFilter
+	group  report.Group
+	limit  int
+	now    time.Time
+}
+
+// reporter produces rows of one report.
+type reporter struct {
+	// row is empty row, CSV columns are its fields.
+	row interface{}
+	// grouped reports take the group parameter.
+	grouped bool
+	run     func(s store.Reports, q reportQuery) ([]interface{}, *errdef.Error)
+}
+
+// reporters are the reports by their name. Loans per period and the
+// overdue rate come from the same loans report.
+var reporters = map[string]reporter{
+	"loans": {
+		row:     report.LoanCount{},
+		grouped: true,
+		run: func(s store.Reports, q reportQuery) ([]interface{}, *errdef.Error) {
+			counts, errSet := s.Loans(q.filter, q.group, q.now)
+			return rowsOf(counts), errSet
+		},
+	},
+	"most_borrowed": {
+		row: report.BookCount{},
+		run: func(s store.Reports, q reportQuery) ([]interface{}, *errdef.Error) {
+			counts, errSet := s.MostBorrowed(q.filter, q.limit)
+			return rowsOf(counts), errSet
+		},
+	},
+	"never_borrowed": {
+		row: report.BookCount{},
+		run: func(s store.Reports, q reportQuery) ([]interface{}, *errdef.Error) {
+			counts, errSet := s.NeverBorrowed(q.filter, q.limit)
+			return rowsOf(counts), errSet
+		},
+	},
+	"active_patrons": {
+		row: report.PersonCount{},
+		run: func(s store.Reports, q reportQuery) ([]interface{}, *errdef.Error) {
+			counts, errSet := s.ActivePatrons(q.filter, q.limit)
+			return rowsOf(counts), errSet
+		},
+	},
+}
+
+// rowsOf returns the elements of the slice.
+func rowsOf(slice interface{}) []interface{} {
+	v := reflect.ValueOf(slice)
+	rows := make([]interface{}, v.Len())
+	for i := range rows {
+		rows[i] = v.Index(i).Interface()
+	}
+	return rows
+}
+
+// getReport returns the report as JSON (default) or CSV. Loans are
+// limited by the checkout time with the from and to parameters, to is
+// exclusive, and by the home branch of the copy with branch_id. The
+// loans report is grouped by the group parameter, month by default,
+// the ranked reports return up to limit rows.
+func (s *server) getReport(w http.ResponseWriter, r *http.Request) {
+	kind := mux.Vars(r)["kind"]
+	rep := reporters[kind]
+	q := r.URL.Query()
+
+	format := records.JSON
+	if v := q.Get("format"); v != "" {
+		var errSet *errdef.Error
+		if format, errSet = records.ParseFormat(v); errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		if format == records.NDJSON {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("reports are returned as json or csv").WithMeta("field", "format"))
+			return
+		}
+	}
+	params, errSet := parseReportQuery(q, rep.grouped)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	rows, errSet := rep.run(s.store.Reports(), params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if format == records.JSON {
+		httpio.WriteJSON(w, http.StatusOK, struct{ Data []interface{} }{rows})
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+kind+`.csv"`)
+	wr := records.NewWriter(w, format, rep.row)
+	for _, row := range rows {
+		if err := wr.Write(row); err != nil {
+			log.Printf("report %s failed: %s", kind, err)
+			panic(http.ErrAbortHandler)
+		}
+	}
+	if err := wr.Close(); err != nil {
+		log.Printf("report %s failed: %s", kind, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// parseReportQuery reads parameters of the report.
+func parseReportQuery(q url.Values, grouped bool) (reportQuery, *errdef.Error) {
+	params := reportQuery{limit: paging.DefaultLimit, now: time.Now().UTC()}
+	for _, param := range []struct {
+		name string
+		at   *time.Time
+	}{{"from", &params.filter.From}, {"to", &params.filter.To}} {
+		if v := q.Get(param.name); v != "" {
+			at, errSet := parseReportTime(v, param.name)
+			if errSet != nil {
+				return params, errSet
+			}
+			*param.at = at
+		}
+	}
+	if !params.filter.From.IsZero() && !params.filter.To.IsZero() && !params.filter.From.Before(params.filter.To) {
+		return params, errdef.ErrInvalidArgument("from must be before to").WithMeta("field", "to")
+	}
+	var errSet *errdef.Error
+	if params.filter.BranchID, errSet = branchParam(q); errSet != nil {
+		return params, errSet
+	}
+	if v := q.Get("group"); v != "" {
+		if !grouped {
+			return params, errdef.ErrInvalidArgument("only the loans report can be grouped").WithMeta("field", "group")
+		}
+		if params.group, errSet = report.ParseGroup(v); errSet != nil {
+			return params, errSet
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > paging.MaxLimit {
+			return params, errdef.ErrInvalidArgumentf("limit must be between 1 and %d", paging.MaxLimit).WithMeta("field", "limit")
+		}
+		params.limit = limit
+	}
+	return params, nil
+}
+
+// parseReportTime parses date or RFC 3339 time, dates are midnight UTC.
+func parseReportTime(v, field string) (time.Time, *errdef.Error) {
+	if at, err := time.Parse("2006-01-02", v); err == nil {
+		return at, nil
+	}
+	at, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return at, errdef.ErrInvalidArgumentf("%s must be a date or RFC 3339 time", field).WithMeta("field", field)
+	}
+	return at.UTC(), nil
+}
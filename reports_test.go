@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/report"
+	"github.com/investapp/backend/models/store/memstore"
+)
+
+func TestReports(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, step := range []struct{ path, body string }{
+		{"/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`},
+		{"/create/branch", `{"Code":"MAIN","Name":"Main library"}`},
+		{"/create/book", `{"Title":"Go","CallNumber":1}`},
+		{"/create/book", `{"Title":"Rust","CallNumber":2}`},
+		{"/create/copy", `{"BookID":1,"Barcode":"A1","HomeBranchID":1}`},
+		{"/checkout/copy/1", `{"PersonID":1}`},
+	} {
+		w := do(t, srv, "POST", step.path, step.body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w := do(t, srv, "GET", "/reports/most_borrowed", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var books struct{ Data []report.BookCount }
+	decode(t, w, &books)
+	assert.Equal(t, []report.BookCount{{BookID: 1, Title: "Go", Loans: 1}}, books.Data)
+
+	w = do(t, srv, "GET", "/reports/never_borrowed?from=2020-01-01", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &books)
+	assert.Equal(t, []report.BookCount{{BookID: 2, Title: "Rust"}}, books.Data)
+
+	month := report.Month.Period(time.Now())
+	w = do(t, srv, "GET", "/reports/loans?format=csv", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "group,loans,overdue,overdue_rate\n"+month+",1,0,0\n", w.Body.String())
+	w = do(t, srv, "GET", "/reports/loans?group=branch&to=2020-01-01", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.True(t, strings.HasPrefix(w.Body.String(), `{"Data":[]}`), w.Body.String())
+
+	for _, target := range []string{
+		"/reports/most_borrowed?group=month",
+		"/reports/loans?group=year",
+		"/reports/loans?from=2020-02-01&to=2020-01-01",
+		"/reports/active_patrons?from=yesterday",
+		"/reports/active_patrons?format=ndjson",
+	} {
+		w = do(t, srv, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestReportsPurgedBook(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, step := range []struct{ method, path, body string }{
+		{"POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`},
+		{"POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`},
+		{"POST", "/create/book", `{"Title":"Go","CallNumber":1}`},
+		{"POST", "/create/copy", `{"BookID":1,"Barcode":"A1","HomeBranchID":1}`},
+		{"POST", "/checkout/copy/1", `{"PersonID":1}`},
+		{"POST", "/return/copy/1", `{"BranchID":1}`},
+		{"DELETE", "/delete/copy/1", ""},
+		{"DELETE", "/delete/book/1", ""},
+		{"DELETE", "/purge/book/1", ""},
+	} {
+		w := do(t, srv, step.method, step.path, step.body)
+		require.Less(t, w.Code, 300, step.path+": "+w.Body.String())
+	}
+
+	// loans of purged books still count, their copies are gone
+	month := report.Month.Period(time.Now())
+	w := do(t, srv, "GET", "/reports/loans?format=csv", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "group,loans,overdue,overdue_rate\n"+month+",1,0,0\n", w.Body.String())
+	w = do(t, srv, "GET", "/reports/loans?group=branch&format=csv", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "group,loans,overdue,overdue_rate\n,1,0,0\n", w.Body.String())
+}
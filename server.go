@@ -0,0 +1,403 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/investapp/backend/api/middleware"
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/errdef"
+)
+
+// server serves the library API. All data is read and written through
+// the injected store, so the same handlers run on the database or in memory.
+type server struct {
+	store  store.Store
+	router *mux.Router
+	// checks are run by the readiness probe.
+	checks []readyCheck
+	// draining is set to 1 once the shutdown started.
+	draining int32
+	// recs holds recommendations built by refreshRecommendations.
+	recs recommender
+	// auth signs users in, it is set from the configuration.
+	auth authenticator
+	// policies rule lending, holds and fines, they are set from the configuration.
+	policies policies
+}
+
+// policies rule the circulation. The operations which depend on them
+// are methods, so handlers and background jobs pass the same rules.
+type policies struct {
+	loan loan.Policy
+	hold hold.Policy
+	fine fine.Policy
+}
+
+// defaultPolicies are used until the configuration is loaded.
+var defaultPolicies = policies{loan: loan.DefaultPolicy, hold: hold.DefaultPolicy, fine: fine.DefaultPolicy}
+
+// newPolicies builds the policies from the configuration.
+func newPolicies(cfg config.Config) policies {
+	return policies{
+		loan: loan.Policy{Period: cfg.Loans.Period, MaxRenewals: cfg.Loans.MaxRenewals},
+		hold: hold.Policy{PickupWindow: cfg.Holds.PickupWindow},
+		fine: fine.Policy{
+			DailyRate:      cfg.Fines.DailyRate,
+			GracePeriod:    cfg.Fines.GracePeriod,
+			MaxPerItem:     cfg.Fines.MaxPerItem,
+			BlockThreshold: cfg.Fines.BlockThreshold,
+		},
+	}
+}
+
+// newServer creates the API server on top of the store. The readiness
+// probe fails while any of the checks fails.
+func newServer(s store.Store, checks ...readyCheck) *server {
+	srv := &server{store: s, router: mux.NewRouter(), checks: checks, policies: defaultPolicies}
+	srv.routes()
+	return srv
+}
+
+// ServeHTTP dispatches the request to the handler of its route.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// publicRoutes are the routes which are never authenticated, so a stale
+// access token sent along doesn't fail them. Keys are the method and
+// the path template.
+var publicRoutes = map[string]bool{
+	"POST /auth/login":   true,
+	"POST /auth/refresh": true,
+	"POST /auth/logout":  true,
+}
+
+// isPublic tells if the request is not authenticated.
+func isPublic(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	path, err := route.GetPathTemplate()
+	return err == nil && publicRoutes[r.Method+" "+path]
+}
+
+// can lets only users with the permission call the handler. Routes
+// which are not wrapped are public, e.g. reading the catalog.
+func can(p user.Permission, h http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(string(p))(h)
+}
+
+// canOrSelf lets users with the permission call the handler, and the
+// person the request is about too, personOf tells who it is.
+func (s *server) canOrSelf(p user.Permission, personOf func(r *http.Request) (uint, *errdef.Error), h http.HandlerFunc) http.Handler {
+	return middleware.RequirePermissionOr(string(p), func(r *http.Request, pr middleware.Principal) (bool, *errdef.Error) {
+		personID, errSet := personOf(r)
+		if errSet != nil {
+			if errdef.IsNotFound(errSet) {
+				return false, nil
+			}
+			return false, errSet
+		}
+		return s.isPerson(pr.UserID, personID)
+	})(h)
+}
+
+// isPerson tells if the user is the person, i.e. if the email of the
+// person is a verified email of the user.
+func (s *server) isPerson(userID, personID uint) (bool, *errdef.Error) {
+	pn, errSet := s.store.People().Get(personID)
+	if errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			return false, nil
+		}
+		return false, errSet
+	}
+	u, errSet := s.store.Users().Get(userID)
+	if errSet != nil {
+		if errdef.IsNotFound(errSet) {
+			return false, nil
+		}
+		return false, errSet
+	}
+	return u.HasEmail(pn.Email), nil
+}
+
+// personParam returns the person of the id route parameter.
+func personParam(r *http.Request) (uint, *errdef.Error) {
+	return idParam(r, "id")
+}
+
+// loanPerson returns the person of the loan of the id route parameter.
+func (s *server) loanPerson(r *http.Request) (uint, *errdef.Error) {
+	loanID, errSet := idParam(r, "id")
+	if errSet != nil {
+		return 0, errSet
+	}
+	l, errSet := s.store.Loans().Get(loanID)
+	if errSet != nil {
+		return 0, errSet
+	}
+	return l.PersonID, nil
+}
+
+// holdPerson returns the person of the hold of the id route parameter.
+func (s *server) holdPerson(r *http.Request) (uint, *errdef.Error) {
+	holdID, errSet := idParam(r, "id")
+	if errSet != nil {
+		return 0, errSet
+	}
+	h, errSet := s.store.Holds().Get(holdID)
+	if errSet != nil {
+		return 0, errSet
+	}
+	return h.PersonID, nil
+}
+
+func (s *server) routes() {
+	router := s.router
+	router.Use(mux.MiddlewareFunc(middleware.Bearer(s.authenticate, isPublic)))
+	// liveness probe
+	router.HandleFunc("/healthz", s.healthz).Methods("GET")
+	// readiness probe
+	router.HandleFunc("/readyz", s.readyz).Methods("GET")
+	// public keys access tokens are verified with
+	router.HandleFunc("/.well-known/jwks.json", s.getJWKS).Methods("GET")
+	// sign in with username or email and password
+	router.HandleFunc("/auth/login", s.login).Methods("POST")
+	// new access token for refresh token
+	router.HandleFunc("/auth/refresh", s.refresh).Methods("POST")
+	// revoke refresh token of this sign in
+	router.HandleFunc("/auth/logout", s.logout).Methods("POST")
+	// revoke refresh tokens of all sign ins of the user
+	router.HandleFunc("/auth/logout/all", s.logoutAll).Methods("POST")
+	// returns all people
+	router.Handle("/people", can(user.RecordsRead, s.getPeople)).Methods("GET")
+	// returns person by id
+	router.Handle("/person/{id}", s.canOrSelf(user.RecordsRead, personParam, s.getPerson)).Methods("GET")
+	// return book by id
+	router.HandleFunc("/book/{id}", s.getBook).Methods("GET")
+	// return book by ISBN
+	router.HandleFunc("/book/isbn/{isbn}", s.getBookByISBN).Methods("GET")
+	// create person
+	router.Handle("/create/person", can(user.PeopleWrite, s.createPerson)).Methods("POST")
+	// create book
+	router.Handle("/create/book", can(user.BooksWrite, s.createBook)).Methods("POST")
+	// get all books
+	router.HandleFunc("/books", s.getBooks).Methods("GET")
+	// search books by title and author
+	router.HandleFunc("/books/search", s.searchBooks).Methods("GET")
+	// import people or books from CSV or NDJSON
+	router.Handle("/import/{kind:people|books}", can(user.DataImport, s.importRows)).Methods("POST")
+	// export people, books or loans as CSV, NDJSON or JSON
+	router.Handle("/export/{kind:people|books|loans}", can(user.RecordsRead, s.exportRows)).Methods("GET")
+	// circulation reports as JSON or CSV
+	router.Handle("/reports/{kind:loans|most_borrowed|active_patrons|never_borrowed}", can(user.RecordsRead, s.getReport)).Methods("GET")
+	// delete person by id
+	router.Handle("/delete/person/{id}", can(user.PeopleWrite, s.deletePerson)).Methods("DELETE")
+	// delete book by id
+	router.Handle("/delete/book/{id}", can(user.BooksWrite, s.deleteBook)).Methods("DELETE")
+	// deleted people
+	router.Handle("/trash/people", can(user.RecordsRead, s.getTrashPeople)).Methods("GET")
+	// deleted books
+	router.Handle("/trash/books", can(user.RecordsRead, s.getTrashBooks)).Methods("GET")
+	// restore deleted person by id
+	router.Handle("/restore/person/{id}", can(user.PeopleWrite, s.restorePerson)).Methods("POST")
+	// restore deleted book by id
+	router.Handle("/restore/book/{id}", can(user.BooksWrite, s.restoreBook)).Methods("POST")
+	// purge deleted person by id
+	router.Handle("/purge/person/{id}", can(user.TrashPurge, s.purgePerson)).Methods("DELETE")
+	// purge deleted book by id
+	router.Handle("/purge/book/{id}", can(user.TrashPurge, s.purgeBook)).Methods("DELETE")
+	// list of authors
+	router.HandleFunc("/authors", s.getAuthors).Methods("GET")
+	// get author by id
+	router.HandleFunc("/author/{id}", s.getAuthor).Methods("GET")
+	// create author
+	router.Handle("/create/author", can(user.BooksWrite, s.createAuthor)).Methods("POST")
+	// replace author by id
+	router.Handle("/update/author/{id}", can(user.BooksWrite, s.replaceAuthor)).Methods("PUT")
+	// patch author by id
+	router.Handle("/update/author/{id}", can(user.BooksWrite, s.patchAuthor)).Methods("PATCH")
+	// delete author by id
+	router.Handle("/delete/author/{id}", can(user.BooksWrite, s.deleteAuthor)).Methods("DELETE")
+	// replace authors of book by id
+	router.Handle("/update/book/{id}/authors", can(user.BooksWrite, s.setBookAuthors)).Methods("PUT")
+	// replace person by id
+	router.Handle("/update/person/{id}", can(user.PeopleWrite, s.replacePerson)).Methods("PUT")
+	// partially update person by id
+	router.Handle("/update/person/{id}", can(user.PeopleWrite, s.patchPerson)).Methods("PATCH")
+	// replace book by id
+	router.Handle("/update/book/{id}", can(user.BooksWrite, s.replaceBook)).Methods("PUT")
+	// partially update book by id
+	router.Handle("/update/book/{id}", can(user.BooksWrite, s.patchBook)).Methods("PATCH")
+	// list of branches
+	router.HandleFunc("/branches", s.getBranches).Methods("GET")
+	// get branch by id
+	router.HandleFunc("/branch/{id}", s.getBranch).Methods("GET")
+	// create branch
+	router.Handle("/create/branch", can(user.BranchesWrite, s.createBranch)).Methods("POST")
+	// replace branch by id
+	router.Handle("/update/branch/{id}", can(user.BranchesWrite, s.replaceBranch)).Methods("PUT")
+	// partially update branch by id
+	router.Handle("/update/branch/{id}", can(user.BranchesWrite, s.patchBranch)).Methods("PATCH")
+	// delete branch by id
+	router.Handle("/delete/branch/{id}", can(user.BranchesWrite, s.deleteBranch)).Methods("DELETE")
+	// copies of book
+	router.HandleFunc("/book/{id}/copies", s.getBookCopies).Methods("GET")
+	// availability of book copies
+	router.HandleFunc("/book/{id}/availability", s.getBookAvailability).Methods("GET")
+	// return copy by id
+	router.HandleFunc("/copy/{id}", s.getCopy).Methods("GET")
+	// return copy by barcode
+	router.HandleFunc("/copy/barcode/{barcode}", s.getCopyByBarcode).Methods("GET")
+	// create copy of book
+	router.Handle("/create/copy", can(user.BooksWrite, s.createCopy)).Methods("POST")
+	// replace copy by id
+	router.Handle("/update/copy/{id}", can(user.BooksWrite, s.replaceCopy)).Methods("PUT")
+	// partially update copy by id
+	router.Handle("/update/copy/{id}", can(user.BooksWrite, s.patchCopy)).Methods("PATCH")
+	// delete copy by id
+	router.Handle("/delete/copy/{id}", can(user.BooksWrite, s.deleteCopy)).Methods("DELETE")
+	// transfers of copies between branches
+	router.HandleFunc("/transfers", s.getTransfers).Methods("GET")
+	// return transfer by id
+	router.HandleFunc("/transfer/{id}", s.getTransfer).Methods("GET")
+	// request transfer of copy to branch
+	router.Handle("/create/transfer", can(user.CirculationWrite, s.createTransfer)).Methods("POST")
+	// send copy of transfer
+	router.Handle("/ship/transfer/{id}", can(user.CirculationWrite, s.shipTransfer)).Methods("POST")
+	// receive copy of transfer
+	router.Handle("/receive/transfer/{id}", can(user.CirculationWrite, s.receiveTransfer)).Methods("POST")
+	// cancel transfer which was not sent yet
+	router.Handle("/cancel/transfer/{id}", can(user.CirculationWrite, s.cancelTransfer)).Methods("POST")
+	// lend free copy of book to person
+	router.Handle("/checkout/book/{id}", can(user.CirculationWrite, s.checkoutBook)).Methods("POST")
+	// lend copy to person
+	router.Handle("/checkout/copy/{id}", can(user.CirculationWrite, s.checkoutCopy)).Methods("POST")
+	// return copy from loan
+	router.Handle("/return/copy/{id}", can(user.CirculationWrite, s.returnCopy)).Methods("POST")
+	// extend due date of loan
+	router.Handle("/renew/loan/{id}", can(user.CirculationWrite, s.renewLoan)).Methods("POST")
+	// return loan by id
+	router.Handle("/loan/{id}", s.canOrSelf(user.RecordsRead, s.loanPerson, s.getLoan)).Methods("GET")
+	// loan history of person
+	router.Handle("/person/{id}/loans", s.canOrSelf(user.RecordsRead, personParam, s.getPersonLoans)).Methods("GET")
+	// loan history of book
+	router.Handle("/book/{id}/loans", can(user.RecordsRead, s.getBookLoans)).Methods("GET")
+	// loan history of copy
+	router.Handle("/copy/{id}/loans", can(user.RecordsRead, s.getCopyLoans)).Methods("GET")
+	// place hold on book
+	router.Handle("/hold/book/{id}", can(user.CirculationWrite, s.placeHold)).Methods("POST")
+	// cancel hold by id
+	router.Handle("/cancel/hold/{id}", can(user.CirculationWrite, s.cancelHold)).Methods("POST")
+	// return hold by id
+	router.Handle("/hold/{id}", s.canOrSelf(user.RecordsRead, s.holdPerson, s.getHold)).Methods("GET")
+	// recommend books to person
+	router.Handle("/person/{id}/recommendations", s.canOrSelf(user.RecordsRead, personParam, s.getPersonRecommendations)).Methods("GET")
+	// books borrowed together with book
+	router.HandleFunc("/book/{id}/similar", s.getSimilarBooks).Methods("GET")
+	// holds placed by person
+	router.Handle("/person/{id}/holds", s.canOrSelf(user.RecordsRead, personParam, s.getPersonHolds)).Methods("GET")
+	// hold queue of book
+	router.Handle("/book/{id}/holds", can(user.RecordsRead, s.getBookHolds)).Methods("GET")
+	// change role of user
+	router.Handle("/update/user/{id}/role", can(user.RolesWrite, s.setUserRole)).Methods("PUT")
+	// fines balance of person
+	router.Handle("/person/{id}/balance", s.canOrSelf(user.RecordsRead, personParam, s.getPersonBalance)).Methods("GET")
+	// fines ledger of person
+	router.Handle("/person/{id}/fines", s.canOrSelf(user.RecordsRead, personParam, s.getPersonFines)).Methods("GET")
+	// record payment of fines
+	router.Handle("/pay/person/{id}", can(user.FinesWrite, s.payFine)).Methods("POST")
+	// waive part of fines
+	router.Handle("/waive/person/{id}", can(user.FinesWrite, s.waiveFine)).Methods("POST")
+}
+
+// Request and response helpers
+
+// idParam parses numeric route parameter.
+func idParam(r *http.Request, name string) (uint, *errdef.Error) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		return 0, errdef.ErrInvalidArgumentf("%s is not valid", name)
+	}
+	return uint(id), nil
+}
+
+// personFilter reads filter of people from query parameters.
+func personFilter(q url.Values) store.PersonFilter {
+	return store.PersonFilter{Name: q.Get("name"), Email: q.Get("email")}
+}
+
+// bookFilter reads filter of books from query parameters.
+func bookFilter(q url.Values) (store.BookFilter, *errdef.Error) {
+	filter := store.BookFilter{Author: q.Get("author"), Title: q.Get("title")}
+	if v := q.Get("person_id"); v != "" {
+		personID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, errdef.ErrInvalidArgument("person_id is not valid").WithMeta("field", "person_id")
+		}
+		id := uint(personID)
+		filter.PersonID = &id
+	}
+	if v := q.Get("author_id"); v != "" {
+		authorID, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return filter, errdef.ErrInvalidArgument("author_id is not valid").WithMeta("field", "author_id")
+		}
+		filter.AuthorID = uint(authorID)
+	}
+	branchID, errSet := branchParam(q)
+	if errSet != nil {
+		return filter, errSet
+	}
+	filter.BranchID = branchID
+	return filter, nil
+}
+
+// branchParam reads the branch_id parameter, zero if it is not set.
+func branchParam(q url.Values) (uint, *errdef.Error) {
+	v := q.Get("branch_id")
+	if v == "" {
+		return 0, nil
+	}
+	branchID, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, errdef.ErrInvalidArgument("branch_id is not valid").WithMeta("field", "branch_id")
+	}
+	return uint(branchID), nil
+}
+
+// loanFilter reads filter of loans from query parameters.
+func loanFilter(q url.Values) (store.LoanFilter, *errdef.Error) {
+	var filter store.LoanFilter
+	for _, param := range []struct {
+		name string
+		id   *uint
+	}{{"person_id", &filter.PersonID}, {"book_id", &filter.BookID}, {"copy_id", &filter.CopyID}} {
+		if v := q.Get(param.name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return filter, errdef.ErrInvalidArgumentf("%s is not valid", param.name).WithMeta("field", param.name)
+			}
+			*param.id = uint(id)
+		}
+	}
+	if v := q.Get("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errdef.ErrInvalidArgument("active must be a boolean").WithMeta("field", "active")
+		}
+		filter.Active = &active
+	}
+	return filter, nil
+}
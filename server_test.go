@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/fine"
+	"github.com/investapp/backend/models/loan"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/store/memstore"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/config"
+	"github.com/investapp/backend/pkg/crypto"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// testUsername is the user signed in by do.
+const testUsername = "admin"
+
+// newTestServer returns server on the store with sign in on and the admin
+// testUsername.
+func newTestServer(t *testing.T, s store.Store) *server {
+	t.Helper()
+	srv := newServer(s)
+	srv.auth = authenticator{signer: crypto.Secret(testSecret), accessTTL: time.Minute, refreshTTL: time.Hour}
+	require.Nil(t, s.Users().Save(&user.User{Username: testUsername, Role: user.Admin}))
+	return srv
+}
+
+// do sends the request to the handler and returns the recorded response.
+// When the handler is a server with sign in on, the request is sent with
+// an access token of testUsername.
+func do(t *testing.T, h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var token string
+	if srv, ok := h.(*server); ok && srv.auth.enabled() {
+		u, errSet := srv.store.Users().ByLogin(testUsername)
+		require.Nil(t, errSet)
+		access, errSet := srv.auth.issue(u.ID)
+		require.Nil(t, errSet)
+		token = access.AccessToken
+	}
+	return doAs(t, h, token, method, target, body)
+}
+
+// doAs sends the request with the access token, if not empty, and returns
+// the recorded response.
+func doAs(t *testing.T, h http.Handler, token, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// decode decodes JSON body of the response into v.
+func decode(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), v), w.Body.String())
+}
+
+func TestPersonHandlers(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+
+	w := do(t, srv, "POST", "/create/person", `{"Name":" Jack ","Email":"Jack@Gmail.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var jack person.Person
+	decode(t, w, &jack)
+	assert.Equal(t, "Jack", jack.Name)
+	assert.Equal(t, "jack@gmail.com", jack.Email)
+
+	w = do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, httpio.ContentTypeProblem, w.Header().Get("Content-Type"))
+
+	w = do(t, srv, "POST", "/create/person", `{"Name":"","Email":"jane@gmail.com"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem httpio.Problem
+	decode(t, w, &problem)
+	assert.Equal(t, "name", problem.Meta["field"])
+
+	w = do(t, srv, "PATCH", "/update/person/1", `{"name":"John"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &jack)
+	assert.Equal(t, "John", jack.Name)
+	assert.Equal(t, "jack@gmail.com", jack.Email)
+
+	w = do(t, srv, "GET", "/person/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do(t, srv, "DELETE", "/delete/person/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = do(t, srv, "GET", "/person/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = do(t, srv, "GET", "/person/x", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetBooks(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	w := do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	for _, body := range []string{
+		`{"Title":"The rules of Thinking","Author":"Richard Templar","CallNumber":1234,"PersonID":1}`,
+		`{"Title":"Happy world champion","Author":"Deko Montera","CallNumber":12345,"PersonID":1}`,
+		`{"Title":"The rules of Work","Author":"Richard Templar","CallNumber":42}`,
+	} {
+		w := do(t, srv, "POST", "/create/book", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Lost","CallNumber":7,"PersonID":9}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var list struct {
+		Data   []book.Book
+		Paging paging.Page
+	}
+	w = do(t, srv, "GET", "/books?author=templar&sort=-call_number&limit=1&total=true", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, 1234, list.Data[0].CallNumber)
+	require.NotNil(t, list.Paging.Total)
+	assert.Equal(t, int64(2), *list.Paging.Total)
+	require.NotEmpty(t, list.Paging.NextCursor)
+
+	w = do(t, srv, "GET", "/books?author=templar&sort=-call_number&limit=1&cursor="+list.Paging.NextCursor, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list.Paging = paging.Page{}
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, 42, list.Data[0].CallNumber)
+	assert.Empty(t, list.Paging.NextCursor)
+
+	var jack person.Person
+	w = do(t, srv, "GET", "/person/1", "")
+	decode(t, w, &jack)
+	assert.Len(t, jack.Books, 2)
+}
+
+func TestBookISBN(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	w := do(t, srv, "POST", "/create/book", `{"Title":"Go","CallNumber":1,"ISBN":"0-306-40615-2"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var b book.Book
+	decode(t, w, &b)
+	assert.Equal(t, "9780306406157", b.ISBN)
+
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Go again","CallNumber":2,"ISBN":"978-0-306-40615-7"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = do(t, srv, "POST", "/create/book", `{"Title":"Rust","CallNumber":3,"ISBN":"0-306-40615-3"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	var problem httpio.Problem
+	decode(t, w, &problem)
+	assert.Equal(t, "isbn", problem.Meta["field"])
+
+	for _, isbn := range []string{"0306406152", "978-0-306-40615-7"} {
+		w = do(t, srv, "GET", "/book/isbn/"+isbn, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		b = book.Book{}
+		decode(t, w, &b)
+		assert.Equal(t, "Go", b.Title)
+	}
+	w = do(t, srv, "GET", "/book/isbn/9780804429573", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = do(t, srv, "GET", "/book/isbn/12345", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSearchBooks(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	for _, body := range []string{
+		`{"Title":"The rules of Thinking","Author":"Richard Templar","CallNumber":1}`,
+		`{"Title":"Čarodějův učeň","Author":"Terry Pratchett","CallNumber":2}`,
+		`{"Title":"The rules of Work","Author":"Richard Templar","CallNumber":3}`,
+	} {
+		w := do(t, srv, "POST", "/create/book", body)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var list struct {
+		Data   []book.Match
+		Paging paging.Page
+	}
+	w := do(t, srv, "GET", "/books/search?q=carod", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "<mark>Čarodějův</mark> učeň", list.Data[0].Title)
+
+	w = do(t, srv, "GET", "/books/search?q=rules+templar&limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, 1, list.Data[0].Book.CallNumber)
+	assert.Equal(t, 1, list.Paging.NextOffset)
+
+	for _, target := range []string{"/books/search", "/books/search?q=rules&sort=title"} {
+		w = do(t, srv, "GET", target, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
+
+func TestCirculationHandlers(t *testing.T) {
+	srv := newTestServer(t, memstore.New())
+	do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`)
+	do(t, srv, "POST", "/create/person", `{"Name":"Jane","Email":"jane@gmail.com"}`)
+	do(t, srv, "POST", "/create/book", `{"Title":"Go","CallNumber":1}`)
+	do(t, srv, "POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`)
+	do(t, srv, "POST", "/create/copy", `{"BookID":1,"Barcode":"0001","HomeBranchID":1}`)
+
+	for _, target := range []string{"/checkout/book/1", "/checkout/copy/1", "/hold/book/1"} {
+		w := do(t, srv, "POST", target, `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+		assert.Contains(t, w.Body.String(), "person_id", target)
+	}
+
+	w := do(t, srv, "POST", "/checkout/book/1", `{"PersonID":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var l loan.Loan
+	decode(t, w, &l)
+	assert.True(t, l.Active())
+
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":2}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, srv, "POST", "/hold/book/1", `{"PersonID":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(t, srv, "POST", "/renew/loan/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(t, srv, "POST", "/return/copy/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":1}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(t, srv, "POST", "/checkout/book/1", `{"PersonID":2}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(t, srv, "GET", "/person/1/loans?active=false", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct{ Data loan.Loans }
+	decode(t, w, &list)
+	assert.Len(t, list.Data, 1)
+
+	w = do(t, srv, "GET", "/person/2/balance", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = do(t, srv, "POST", "/pay/person/2", `{"Amount":"1.00"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// credits can only be attached to loans of the person
+	charge := fine.NewCharge(1, 1, decimal.New(2, 0))
+	require.Nil(t, srv.store.Fines().Save(&charge))
+	charge = fine.NewCharge(2, 2, decimal.New(2, 0))
+	require.Nil(t, srv.store.Fines().Save(&charge))
+	for _, target := range []string{"/pay/person/2", "/waive/person/2"} {
+		for _, loanID := range []string{"1", "99"} {
+			w = do(t, srv, "POST", target, `{"Amount":"1.00","LoanID":`+loanID+`}`)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+			assert.Contains(t, w.Body.String(), "is not a loan of person 2")
+		}
+	}
+	w = do(t, srv, "POST", "/pay/person/2", `{"Amount":"1.00","LoanID":2}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/waive/person/1", `{"Amount":"1.00","LoanID":1}`)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestPolicies(t *testing.T) {
+	assert.Equal(t, defaultPolicies, newPolicies(config.Default()))
+
+	srv := newTestServer(t, memstore.New())
+	srv.policies.loan.MaxRenewals = 0
+	do(t, srv, "POST", "/create/person", `{"Name":"Jack","Email":"jack@gmail.com"}`)
+	do(t, srv, "POST", "/create/book", `{"Title":"Go","CallNumber":1}`)
+	do(t, srv, "POST", "/create/branch", `{"Code":"MAIN","Name":"Main library"}`)
+	do(t, srv, "POST", "/create/copy", `{"BookID":1,"Barcode":"0001","HomeBranchID":1}`)
+	w := do(t, srv, "POST", "/checkout/book/1", `{"PersonID":1}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = do(t, srv, "POST", "/renew/loan/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
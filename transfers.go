@@ -0,0 +1,302 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/investapp/backend/models/branch"
+	"github.com/investapp/backend/models/hold"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// Transfers move copies between branches. Staff request them, or they
+// are requested to bring a copy to the pickup branch of a hold. The copy
+// is in transit from being shipped until it is received.
+
+type transferRequest struct {
+	CopyID     uint
+	ToBranchID uint
+}
+
+// getTransfers returns transfers, the filter is read from the copy_id,
+// branch_id, status and open parameters.
+func (s *server) getTransfers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	params, errSet := paging.Parse(q, branch.TransferSortable, "-requested_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var filter store.TransferFilter
+	for _, param := range []struct {
+		name string
+		id   *uint
+	}{{"copy_id", &filter.CopyID}, {"branch_id", &filter.BranchID}} {
+		if v := q.Get(param.name); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				httpio.WriteErr(w, r, errdef.ErrInvalidArgumentf("%s is not valid", param.name).WithMeta("field", param.name))
+				return
+			}
+			*param.id = uint(id)
+		}
+	}
+	if v := q.Get("status"); v != "" {
+		status, errSet := branch.ParseStatus(v)
+		if errSet != nil {
+			httpio.WriteErr(w, r, errSet)
+			return
+		}
+		filter.Status = &status
+	}
+	if v := q.Get("open"); v != "" {
+		open, err := strconv.ParseBool(v)
+		if err != nil {
+			httpio.WriteErr(w, r, errdef.ErrInvalidArgument("open must be a boolean").WithMeta("field", "open"))
+			return
+		}
+		filter.Open = &open
+	}
+
+	transfers, total, errSet := s.store.Transfers().Find(filter, params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(transfers), func(i int) []interface{} {
+		return transfers[i].SortValues(params.Sort)
+	})
+	transfers = transfers[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &transfers, Paging: page})
+}
+
+func (s *server) getTransfer(w http.ResponseWriter, r *http.Request) {
+	transferID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	t, errSet := s.store.Transfers().Get(transferID)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &t)
+}
+
+// createTransfer requests the copy to be moved to the branch.
+func (s *server) createTransfer(w http.ResponseWriter, r *http.Request) {
+	var req transferRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var created branch.Transfer
+	err := s.store.Transaction(func(tx store.Store) error {
+		c, errSet := lockCopy(tx, req.CopyID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := checkBranch(tx, req.ToBranchID, "to_branch_id"); errSet != nil {
+			return errSet
+		}
+		t, errSet := requestTransfer(tx, c.ID, c.LocationID, req.ToBranchID, nil, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		created = t
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusCreated, &created)
+}
+
+// shipTransfer marks the copy sent, it is in transit until received.
+func (s *server) shipTransfer(w http.ResponseWriter, r *http.Request) {
+	s.moveTransfer(w, r, s.policies.shipCopy)
+}
+
+// receiveTransfer marks the copy arrived, it serves the hold queue
+// of its book at the new location.
+func (s *server) receiveTransfer(w http.ResponseWriter, r *http.Request) {
+	s.moveTransfer(w, r, s.policies.receiveCopy)
+}
+
+// cancelTransfer cancels the transfer which was not shipped yet.
+func (s *server) cancelTransfer(w http.ResponseWriter, r *http.Request) {
+	s.moveTransfer(w, r, dropTransfer)
+}
+
+func (s *server) moveTransfer(w http.ResponseWriter, r *http.Request, op func(tx store.Store, transferID uint, now time.Time) (branch.Transfer, *errdef.Error)) {
+	transferID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var moved branch.Transfer
+	err := s.store.Transaction(func(tx store.Store) error {
+		t, errSet := op(tx, transferID, time.Now().UTC())
+		if errSet != nil {
+			return errSet
+		}
+		moved = t
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &moved)
+}
+
+// Transfer operations, they are expected to run inside of transaction.
+// The book of the copy is locked first, like in the hold operations.
+
+// requestTransfer creates transfer of the copy. A copy can be
+// transferred to one branch at a time.
+func requestTransfer(tx store.Store, copyID, from, to uint, holdID *uint, now time.Time) (branch.Transfer, *errdef.Error) {
+	open, errSet := tx.Transfers().Open(copyID)
+	if errSet != nil {
+		return branch.Transfer{}, errSet
+	}
+	if open != nil {
+		return branch.Transfer{}, errdef.ErrAlreadyExistsf("copy %d is already being transferred by transfer %d", copyID, open.ID).WithProcess(branch.TransferProcessName)
+	}
+	t, errSet := branch.NewTransfer(copyID, from, to, holdID, now)
+	if errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Transfers().Save(&t); errSet != nil {
+		return t, errSet
+	}
+	return t, nil
+}
+
+// lockTransfer loads the transfer and locks the book and the copy.
+func lockTransfer(tx store.Store, transferID uint) (branch.Transfer, *errdef.Error) {
+	t, errSet := tx.Transfers().Get(transferID)
+	if errSet != nil {
+		return t, errSet
+	}
+	if _, errSet := lockCopy(tx, t.CopyID); errSet != nil {
+		return t, errSet
+	}
+	// reload as the transfer could change before the copy was locked
+	return tx.Transfers().Get(transferID)
+}
+
+// shipCopy sends the copy. A copy set aside for a hold can only be
+// sent by the transfer of that hold.
+func (p policies) shipCopy(tx store.Store, transferID uint, now time.Time) (branch.Transfer, *errdef.Error) {
+	t, errSet := lockTransfer(tx, transferID)
+	if errSet != nil {
+		return t, errSet
+	}
+	c, errSet := tx.Copies().Get(t.CopyID)
+	if errSet != nil {
+		return t, errSet
+	}
+	holds, errSet := p.openBookHolds(tx, c.BookID, now)
+	if errSet != nil {
+		return t, errSet
+	}
+	if h, ok := holds.ByCopyID(c.ID); ok && (t.HoldID == nil || *t.HoldID != h.ID) {
+		return t, errdef.ErrFailedPreconditionf("copy %d is set aside for hold %d", c.ID, h.ID).WithProcess(branch.TransferProcessName)
+	}
+	if errSet := t.Ship(now); errSet != nil {
+		return t, errSet
+	}
+	if errSet := c.Ship(t.ToBranchID); errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Copies().Save(&c); errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Transfers().Save(&t); errSet != nil {
+		return t, errSet
+	}
+	return t, nil
+}
+
+// receiveCopy puts the copy on the shelf of the branch it was sent to,
+// the hold it was sent for becomes ready.
+func (p policies) receiveCopy(tx store.Store, transferID uint, now time.Time) (branch.Transfer, *errdef.Error) {
+	t, errSet := lockTransfer(tx, transferID)
+	if errSet != nil {
+		return t, errSet
+	}
+	c, errSet := tx.Copies().Get(t.CopyID)
+	if errSet != nil {
+		return t, errSet
+	}
+	if errSet := t.Receive(now); errSet != nil {
+		return t, errSet
+	}
+	if errSet := c.Receive(); errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Copies().Save(&c); errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Transfers().Save(&t); errSet != nil {
+		return t, errSet
+	}
+	if _, errSet := p.openBookHolds(tx, c.BookID, now); errSet != nil {
+		return t, errSet
+	}
+	return t, nil
+}
+
+// dropTransfer cancels the transfer requested by staff. Transfers of
+// holds end with the hold.
+func dropTransfer(tx store.Store, transferID uint, now time.Time) (branch.Transfer, *errdef.Error) {
+	t, errSet := lockTransfer(tx, transferID)
+	if errSet != nil {
+		return t, errSet
+	}
+	if t.HoldID != nil && t.Status == branch.Requested {
+		return t, errdef.ErrFailedPreconditionf("transfer %d brings the copy for hold %d, cancel the hold instead", t.ID, *t.HoldID).WithProcess(branch.TransferProcessName)
+	}
+	if errSet := t.Cancel(now); errSet != nil {
+		return t, errSet
+	}
+	if errSet := tx.Transfers().Save(&t); errSet != nil {
+		return t, errSet
+	}
+	return t, nil
+}
+
+// releaseTransfer cancels the transfer requested for the hold when the
+// hold doesn't need the copy anymore. A copy already shipped goes on
+// and serves the queue where it arrives.
+func releaseTransfer(tx store.Store, h hold.Hold, now time.Time) *errdef.Error {
+	open := true
+	found, _, errSet := tx.Transfers().Find(store.TransferFilter{HoldID: h.ID, Open: &open}, firstByID)
+	if errSet != nil {
+		return errSet
+	}
+	for _, t := range found {
+		if t.Status != branch.Requested {
+			continue
+		}
+		if errSet := t.Cancel(now); errSet != nil {
+			return errSet
+		}
+		if errSet := tx.Transfers().Save(&t); errSet != nil {
+			return errSet
+		}
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+// trashPurgeInterval is how often the trash is checked for rows
+// deleted long enough ago to be purged.
+const trashPurgeInterval = time.Hour
+
+// Deleted people and books stay in the trash, they can be restored
+// or purged for good. Loans, holds and fines of purged people and
+// books are kept as history.
+
+// getTrashPeople returns deleted people, the last deleted first.
+func (s *server) getTrashPeople(w http.ResponseWriter, r *http.Request) {
+	params, errSet := paging.Parse(r.URL.Query(), person.TrashSortable, "-deleted_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	people, total, errSet := s.store.People().Trash(params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(people), func(i int) []interface{} {
+		return people[i].SortValues(params.Sort)
+	})
+	people = people[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &people, Paging: page})
+}
+
+// getTrashBooks returns deleted books, the last deleted first.
+func (s *server) getTrashBooks(w http.ResponseWriter, r *http.Request) {
+	params, errSet := paging.Parse(r.URL.Query(), book.TrashSortable, "-deleted_at")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	books, total, errSet := s.store.Books().Trash(params)
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	page, n := paging.NewPage(params, len(books), func(i int) []interface{} {
+		return books[i].SortValues(params.Sort)
+	})
+	books = books[:n]
+	page.Total = total
+
+	httpio.WriteJSON(w, http.StatusOK, paging.List{Data: &books, Paging: page})
+}
+
+// restorePerson moves the person out of the trash. Books deleted
+// together with the person have to be restored one by one.
+func (s *server) restorePerson(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var restored person.Person
+	err := s.store.Transaction(func(tx store.Store) error {
+		if errSet := tx.People().Restore(personID); errSet != nil {
+			return errSet
+		}
+		p, errSet := tx.People().Get(personID)
+		if errSet != nil {
+			return errSet
+		}
+		restored = p
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &restored)
+}
+
+// restoreBook moves the book out of the trash. The person the book
+// is assigned to has to be restored first.
+func (s *server) restoreBook(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var restored book.Book
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Books().GetDeleted(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		if b.PersonID != 0 {
+			if _, errSet := tx.People().Lock(uint(b.PersonID)); errSet != nil {
+				if errdef.IsNotFound(errSet) {
+					return errdef.ErrFailedPreconditionf("person %d of the book is deleted, restore them first", b.PersonID).WithMeta("field", "person_id")
+				}
+				return errSet
+			}
+		}
+		if errSet := tx.Books().Restore(bookID); errSet != nil {
+			return errSet
+		}
+		if restored, errSet = tx.Books().Get(bookID); errSet != nil {
+			return errSet
+		}
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &restored)
+}
+
+// purgePerson removes the person from the trash for good.
+func (s *server) purgePerson(w http.ResponseWriter, r *http.Request) {
+	personID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var purged person.Person
+	err := s.store.Transaction(func(tx store.Store) error {
+		p, errSet := tx.People().GetDeleted(personID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := tx.People().Purge(personID); errSet != nil {
+			return errSet
+		}
+		purged = p
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &purged)
+}
+
+// purgeBook removes the book from the trash for good.
+func (s *server) purgeBook(w http.ResponseWriter, r *http.Request) {
+	bookID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+
+	var purged book.Book
+	err := s.store.Transaction(func(tx store.Store) error {
+		b, errSet := tx.Books().GetDeleted(bookID)
+		if errSet != nil {
+			return errSet
+		}
+		if errSet := tx.Books().Purge(bookID); errSet != nil {
+			return errSet
+		}
+		purged = b
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+
+	httpio.WriteJSON(w, http.StatusOK, &purged)
+}
+
+// purgeTrash periodically purges people and books deleted more than
+// the retention ago until ctx is done.
+func purgeTrash(ctx context.Context, s store.Store, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if errSet := emptyTrash(s, time.Now().UTC().Add(-retention)); errSet != nil {
+			log.Println("failed to purge trash:", errSet)
+		}
+	}
+}
+
+// emptyTrash purges people and books deleted before the time.
+func emptyTrash(s store.Store, before time.Time) *errdef.Error {
+	books, errSet := s.Books().PurgeDeletedBefore(before)
+	if errSet != nil {
+		return errSet
+	}
+	people, errSet := s.People().PurgeDeletedBefore(before)
+	if errSet != nil {
+		return errSet
+	}
+	if books > 0 || people > 0 {
+		log.Printf("purged %d people and %d books from trash", people, books)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/investapp/backend/models/book"
+	"github.com/investapp/backend/models/person"
+	"github.com/investapp/backend/models/store/memstore"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/paging"
+)
+
+func TestTrash(t *testing.T) {
+	st := memstore.New()
+	srv := newTestServer(t, st)
+	for _, p := range []person.Person{{Name: "Jack", Email: "jack@gmail.com"}, {Name: "Jill", Email: "jill@gmail.com"}} {
+		require.Nil(t, st.People().Save(&p))
+	}
+	for _, b := range []book.Book{{Title: "Go", CallNumber: 1, PersonID: 1}, {Title: "Rust", CallNumber: 2, PersonID: 1}} {
+		require.Nil(t, st.Books().Save(&b))
+	}
+
+	// person with books is kept by default
+	w := do(t, srv, "DELETE", "/delete/person/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = do(t, srv, "DELETE", "/delete/person/1?books=reassign&to=1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = do(t, srv, "DELETE", "/delete/person/1?books=reassign&to=9", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// cascade moves the books to the trash too
+	w = do(t, srv, "DELETE", "/delete/person/1?books=cascade", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/trash/books", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var books struct{ Data []book.Book }
+	decode(t, w, &books)
+	assert.Len(t, books.Data, 2)
+
+	// book can't be restored before its person
+	w = do(t, srv, "POST", "/restore/book/1", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/restore/person/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/restore/book/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/restore/book/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "book is not in trash")
+
+	// reassign gives the books to another person
+	w = do(t, srv, "DELETE", "/delete/person/1?books=reassign&to=2", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	b, errSet := st.Books().Get(1)
+	require.Nil(t, errSet)
+	assert.Equal(t, 2, b.PersonID)
+
+	// purge removes the row for good
+	w = do(t, srv, "DELETE", "/purge/book/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, "book is not in trash")
+	w = do(t, srv, "DELETE", "/purge/person/1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = do(t, srv, "POST", "/restore/person/1", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// only rows deleted before the time are purged
+	require.Nil(t, emptyTrash(st, time.Now().Add(-time.Hour)))
+	_, errSet = st.Books().GetDeleted(2)
+	require.Nil(t, errSet)
+	require.Nil(t, emptyTrash(st, time.Now().Add(time.Hour)))
+	_, errSet = st.Books().GetDeleted(2)
+	assert.True(t, errdef.IsNotFound(errSet))
+}
+
+func TestTrashCursor(t *testing.T) {
+	st := memstore.New()
+	srv := newTestServer(t, st)
+	for _, p := range []person.Person{{Name: "Jack", Email: "jack@gmail.com"}, {Name: "Jill", Email: "jill@gmail.com"}} {
+		require.Nil(t, st.People().Save(&p))
+		require.Nil(t, st.People().Delete(p.ID))
+	}
+
+	// deleted_at is NULL on the live list, it can't be paged by it
+	w := do(t, srv, "GET", "/people?sort=deleted_at", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	w = do(t, srv, "GET", "/books?sort=-deleted_at", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	var list struct {
+		Data   []person.Person
+		Paging paging.Page
+	}
+	w = do(t, srv, "GET", "/trash/people?limit=1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "Jill", list.Data[0].Name)
+	require.NotEmpty(t, list.Paging.NextCursor)
+
+	w = do(t, srv, "GET", "/trash/people?limit=1&cursor="+list.Paging.NextCursor, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	list.Paging = paging.Page{}
+	decode(t, w, &list)
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "Jack", list.Data[0].Name)
+	assert.Empty(t, list.Paging.NextCursor)
+}
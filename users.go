@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/investapp/backend/models/store"
+	"github.com/investapp/backend/models/user"
+	"github.com/investapp/backend/pkg/errdef"
+	"github.com/investapp/backend/pkg/httpio"
+)
+
+// UserCMD manages user accounts.
+var UserCMD = &cli.Command{
+	Name:  "user",
+	Usage: "manage user accounts",
+	Flags: configFlags(),
+	Subcommands: []*cli.Command{
+		{
+			Name:      "role",
+			Usage:     "change role of the user, this is how the first admin is made",
+			ArgsUsage: "LOGIN member|staff|admin",
+			Action: func(ctx *cli.Context) error {
+				if ctx.NArg() != 2 {
+					return cli.Exit("login and role are required", 1)
+				}
+				role, errSet := user.ParseRole(ctx.Args().Get(1))
+				if errSet != nil {
+					return errSet
+				}
+				return withStore(ctx, func(s store.Store) error {
+					u, errSet := s.Users().ByLogin(ctx.Args().Get(0))
+					if errSet != nil {
+						return errSet
+					}
+					u.Role = role
+					if errSet := s.Users().Save(&u); errSet != nil {
+						return errSet
+					}
+					fmt.Printf("user %s is %s\n", u.Username, u.Role)
+					return nil
+				})
+			},
+		},
+	},
+}
+
+type roleRequest struct {
+	Role *user.Role
+}
+
+// setUserRole changes role of the user, the change takes effect with
+// the next request of the user. The last admin keeps the role, so
+// there is always someone who can change roles.
+func (s *server) setUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, errSet := idParam(r, "id")
+	if errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	var req roleRequest
+	if errSet := httpio.ReadJSON(r, &req); errSet != nil {
+		httpio.WriteErr(w, r, errSet)
+		return
+	}
+	if req.Role == nil {
+		httpio.WriteErr(w, r, errdef.ErrInvalidArgument("role is required").WithMeta("field", "role"))
+		return
+	}
+	var updated user.User
+	err := s.store.Transaction(func(tx store.Store) error {
+		admins := 0
+		if *req.Role != user.Admin {
+			n, errSet := tx.Users().CountRole(user.Admin)
+			if errSet != nil {
+				return errSet
+			}
+			admins = n
+		}
+		u, errSet := tx.Users().Get(userID)
+		if errSet != nil {
+			return errSet
+		}
+		if u.Role == user.Admin && *req.Role != user.Admin && admins < 2 {
+			return errdef.ErrFailedPreconditionf("user %s is the last admin", u.Username).WithMeta("field", "role")
+		}
+		u.Role = *req.Role
+		if errSet := tx.Users().Save(&u); errSet != nil {
+			return errSet
+		}
+		updated = u
+		return nil
+	})
+	if err != nil {
+		httpio.WriteErr(w, r, err)
+		return
+	}
+	httpio.WriteJSON(w, http.StatusOK, &updated)
+}